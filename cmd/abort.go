@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/jenkins"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	abortQueueIDs []string
+	assumeYes     bool
+)
+
+func init() {
+	rootCmd.AddCommand(abortCmd)
+
+	abortCmd.Flags().StringArrayVarP(&abortQueueIDs, "queue", "q", []string{}, "Queue number to cancel (or abort, if it has already started)")
+	abortCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	abortCmd.Flags().Duration("term-after", 30*time.Second, "Escalate to term if the build is still running after this long")
+	viper.BindPFlag("abort.term_after", abortCmd.Flags().Lookup("term-after"))
+	abortCmd.Flags().Duration("kill-after", 30*time.Second, "Escalate to kill if the build is still running this long after term")
+	viper.BindPFlag("abort.kill_after", abortCmd.Flags().Lookup("kill-after"))
+}
+
+var abortCmd = &cobra.Command{
+	Use:   "abort [build_id] [...build_id]",
+	Short: "Abort running builds or cancel queued ones",
+	Long: `Abort running builds. Each build is first asked to stop; if it is still running
+after --term-after it is terminated, and if it survives --kill-after longer it is
+hard-killed.
+
+Queued items are given with --queue. An item that is still waiting is removed
+from the queue; one that has already started is resolved to its build number and
+aborted like any other build.
+
+Examples:
+  jenkins abort 1234 1235
+  jenkins abort --queue 98765 --yes`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && len(abortQueueIDs) == 0 {
+			return fmt.Errorf("at least one build ID or --queue number is required")
+		}
+
+		pipeline := viper.GetString("pipeline")
+		buildIDs := append([]string{}, args...)
+
+		for _, queueID := range abortQueueIDs {
+			buildID, err := cancelQueued(queueID)
+			if err != nil {
+				return err
+			}
+			if buildID != "" {
+				buildIDs = append(buildIDs, buildID)
+			}
+		}
+
+		for _, buildID := range buildIDs {
+			if err := abortBuild(pipeline, buildID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+// cancelQueued cancels a queue item that hasn't started yet. If it has
+// already started, returns the build number so the build can be aborted.
+func cancelQueued(queueID string) (string, error) {
+	item, err := jenkinsClient.GetQueueItem(queueID)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up queue item %s: %w", queueID, err)
+	}
+
+	if item.Executable.Number != 0 {
+		buildID := strconv.Itoa(item.Executable.Number)
+		fmt.Printf("Queue item %s already started as build #%s\n", queueID, buildID)
+		return buildID, nil
+	}
+	if item.Cancelled {
+		fmt.Println(grayStyle.Render(fmt.Sprintf("Queue item %s is already cancelled", queueID)))
+		return "", nil
+	}
+
+	if !assumeYes {
+		ok, err := confirm(stdinReader, os.Stdout, fmt.Sprintf("Cancel queue item %s (%s)?", queueID, item.Why))
+		if err != nil || !ok {
+			fmt.Println(grayStyle.Render("Skipped"))
+			return "", err
+		}
+	}
+
+	if err := jenkinsClient.CancelQueueItem(queueID); err != nil {
+		return "", fmt.Errorf("failed to cancel queue item %s: %w", queueID, err)
+	}
+
+	// The item may have started between the lookup and the cancel
+	if item, err = jenkinsClient.GetQueueItem(queueID); err == nil && item.Executable.Number != 0 {
+		buildID := strconv.Itoa(item.Executable.Number)
+		fmt.Printf("Queue item %s started as build #%s before it could be cancelled\n", queueID, buildID)
+		return buildID, nil
+	}
+
+	fmt.Println(infoBoldStyle.Render(fmt.Sprintf("Cancelled queue item %s", queueID)))
+	return "", nil
+}
+
+// abortBuild stops a running build, escalating to term and kill if it
+// doesn't finish within the configured timeouts
+func abortBuild(pipeline, buildID string) error {
+	build, err := jenkinsClient.GetBuildInfo(pipeline, buildID)
+	if err != nil {
+		return fmt.Errorf("failed to get build %s: %w", buildID, err)
+	}
+
+	if !build.Building {
+		fmt.Println(grayStyle.Render(fmt.Sprintf("Build #%s has already finished [%s]", buildID, build.Result)))
+		return nil
+	}
+
+	if !assumeYes {
+		ok, err := confirm(stdinReader, os.Stdout, fmt.Sprintf("Abort build %s on [%s]?", build.FullDisplayName, pipeline))
+		if err != nil || !ok {
+			fmt.Println(grayStyle.Render("Skipped"))
+			return err
+		}
+	}
+
+	escalation := []struct {
+		signal  jenkins.BuildSignal
+		timeout time.Duration
+	}{
+		{jenkins.SignalStop, viper.GetDuration("abort.term_after")},
+		{jenkins.SignalTerm, viper.GetDuration("abort.kill_after")},
+		{jenkins.SignalKill, viper.GetDuration("abort.kill_after")},
+	}
+
+	for _, step := range escalation {
+		fmt.Printf("Sending %s to build #%s...\n", step.signal, buildID)
+		if err := jenkinsClient.SignalBuild(pipeline, buildID, step.signal); err != nil {
+			return fmt.Errorf("failed to %s build %s: %w", step.signal, buildID, err)
+		}
+
+		finished, err := waitForBuildToFinish(pipeline, buildID, step.timeout)
+		if err != nil {
+			return err
+		}
+		if finished != nil {
+			fmt.Println(noStyle.Render(fmt.Sprintf("%s: Build [%s] finished as [%s]",
+				infoBoldStyle.Render(buildID), infoBoldStyle.Render(finished.FullDisplayName), resultStyle(finished.Result).Render(finished.Result))))
+			return nil
+		}
+		verbose("Build [%s] still running after %s", buildID, step.signal)
+	}
+
+	return fmt.Errorf("build %s is still running after kill", buildID)
+}
+
+// waitForBuildToFinish polls a build until it stops building or timeout
+// passes. Returns nil if the build is still running.
+func waitForBuildToFinish(pipeline, buildID string, timeout time.Duration) (*jenkins.WorkflowRun, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		build, err := jenkinsClient.GetBuildInfo(pipeline, buildID)
+		if err != nil {
+			return nil, err
+		}
+		if !build.Building {
+			return build, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, nil
+		}
+		time.Sleep(DefaultPollInterval)
+	}
+}
@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/formatting"
+	"jenkins/internal/jenkins"
+	"jenkins/internal/util"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// agentLowDisk is the free space below which a node's disk is
+	// highlighted, the same default Jenkins uses to take a node offline
+	agentLowDisk = 1 << 30
+	// agentSlowRatio is how much slower than the stage's overall average a
+	// stage must be on an agent for the agent to be flagged
+	agentSlowRatio = 1.5
+)
+
+var (
+	agentsLabel   string
+	agentsOffline bool
+	agentsAnalyze bool
+	agentsLimit   int
+	agentsFilter  []string
+)
+
+func init() {
+	rootCmd.AddCommand(agentsCmd)
+
+	agentsCmd.Flags().StringVarP(&agentsLabel, "label", "L", "", "Only list nodes with this label")
+	agentsCmd.Flags().BoolVar(&agentsOffline, "offline", false, "Only list offline nodes")
+	agentsCmd.Flags().BoolVarP(&agentsAnalyze, "analyze", "a", false, "Analyze which agents ran the stages of recent builds instead of listing nodes")
+	agentsCmd.Flags().IntVarP(&agentsLimit, "limit", "l", 50, "Number of recent builds to analyze")
+	agentsCmd.Flags().StringArrayVarP(&agentsFilter, "filter", "f", []string{}, "Only analyze stages matching any of these filters (case insensitive)")
+}
+
+var agentsCmd = &cobra.Command{
+	Use:   "agents",
+	Short: "List Jenkins agents or analyze which agents ran recent stages",
+	Long: `List the nodes of the Jenkins instance: whether they are online, their
+labels, busy and total executors, and the free disk, temporary and swap space
+Jenkins last measured. Disks with less than 1 GiB free are highlighted.
+
+With --analyze, the last --limit builds of the pipeline are read instead, to
+show which agents ran each top-level stage, how long the stage took on each
+agent compared with its overall average, and how often it failed there. Agents
+on which a stage is at least 1.5 times slower than average, or fails at least
+twice as often, are listed as suspects.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if agentsAnalyze {
+			return analyzeAgentsCmd(cmd)
+		}
+
+		computers, err := jenkinsClient.GetComputers()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		shown := []jenkins.Computer{}
+		for _, c := range computers {
+			if agentsOffline && !c.Offline {
+				continue
+			}
+			if agentsLabel != "" && !slices.Contains(c.Labels(), agentsLabel) {
+				continue
+			}
+			shown = append(shown, c)
+		}
+		if len(shown) == 0 {
+			fmt.Println(grayStyle.Render("No matching nodes"))
+			return nil
+		}
+
+		printComputers(shown)
+		return nil
+	},
+}
+
+func printComputers(computers []jenkins.Computer) {
+	busy, total, offline := 0, 0, 0
+	t := reportTable("NODE", "STATUS", "EXECUTORS", "LABELS", "DISK", "TEMP", "MEMORY", "SWAP")
+	for _, c := range computers {
+		status := c.Status()
+		if c.Offline {
+			offline++
+			status = orangeStyle.Render(status)
+			if c.OfflineCauseReason != "" {
+				status += " " + grayStyle.Render("("+c.OfflineCauseReason+")")
+			}
+		}
+		busy += c.BusyExecutors()
+		total += c.NumExecutors
+
+		mem, swap := "-", "-"
+		if s := c.MonitorData.Swap; s != nil {
+			mem = fmt.Sprintf("%s / %s", formatSize(s.AvailablePhysicalMemory), formatSize(s.TotalPhysicalMemory))
+			swap = fmt.Sprintf("%s / %s", formatSize(s.AvailableSwapSpace), formatSize(s.TotalSwapSpace))
+		}
+		t.Row(c.DisplayName, status, fmt.Sprintf("%d / %d", c.BusyExecutors(), c.NumExecutors), strings.Join(c.Labels(), " "),
+			freeSpace(c.MonitorData.DiskSpace), freeSpace(c.MonitorData.TempSpace), mem, swap)
+	}
+	fmt.Println(t)
+	fmt.Println(grayStyle.Render(fmt.Sprintf("%d node(s), %d offline, %d of %d executor(s) busy", len(computers), offline, busy, total)))
+}
+
+// freeSpace renders a space monitor reading, highlighting low free space
+func freeSpace(m *jenkins.SpaceMonitor) string {
+	if m == nil {
+		return "-"
+	}
+	if m.Size < agentLowDisk {
+		return orangeStyle.Render(formatSize(m.Size))
+	}
+	return formatSize(m.Size)
+}
+
+func analyzeAgentsCmd(cmd *cobra.Command) error {
+	pipeline := viper.GetString("pipeline")
+
+	var lcFilter []string
+	for _, f := range agentsFilter {
+		lcFilter = append(lcFilter, strings.ToLower(f))
+	}
+
+	builds, err := jenkinsClient.GetBuilds(pipeline, agentsLimit)
+	if err != nil {
+		return err
+	}
+	finished := []jenkins.WorkflowRun{}
+	for _, b := range builds {
+		if !b.Building {
+			finished = append(finished, b)
+		}
+	}
+	if len(finished) == 0 {
+		return fmt.Errorf("no finished builds found on %s", pipeline)
+	}
+
+	fmt.Printf("Analyzing agents of %d build(s) on [%s]...\n", len(finished), pipeline)
+	details := fetchJobDetails(pipeline, finished)
+	jobs := make([]*jenkins.Job, 0, len(details))
+	for _, b := range finished {
+		if job, ok := details[b.ID]; ok {
+			jobs = append(jobs, job)
+		}
+	}
+
+	cmd.SilenceUsage = true
+
+	stages := analyzeAgents(jobs, lcFilter)
+	if len(stages) == 0 {
+		fmt.Println(grayStyle.Render("No agent information in the stage data"))
+		return nil
+	}
+
+	fmt.Println()
+	printStageAgents(stages)
+	fmt.Println()
+	printAgentSummary(agentSummaries(stages))
+	fmt.Println()
+	printAgentSuspects(stages)
+	return nil
+}
+
+// agentStats are the runs of a stage, overall or on one agent
+type agentStats struct {
+	failureCount
+	// Millis are the durations of the successful runs
+	Millis []int
+}
+
+// Avg returns the average duration of the successful runs
+func (s *agentStats) Avg() time.Duration {
+	return time.Duration(util.Avg(s.Millis) * float64(time.Millisecond))
+}
+
+func (s *agentStats) add(stage jenkins.Stage) {
+	s.failureCount.add(stage)
+	if stage.Status == "SUCCESS" {
+		s.Millis = append(s.Millis, stage.Duration)
+	}
+}
+
+// stageByAgent is how a top-level stage ran on each agent
+type stageByAgent struct {
+	Name    string
+	Overall agentStats
+	Agents  map[string]*agentStats
+}
+
+// add counts a run of the stage overall and on the agent it ran on, if any
+func (s *stageByAgent) add(stage jenkins.Stage) {
+	s.Overall.add(stage)
+	if stage.ExecNode == "" {
+		return
+	}
+	if s.Agents[stage.ExecNode] == nil {
+		s.Agents[stage.ExecNode] = &agentStats{}
+	}
+	s.Agents[stage.ExecNode].add(stage)
+}
+
+// Ratio returns how the stage's average time on agent compares with its
+// overall average, and false if either has no successful runs
+func (s *stageByAgent) Ratio(agent string) (float64, bool) {
+	a := s.Agents[agent]
+	if a == nil || len(a.Millis) == 0 || len(s.Overall.Millis) == 0 || s.Overall.Avg() == 0 {
+		return 0, false
+	}
+	return float64(a.Avg()) / float64(s.Overall.Avg()), true
+}
+
+// Slow reports whether the stage is at least agentSlowRatio times slower on
+// agent than overall, over at least two successful runs
+func (s *stageByAgent) Slow(agent string) bool {
+	ratio, ok := s.Ratio(agent)
+	return ok && len(s.Agents[agent].Millis) >= 2 && ratio >= agentSlowRatio
+}
+
+// Failing reports whether the stage failed on agent at least twice, and at
+// least twice as often as overall
+func (s *stageByAgent) Failing(agent string) bool {
+	a := s.Agents[agent]
+	return a != nil && a.Failures >= 2 && a.Rate() >= 2*s.Overall.Rate()
+}
+
+// SuspectAgent returns the agent on which the stage fails most often among
+// those it is Failing on
+func (s *stageByAgent) SuspectAgent() (string, bool) {
+	suspect, worst := "", 0.0
+	for name, a := range s.Agents {
+		if s.Failing(name) && a.Rate() > worst {
+			suspect, worst = name, a.Rate()
+		}
+	}
+	return suspect, suspect != ""
+}
+
+// AgentNames returns the agents that ran the stage, slowest first
+func (s *stageByAgent) AgentNames() []string {
+	names := make([]string, 0, len(s.Agents))
+	for name := range s.Agents {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := s.Agents[names[i]], s.Agents[names[j]]
+		if a.Avg() != b.Avg() {
+			return a.Avg() > b.Avg()
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// analyzeAgents collects the runs of every top-level stage matching the
+// filters, per agent. Aborted and skipped stages and stages without an agent
+// are not counted. Stages are sorted by name.
+func analyzeAgents(jobs []*jenkins.Job, lcFilter []string) []*stageByAgent {
+	stages := map[string]*stageByAgent{}
+	for _, job := range jobs {
+		for _, stage := range job.Stages {
+			if stage.ExecNode == "" || !countedRun(stage) {
+				continue
+			}
+			if !matchesFilters(stage.Name, lcFilter, false) {
+				continue
+			}
+			s, ok := stages[stage.Name]
+			if !ok {
+				s = &stageByAgent{Name: stage.Name, Agents: map[string]*agentStats{}}
+				stages[stage.Name] = s
+			}
+			s.add(stage)
+		}
+	}
+
+	sorted := make([]*stageByAgent, 0, len(stages))
+	for _, s := range stages {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// agentSummary sums up the stages an agent ran
+type agentSummary struct {
+	Name string
+	failureCount
+	// Relative is the agent's stage time relative to the stages' overall
+	// averages, weighted by successful runs: 1.2 means 20% slower
+	Relative float64
+}
+
+// agentSummaries sums up the stage runs of each agent, slowest first
+func agentSummaries(stages []*stageByAgent) []agentSummary {
+	byName := map[string]*agentSummary{}
+	weights := map[string]int{}
+	for _, s := range stages {
+		for name, a := range s.Agents {
+			sum := byName[name]
+			if sum == nil {
+				sum = &agentSummary{Name: name}
+				byName[name] = sum
+			}
+			sum.Runs += a.Runs
+			sum.Failures += a.Failures
+			if ratio, ok := s.Ratio(name); ok {
+				sum.Relative += ratio * float64(len(a.Millis))
+				weights[name] += len(a.Millis)
+			}
+		}
+	}
+
+	summaries := make([]agentSummary, 0, len(byName))
+	for name, sum := range byName {
+		if weights[name] > 0 {
+			sum.Relative /= float64(weights[name])
+		}
+		summaries = append(summaries, *sum)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Relative != summaries[j].Relative {
+			return summaries[i].Relative > summaries[j].Relative
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
+// relative formats a ratio to the average as a signed percentage
+func relative(ratio float64) string {
+	if ratio == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.0f%%", (ratio-1)*100)
+}
+
+func printStageAgents(stages []*stageByAgent) {
+	t := reportTable("STAGE", "AGENT", "RUNS", "FAILED", "AVG", "VS AVG")
+	for _, s := range stages {
+		t.Row(s.Name, "(all)", strconv.Itoa(s.Overall.Runs), strconv.Itoa(s.Overall.Failures), formatting.Duration(s.Overall.Avg()), "")
+		for _, name := range s.AgentNames() {
+			a := s.Agents[name]
+			ratio, _ := s.Ratio(name)
+			vs := relative(ratio)
+			if s.Slow(name) {
+				vs = orangeStyle.Render(vs)
+			}
+			failed := strconv.Itoa(a.Failures)
+			if s.Failing(name) {
+				failed = failureStyle.Render(failed)
+			}
+			avg := "-"
+			if len(a.Millis) > 0 {
+				avg = formatting.Duration(a.Avg())
+			}
+			t.Row("", name, strconv.Itoa(a.Runs), failed, avg, vs)
+		}
+	}
+	fmt.Println(infoBoldStyle.Render("STAGES BY AGENT:"))
+	fmt.Println(t)
+}
+
+func printAgentSummary(summaries []agentSummary) {
+	t := reportTable("AGENT", "STAGE RUNS", "FAILED", "RATE", "VS AVG")
+	for _, s := range summaries {
+		t.Row(s.Name, strconv.Itoa(s.Runs), strconv.Itoa(s.Failures), percent(s.Rate()), relative(s.Relative))
+	}
+	fmt.Println(infoBoldStyle.Render("AGENTS:"))
+	fmt.Println(t)
+}
+
+func printAgentSuspects(stages []*stageByAgent) {
+	suspects := []string{}
+	for _, s := range stages {
+		for _, name := range s.AgentNames() {
+			a := s.Agents[name]
+			if s.Slow(name) {
+				ratio, _ := s.Ratio(name)
+				suspects = append(suspects, fmt.Sprintf("  %s is %.1fx slower on %s (%s vs %s)", s.Name, ratio, infoBoldStyle.Render(name),
+					formatting.Duration(a.Avg()), formatting.Duration(s.Overall.Avg())))
+			}
+			if s.Failing(name) {
+				suspects = append(suspects, fmt.Sprintf("  %s fails %s of the time on %s (%s overall)", s.Name, percent(a.Rate()),
+					infoBoldStyle.Render(name), percent(s.Overall.Rate())))
+			}
+		}
+	}
+	if len(suspects) == 0 {
+		fmt.Println(successStyle.Render("✓ No agent stands out as slow or failing"))
+		return
+	}
+	fmt.Println(orangeStyle.Render("SUSPECT AGENTS:"))
+	for _, s := range suspects {
+		fmt.Println(s)
+	}
+}
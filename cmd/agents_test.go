@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"testing"
+	"time"
+)
+
+func agentJobs() []*jenkins.Job {
+	job := func(build, test, deploy jenkins.Stage) *jenkins.Job {
+		return &jenkins.Job{Stages: []jenkins.Stage{build, test, deploy}}
+	}
+	return []*jenkins.Job{
+		job(testStage("Build", "SUCCESS", time.Minute, "fast"), testStage("Test", "SUCCESS", time.Minute, "fast"), testStage("Deploy", "SUCCESS", time.Minute, "")),
+		job(testStage("Build", "SUCCESS", time.Minute, "fast"), testStage("Test", "SUCCESS", time.Minute, "fast"), testStage("Deploy", "ABORTED", time.Minute, "fast")),
+		job(testStage("Build", "SUCCESS", 4*time.Minute, "slow"), testStage("Test", "FAILED", time.Minute, "slow"), testStage("Deploy", "SUCCESS", time.Minute, "fast")),
+		job(testStage("Build", "SUCCESS", 4*time.Minute, "slow"), testStage("Test", "FAILED", time.Minute, "slow"), testStage("Deploy", "SUCCESS", time.Minute, "fast")),
+		job(testStage("Build", "SUCCESS", time.Minute, "fast"), testStage("Test", "SUCCESS", time.Minute, "fast"), testStage("Deploy", "SUCCESS", time.Minute, "fast")),
+	}
+}
+
+func TestAnalyzeAgents(t *testing.T) {
+	stages := analyzeAgents(agentJobs(), nil)
+	if len(stages) != 3 || stages[0].Name != "Build" || stages[2].Name != "Test" {
+		t.Fatalf("analyzeAgents() = %+v", stages)
+	}
+
+	build, deploy, test := stages[0], stages[1], stages[2]
+	if build.Overall.Runs != 5 || build.Overall.Avg() != 132*time.Second || build.Agents["slow"].Avg() != 4*time.Minute {
+		t.Errorf("Build overall = %+v, slow = %+v", build.Overall, build.Agents["slow"])
+	}
+	if ratio, ok := build.Ratio("slow"); !ok || ratio < 1.8 || ratio > 1.82 || !build.Slow("slow") || build.Slow("fast") {
+		t.Errorf("Build on slow: ratio %v, slow %v", ratio, build.Slow("slow"))
+	}
+	if names := build.AgentNames(); names[0] != "slow" {
+		t.Errorf("AgentNames() = %q, want slowest first", names)
+	}
+
+	if deploy.Overall.Runs != 3 {
+		t.Errorf("Deploy runs = %d, want aborted runs and runs without an agent left out", deploy.Overall.Runs)
+	}
+
+	if !test.Failing("slow") || test.Failing("fast") {
+		t.Errorf("Test failures: slow %+v, fast %+v", test.Agents["slow"], test.Agents["fast"])
+	}
+	if _, ok := test.Ratio("slow"); ok {
+		t.Error("Ratio() should be unknown for an agent without successful runs")
+	}
+
+	if filtered := analyzeAgents(agentJobs(), []string{"test", "deploy"}); len(filtered) != 2 {
+		t.Errorf("filtered analysis has %d stages, want 2", len(filtered))
+	}
+}
+
+func TestAgentSummaries(t *testing.T) {
+	summaries := agentSummaries(analyzeAgents(agentJobs(), nil))
+	if len(summaries) != 2 || summaries[0].Name != "slow" {
+		t.Fatalf("agentSummaries() = %+v", summaries)
+	}
+	slow, fast := summaries[0], summaries[1]
+	if slow.Runs != 4 || slow.Failures != 2 || relative(slow.Relative) != "+82%" {
+		t.Errorf("slow = %+v", slow)
+	}
+	if relative(fast.Relative) != "-18%" {
+		t.Errorf("fast = %+v, relative %s", fast, relative(fast.Relative))
+	}
+	if relative(0) != "-" {
+		t.Errorf("relative(0) = %q", relative(0))
+	}
+}
+
+func TestFreeSpace(t *testing.T) {
+	if got := freeSpace(nil); got != "-" {
+		t.Errorf("freeSpace(nil) = %q", got)
+	}
+	if got := freeSpace(&jenkins.SpaceMonitor{Size: 2 << 30}); got != "2.0 GiB" {
+		t.Errorf("freeSpace(2 GiB) = %q", got)
+	}
+}
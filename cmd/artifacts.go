@@ -0,0 +1,455 @@
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"jenkins/internal/jenkins"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// artifactRefresh is how often the download progress bars are redrawn
+const artifactRefresh = 200 * time.Millisecond
+
+var (
+	artifactsDir      string
+	artifactsParallel int
+	artifactsRetries  int
+	artifactsPlain    bool
+)
+
+func init() {
+	rootCmd.AddCommand(artifactsCmd)
+	artifactsCmd.AddCommand(artifactsGetCmd)
+
+	artifactsGetCmd.Flags().StringVarP(&artifactsDir, "dir", "d", ".", "Directory to download into")
+	artifactsGetCmd.Flags().IntVarP(&artifactsParallel, "parallel", "p", 4, "Number of files to download at once")
+	artifactsGetCmd.Flags().IntVar(&artifactsRetries, "retries", 3, "Times to resume a failed download before giving up")
+	artifactsGetCmd.Flags().BoolVar(&artifactsPlain, "plain", false, "Print a line per file instead of progress bars")
+}
+
+var artifactsCmd = &cobra.Command{
+	Use:   "artifacts [build_id]",
+	Short: "List the artifacts archived by a build",
+	Long: `List the files a build archived, with their sizes and, when the build
+fingerprinted them, their MD5 checksums.
+
+The build can be a build number or a product name, which resolves to the latest
+build of that product. Use 'artifacts get' to download them.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline := viper.GetString("pipeline")
+		buildID, err := resolveBuildID(args[0])
+		if err != nil {
+			return err
+		}
+
+		artifacts, err := jenkinsClient.GetArtifacts(pipeline, buildID)
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		if len(artifacts) == 0 {
+			fmt.Println(grayStyle.Render(fmt.Sprintf("Build %s archived no artifacts", buildID)))
+			return nil
+		}
+		fetchArtifactSizes(pipeline, buildID, artifacts)
+
+		var total int64
+		t := reportTable("ARTIFACT", "SIZE", "MD5")
+		for _, a := range artifacts {
+			total += max(a.Size, 0)
+			t.Row(a.RelativePath, formatSize(a.Size), valueOr(a.MD5, "-"))
+		}
+		fmt.Println(t)
+		fmt.Println(grayStyle.Render(fmt.Sprintf("%d artifact(s), %s", len(artifacts), formatSize(total))))
+		return nil
+	},
+}
+
+var artifactsGetCmd = &cobra.Command{
+	Use:   "get [build_id] [glob...]",
+	Short: "Download the artifacts of a build",
+	Long: `Download the artifacts of a build into --dir, keeping their relative paths.
+
+Globs select which artifacts to download and are matched against both the
+relative path and the file name, so "*.tar.gz" and "dist/*" both work. Without
+globs every artifact is downloaded.
+
+Files are written to a .part file first. A download that fails is resumed
+where it stopped, up to --retries times, and running the command again resumes
+any .part files left behind. Files that were fingerprinted are checked against
+their MD5 checksum, and files already downloaded are skipped.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline := viper.GetString("pipeline")
+		buildID, err := resolveBuildID(args[0])
+		if err != nil {
+			return err
+		}
+
+		artifacts, err := jenkinsClient.GetArtifacts(pipeline, buildID)
+		if err != nil {
+			return err
+		}
+		matched, err := matchArtifacts(artifacts, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(matched) == 0 {
+			return fmt.Errorf("no artifacts of build %s match %s", buildID, strings.Join(args[1:], " "))
+		}
+
+		cmd.SilenceUsage = true
+
+		fetchArtifactSizes(pipeline, buildID, matched)
+		fmt.Printf("Downloading %d artifact(s) of build %s to %s\n", len(matched), buildID, artifactsDir)
+
+		transfers := make([]*artifactTransfer, len(matched))
+		for i, a := range matched {
+			transfers[i] = &artifactTransfer{Artifact: a}
+			transfers[i].Total.Store(a.Size)
+		}
+
+		live := !artifactsPlain && term.IsTerminal(os.Stdout.Fd())
+		done := make(chan struct{})
+		go func() {
+			downloadArtifacts(pipeline, buildID, transfers, live)
+			close(done)
+		}()
+		if live {
+			showTransfers(transfers, done)
+		} else {
+			<-done
+		}
+
+		failed := 0
+		for _, t := range transfers {
+			if t.Err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d artifact(s) failed to download", failed, len(transfers))
+		}
+		fmt.Println(successStyle.Render(fmt.Sprintf("✓ Downloaded %d artifact(s) to %s", len(transfers), artifactsDir)))
+		return nil
+	},
+}
+
+// matchArtifacts returns the artifacts whose relative path or file name
+// matches any of the globs, or all of them if there are no globs
+func matchArtifacts(artifacts []jenkins.Artifact, globs []string) ([]jenkins.Artifact, error) {
+	if len(globs) == 0 {
+		return artifacts, nil
+	}
+	for _, g := range globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, NewValidationError("glob", g, err.Error())
+		}
+	}
+
+	matched := []jenkins.Artifact{}
+	for _, a := range artifacts {
+		for _, g := range globs {
+			byPath, _ := path.Match(g, a.RelativePath)
+			byName, _ := path.Match(g, a.FileName)
+			if byPath || byName {
+				matched = append(matched, a)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+// fetchArtifactSizes fills in the size of each artifact, concurrently.
+// Sizes that cannot be read are left at -1.
+func fetchArtifactSizes(pipeline, buildID string, artifacts []jenkins.Artifact) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, historyWorkers)
+	for i := range artifacts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			size, err := jenkinsClient.GetArtifactSize(pipeline, buildID, artifacts[i].RelativePath)
+			if err != nil {
+				verbose("Failed to read the size of [%s] [%v]", artifacts[i].RelativePath, err)
+				return
+			}
+			artifacts[i].Size = size
+		}()
+	}
+	wg.Wait()
+}
+
+// formatSize formats a size in bytes with a binary unit, "-" if unknown
+func formatSize(n int64) string {
+	if n < 0 {
+		return "-"
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// valueOr returns s, or fallback if s is empty
+func valueOr(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
+
+// artifactTransfer tracks the download of one artifact
+type artifactTransfer struct {
+	jenkins.Artifact
+	// Done is the number of bytes on disk so far, updated while downloading
+	Done atomic.Int64
+	// Total is the artifact size, -1 until known
+	Total atomic.Int64
+	// Finished is set once the download has ended, successfully or not
+	Finished atomic.Bool
+	// Skipped is set if the file was already downloaded
+	Skipped bool
+	Err     error
+}
+
+// downloadArtifacts downloads the transfers with artifactsParallel workers.
+// Unless live, a line is printed as each one finishes.
+func downloadArtifacts(pipeline, buildID string, transfers []*artifactTransfer, live bool) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(artifactsParallel, 1))
+	for _, t := range transfers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			dest, err := artifactDest(artifactsDir, t.RelativePath)
+			if err == nil {
+				t.Skipped, err = downloadArtifact(pipeline, buildID, t, dest, artifactsRetries)
+			}
+			t.Err = err
+			t.Finished.Store(true)
+			if !live {
+				fmt.Println(t.status())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// artifactDest returns where an artifact is saved under dir, refusing
+// relative paths that would escape it
+func artifactDest(dir, relativePath string) (string, error) {
+	local := filepath.FromSlash(relativePath)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("refusing to write artifact outside %s: %s", dir, relativePath)
+	}
+	return filepath.Join(dir, local), nil
+}
+
+// downloadArtifact downloads an artifact to dest through a .part file,
+// resuming from wherever an earlier attempt stopped. Returns true if dest
+// already held the artifact.
+func downloadArtifact(pipeline, buildID string, t *artifactTransfer, dest string, retries int) (bool, error) {
+	// An existing file is only trusted when its checksum matches or, without
+	// a fingerprint, when its size matches the artifact's known size
+	if info, err := os.Stat(dest); err == nil {
+		size := t.Total.Load()
+		trusted := size >= 0 && info.Size() == size
+		if t.MD5 != "" {
+			trusted = (size < 0 || info.Size() == size) && verifyMD5(dest, t.MD5) == nil
+		}
+		if trusted {
+			t.Done.Store(info.Size())
+			return true, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return false, err
+	}
+
+	part := dest + ".part"
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			verbose("Retrying [%s] after [%v], attempt %d of %d", t.RelativePath, err, attempt, retries)
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		if err = resumeDownload(pipeline, buildID, t, part); err == nil {
+			break
+		}
+		if errors.Is(err, jenkins.ErrInvalidRange) {
+			// The .part file is longer than the artifact, so it is stale
+			os.Remove(part)
+		}
+		if errors.Is(err, jenkins.ErrNotFound) || errors.Is(err, jenkins.ErrUnauthorized) {
+			return false, err
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if t.MD5 != "" {
+		if err := verifyMD5(part, t.MD5); err != nil {
+			os.Remove(part)
+			return false, err
+		}
+	}
+	return false, os.Rename(part, dest)
+}
+
+// resumeDownload appends the rest of an artifact to its .part file, or
+// rewrites the file if Jenkins sends the whole artifact
+func resumeDownload(pipeline, buildID string, t *artifactTransfer, part string) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+	if size := t.Total.Load(); size >= 0 && offset == size {
+		t.Done.Store(offset)
+		return nil
+	}
+
+	download, err := jenkinsClient.DownloadArtifact(pipeline, buildID, t.RelativePath, offset)
+	if err != nil {
+		return err
+	}
+	defer download.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if download.Offset == 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if download.Size >= 0 {
+		t.Total.Store(download.Size)
+	}
+	t.Done.Store(download.Offset)
+	_, err = io.Copy(f, &countingReader{r: download, n: &t.Done})
+	return err
+}
+
+// countingReader adds the bytes read to n
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// verifyMD5 checks a file against a hex MD5 checksum
+func verifyMD5(file, want string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", file, got, want)
+	}
+	return nil
+}
+
+// status renders a finished transfer as a single line
+func (t *artifactTransfer) status() string {
+	switch {
+	case t.Err != nil:
+		return failureStyle.Render(fmt.Sprintf("✗ %s: %v", t.RelativePath, t.Err))
+	case t.Skipped:
+		return grayStyle.Render(fmt.Sprintf("- %s already downloaded", t.RelativePath))
+	}
+	checked := ""
+	if t.MD5 != "" {
+		checked = ", checksum OK"
+	}
+	return successStyle.Render("✓ ") + fmt.Sprintf("%s (%s%s)", t.RelativePath, formatSize(t.Done.Load()), checked)
+}
+
+// showTransfers draws a progress bar per transfer in place until done is
+// closed, then prints the final status of each
+func showTransfers(transfers []*artifactTransfer, done <-chan struct{}) {
+	view := &liveView{out: os.Stdout}
+	if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil {
+		view.width = width
+	}
+	bar := progress.New(progress.WithDefaultGradient(), progress.WithWidth(30))
+
+	ticker := time.NewTicker(artifactRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			view.Render(renderTransfers(transfers, bar))
+		case <-done:
+			view.Render("")
+			for _, t := range transfers {
+				fmt.Println(t.status())
+			}
+			return
+		}
+	}
+}
+
+// renderTransfers renders the unfinished transfers, one bar per line
+func renderTransfers(transfers []*artifactTransfer, bar progress.Model) string {
+	lines := []string{}
+	for _, t := range transfers {
+		if t.Finished.Load() {
+			continue
+		}
+		done, size := t.Done.Load(), t.Total.Load()
+		name := ansi.Truncate(t.RelativePath, 40, "…")
+		if size <= 0 {
+			lines = append(lines, fmt.Sprintf("%-40s %s", name, formatSize(done)))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-40s %s %s / %s", name, bar.ViewAs(float64(done)/float64(size)),
+			formatSize(done), formatSize(size)))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"jenkins/internal/jenkins"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchArtifacts(t *testing.T) {
+	artifacts := []jenkins.Artifact{
+		{FileName: "app.tar.gz", RelativePath: "dist/app.tar.gz"},
+		{FileName: "app.zip", RelativePath: "dist/app.zip"},
+		{FileName: "junit.xml", RelativePath: "reports/junit.xml"},
+	}
+
+	tests := []struct {
+		globs []string
+		want  int
+	}{
+		{globs: nil, want: 3},
+		{globs: []string{"*.tar.gz"}, want: 1},
+		{globs: []string{"dist/*"}, want: 2},
+		{globs: []string{"*.zip", "reports/*"}, want: 2},
+		{globs: []string{"*.exe"}, want: 0},
+	}
+	for _, tt := range tests {
+		matched, err := matchArtifacts(artifacts, tt.globs)
+		if err != nil || len(matched) != tt.want {
+			t.Errorf("matchArtifacts(%q) = %d artifacts, %v; want %d", tt.globs, len(matched), err, tt.want)
+		}
+	}
+
+	if _, err := matchArtifacts(artifacts, []string{"[a-"}); err == nil {
+		t.Error("matchArtifacts() with a malformed glob should fail")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{-1: "-", 0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 20: "5.0 MiB", 3 << 30: "3.0 GiB"}
+	for n, want := range tests {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestArtifactDest(t *testing.T) {
+	if dest, err := artifactDest("out", "dist/app.zip"); err != nil || dest != filepath.Join("out", "dist", "app.zip") {
+		t.Errorf("artifactDest() = %q, %v", dest, err)
+	}
+	if _, err := artifactDest("out", "../etc/passwd"); err == nil {
+		t.Error("artifactDest() should refuse paths outside the directory")
+	}
+}
+
+func TestDownloadArtifactResumes(t *testing.T) {
+	content := strings.Repeat("artifact content ", 100)
+	sum := md5.Sum([]byte(content))
+	ranged := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranged = ranged || r.Header.Get("Range") != ""
+		http.ServeContent(w, r, "app.zip", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	oldClient := jenkinsClient
+	defer func() { jenkinsClient = oldClient }()
+	jenkinsClient = jenkins.NewClient(jenkins.Config{Host: server.URL, User: "test", APIKey: "test"})
+
+	dest := filepath.Join(t.TempDir(), "dist", "app.zip")
+	os.MkdirAll(filepath.Dir(dest), 0o755)
+	if err := os.WriteFile(dest+".part", []byte(content[:500]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := &artifactTransfer{Artifact: jenkins.Artifact{RelativePath: "dist/app.zip", MD5: hex.EncodeToString(sum[:])}}
+	transfer.Total.Store(int64(len(content)))
+	skipped, err := downloadArtifact("master", "42", transfer, dest, 0)
+	if err != nil || skipped {
+		t.Fatalf("downloadArtifact() = %v, %v", skipped, err)
+	}
+	if got, _ := os.ReadFile(dest); string(got) != content || !ranged {
+		t.Errorf("download was not resumed from the .part file (ranged %v, %d bytes)", ranged, len(got))
+	}
+	if _, err := os.Stat(dest + ".part"); !os.IsNotExist(err) {
+		t.Error(".part file was not renamed")
+	}
+
+	if skipped, err := downloadArtifact("master", "42", transfer, dest, 0); err != nil || !skipped {
+		t.Errorf("second downloadArtifact() = %v, %v; want skipped", skipped, err)
+	}
+
+	transfer.MD5 = strings.Repeat("0", 32)
+	other := filepath.Join(filepath.Dir(dest), "other.zip")
+	if _, err := downloadArtifact("master", "42", transfer, other, 0); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("downloadArtifact() with a bad checksum = %v", err)
+	}
+	if _, err := os.Stat(other + ".part"); !os.IsNotExist(err) {
+		t.Error("corrupt .part file was kept")
+	}
+
+	// Without a fingerprint or a known size an existing file can't be trusted
+	unknown := &artifactTransfer{Artifact: jenkins.Artifact{RelativePath: "dist/app.zip"}}
+	unknown.Total.Store(-1)
+	if err := os.WriteFile(other, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if skipped, err := downloadArtifact("master", "42", unknown, other, 0); err != nil || skipped {
+		t.Errorf("downloadArtifact() of an unverifiable file = %v, %v; want downloaded", skipped, err)
+	}
+	if got, _ := os.ReadFile(other); string(got) != content {
+		t.Errorf("stale file was kept (%d bytes)", len(got))
+	}
+}
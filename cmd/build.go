@@ -1,137 +1,175 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"jenkins/internal/jenkins"
-	"net/url"
-	"strconv"
-	"strings"
+	"os"
+	"sort"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var (
+	buildParams   []string
+	promptMissing bool
+)
+
 func init() {
 	rootCmd.AddCommand(buildCmd)
+
+	buildCmd.Flags().StringArrayVarP(&buildParams, "param", "p", []string{}, "Build parameter as NAME=VALUE (repeatable)")
+	buildCmd.Flags().BoolVarP(&promptMissing, "interactive", "i", false, "Prompt for parameters that have no value or default")
+	addNotifyFlag(buildCmd)
 }
 
 var buildCmd = &cobra.Command{
 	Use:   "build [product] [branch]",
-	Short: "Trigger a build on the master pipeline",
-	Long: `Trigger a build on the master pipeline with specified product and branch.
-
-Product must be one of:
-  - ingredi (or rs)
-  - bpam (or pra)
-
-Branch is the TRYMAX_BRANCH to build (e.g., feature/my-branch).
-Note: "origin/" will be automatically prepended if not provided.`,
-	Args: cobra.ExactArgs(2),
+	Short: "Trigger a parameterized build on the pipeline",
+	Long: `Trigger a build on the pipeline. Parameters are validated against the job's
+parameter definitions before the build is queued.
+
+Parameters can be given with -p NAME=VALUE, or through a product from the
+products config section (by key or alias). The default products are:
+  - rs (or ingredi)
+  - pra (or bpam)
+
+When a product is used, its product parameter is set and the optional branch
+argument is assigned to its branch parameter (PRODUCT and TRYMAX_BRANCH for the
+defaults), with the branch prefix ("origin/") prepended if not provided. The
+product's default branch is used when no branch is given.
+
+Examples:
+  jenkins build rs feature/my-branch
+  jenkins build -p PRODUCT=bpam -p TRYMAX_BRANCH=origin/master
+  jenkins --pipeline nightly build -i`,
+	Args: cobra.MaximumNArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		product := args[0]
-		branch := args[1]
-
-		// Normalize product name
-		switch product {
-		case "rs":
-			product = "ingredi"
-		case "pra":
-			product = "bpam"
-		case "ingredi", "bpam":
-			// Already valid
-		default:
-			return fmt.Errorf("invalid product: %s (must be 'ingredi', 'bpam', 'rs', or 'pra')", product)
-		}
-
-		// Prepend "origin/" to branch if not already present
-		if !strings.HasPrefix(branch, "origin/") {
-			branch = "origin/" + branch
+		if err := checkNotifiers(); err != nil {
+			return err
 		}
 
-		verbose("Triggering build for product [%s] branch [%s]", product, branch)
+		pipeline := viper.GetString("pipeline")
 
-		params := map[string]string{
-			"PRODUCT":       product,
-			"TRYMAX_BRANCH": branch,
+		params := map[string]string{}
+		if len(args) > 0 {
+			p, err := resolveProduct(args[0])
+			if err != nil {
+				return err
+			}
+			productBranch := ""
+			if len(args) > 1 {
+				productBranch = args[1]
+			}
+			if params, err = p.BuildParams(productBranch); err != nil {
+				return err
+			}
 		}
-		vVerbose("Build params [%#+v]", params)
 
-		res, err := jenkinsClient.TriggerBuild(viper.GetString("pipeline"), params)
+		flagParams, err := parseParamFlags(buildParams)
 		if err != nil {
-			verbose("Request error")
 			return err
 		}
+		for name, value := range flagParams {
+			params[name] = value
+		}
 
-		{
-			defer res.Body.Close()
-			bodyBytes, err := io.ReadAll(res.Body)
-			if err != nil {
+		defs, err := jenkinsClient.GetJobParameters(pipeline)
+		if err != nil {
+			return fmt.Errorf("failed to read parameter definitions for %s: %w", pipeline, err)
+		}
+		vVerbose("Parameter definitions [%+v]", defs)
+
+		if promptMissing {
+			if err := promptParams(missingParams(defs, params), params, stdinReader, os.Stdout); err != nil {
 				return err
 			}
-			verbose("Response body [%s]", string(bodyBytes))
 		}
 
-		// Get the queue location from the response
-		location := res.Header.Get("Location")
-		if location == "" {
-			return fmt.Errorf("no queue location in response")
+		if err := validateParams(defs, params); err != nil {
+			return err
 		}
 
-		fmt.Printf("Build queued successfully!\n")
-		fmt.Printf("Product: %s\n", product)
-		fmt.Printf("Branch:  %s\n", branch)
-		fmt.Printf("Queue:   %s\n", location)
-		fmt.Println()
+		verbose("Triggering build on [%s]", pipeline)
+		vVerbose("Build params [%#+v]", params)
 
-		// Poll the queue to get the build number
-		u, err := url.Parse(location)
+		location, err := triggerBuild(pipeline, params)
 		if err != nil {
 			return err
 		}
-		path := u.Path[1:] + u.Fragment + u.RawQuery
-		queueNumber := QueueNumberFromPath(location)
-		verbose("Polling queue location [%s] (queue #%s)", path, queueNumber)
-		p := NewURLPoller(path)
-		defer p.Stop()
-
-		for res := range p.Response {
-			var queue jenkins.QueueItem
-			if err := json.NewDecoder(res.Body).Decode(&queue); err != nil {
-				verbose("JSON decode error trying to parse build id [%v]", err)
-				res.Body.Close()
-				fmt.Printf("Could not resolve queue item %s to a build number.\n", queueNumber)
-				fmt.Printf("Resolve manually with: jenkins queue %s\n", queueNumber)
-				return nil
-			}
-			res.Body.Close()
 
-			if queue.Executable.Number == 0 {
-				verbose("Build not yet started, still queued...")
-				continue
-			}
+		fmt.Printf("Build queued successfully!\n")
+		printParams(params)
+		fmt.Printf("Queue:   %s\n", location)
+		fmt.Println()
 
-			buildNumber := strconv.Itoa(queue.Executable.Number)
-			fmt.Printf("Build started: #%s\n", buildNumber)
-			fmt.Printf("Monitor with: jenkins monitor %s\n", buildNumber)
-			fmt.Printf("Diagnose with: jenkins diagnose %s\n", buildNumber)
+		_, err = trackQueuedBuild(pipeline, location, true)
+		return err
+	},
+}
 
-			// Optionally start monitoring in background
-			buildArgs := []string{"monitor", "--bg", "--pipeline", viper.GetString("pipeline"), buildNumber}
-			verbose("Spawning monitor with args [%+v]", buildArgs)
-			monitorCmd, err := SpawnBG(buildArgs...)
-			if err != nil {
-				return err
-			}
+// triggerBuild queues a build of pipeline with params and returns the queue
+// item location
+func triggerBuild(pipeline string, params map[string]string) (string, error) {
+	res, err := jenkinsClient.TriggerBuild(pipeline, params)
+	if err != nil {
+		verbose("Request error")
+		return "", err
+	}
+	defer res.Body.Close()
+
+	bodyBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	verbose("Response body [%s]", string(bodyBytes))
+
+	if res.StatusCode >= 400 {
+		return "", NewAPIError(res.Request.URL.Path, res.StatusCode, "failed to queue build", nil)
+	}
+
+	// Get the queue location from the response
+	location := res.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("no queue location in response")
+	}
+	return location, nil
+}
 
-			if err := monitorCmd.Wait(); err != nil {
-				return err
-			}
+// trackQueuedBuild waits for the queue item at location to start, prints how
+// to follow the build and, if monitor is set, monitors it in the background.
+// Returns the build number, or an empty string if it couldn't be resolved.
+func trackQueuedBuild(pipeline, location string, monitor bool) (string, error) {
+	buildNumber, err := waitForBuildNumber(location)
+	if err != nil || buildNumber == "" {
+		return "", err
+	}
 
-			break
-		}
+	return buildNumber, trackStartedBuild(pipeline, buildNumber, monitor)
+}
+
+// trackStartedBuild prints follow-up commands for a started build and, if
+// monitor is set, hands it to the background watcher
+func trackStartedBuild(pipeline, buildNumber string, monitor bool) error {
+	fmt.Printf("Build started: #%s\n", buildNumber)
+	fmt.Printf("Monitor with: jenkins monitor %s\n", buildNumber)
+	fmt.Printf("Diagnose with: jenkins diagnose %s\n", buildNumber)
 
+	if !monitor {
 		return nil
-	},
+	}
+	return watchBuilds(pipeline, buildNumber)
+}
+
+// printParams prints parameters in name order
+func printParams(params map[string]string) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("  %s = %s\n", name, params[name])
+	}
 }
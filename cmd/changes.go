@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/jenkins"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	changesSinceGreen bool
+	changesFiles      bool
+)
+
+func init() {
+	rootCmd.AddCommand(changesCmd)
+
+	changesCmd.Flags().BoolVarP(&changesSinceGreen, "since-green", "g", false, "Show the commits of every build since the last successful build with the same parameters")
+	changesCmd.Flags().BoolVar(&changesFiles, "files", false, "List the files each commit changed")
+}
+
+var changesCmd = &cobra.Command{
+	Use:   "changes [build_id]",
+	Short: "Show the SCM commits that went into a build",
+	Long: `Show the commits a build picked up since the previous build, with their
+author, date and message, followed by the culprits Jenkins blames for the
+changes since the last successful build.
+
+With --since-green, the commits of every build after the last successful build
+with the same parameters are shown, which is what to look at when a build has
+been broken for a while. The build can be a build number or a product name.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline := viper.GetString("pipeline")
+		buildID, err := resolveBuildID(args[0])
+		if err != nil {
+			return err
+		}
+
+		run, err := jenkinsClient.GetBuildInfo(pipeline, buildID)
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		title := fmt.Sprintf("CHANGES IN %s", buildLabel(run))
+		commits := runCommits(run)
+		if changesSinceGreen {
+			green, err := lastGreenBuild(pipeline, run)
+			if err != nil {
+				return err
+			}
+			if green == nil {
+				fmt.Println(orangeStyle.Render(fmt.Sprintf("No successful build with the parameters of #%s found, showing its own changes", buildID)))
+			} else {
+				title = fmt.Sprintf("CHANGES IN %s SINCE #%s", buildLabel(run), green.ID)
+				commits = commitsBetween(pipeline, run, green)
+			}
+		}
+
+		fmt.Println(infoBoldStyle.Render(title))
+		if len(commits) == 0 {
+			fmt.Println(grayStyle.Render("  no SCM changes"))
+		}
+		for _, c := range commits {
+			printCommit(c, changesFiles)
+		}
+		if culprits := run.CulpritNames(); len(culprits) > 0 {
+			fmt.Println()
+			fmt.Printf("%s %s\n", infoBoldStyle.Render("CULPRITS:"), strings.Join(culprits, ", "))
+		}
+		return nil
+	},
+}
+
+// runCommits returns the commits of a single build
+func runCommits(run *jenkins.WorkflowRun) []buildCommit {
+	commits := []buildCommit{}
+	for _, c := range run.Commits() {
+		commits = append(commits, buildCommit{Build: run.ID, ChangeSetItem: c})
+	}
+	return commits
+}
+
+// printCommit prints a commit on one line, followed by the files it changed
+// if files is set
+func printCommit(c buildCommit, files bool) {
+	date := ""
+	if !c.Timestamp.IsZero() {
+		date = " " + c.Timestamp.Format("2006-01-02 15:04")
+	}
+	fmt.Printf("  %s %s %s %s\n", grayStyle.Render("#"+c.Build), orangeStyle.Render(c.ShortID()), c.Subject(),
+		grayStyle.Render("("+c.Author.FullName+date+")"))
+	if !files {
+		return
+	}
+	if len(c.Paths) == 0 {
+		for _, p := range c.AffectedPaths {
+			fmt.Println(grayStyle.Render("      " + p))
+		}
+		return
+	}
+	for _, p := range c.Paths {
+		fmt.Println(grayStyle.Render(fmt.Sprintf("    %s %s", editMarker(p.EditType), p.File)))
+	}
+}
+
+// editMarker abbreviates a change set edit type the way git does
+func editMarker(editType string) string {
+	switch editType {
+	case "add":
+		return "A"
+	case "delete":
+		return "D"
+	case "edit":
+		return "M"
+	}
+	return "?"
+}
+
+// printBuildChanges prints the commits a build picked up since the previous
+// build and its culprits, for diagnose
+func printBuildChanges(run *jenkins.WorkflowRun) {
+	commits := runCommits(run)
+	fmt.Println(infoBoldStyle.Render("CHANGES SINCE THE PREVIOUS BUILD:"))
+	if len(commits) == 0 {
+		fmt.Println(grayStyle.Render("  no SCM changes"))
+	}
+	for i, c := range commits {
+		if i == diagnoseMaxCommits {
+			fmt.Println(grayStyle.Render(fmt.Sprintf("  ... and %d more, see 'jenkins changes %s'", len(commits)-i, run.ID)))
+			break
+		}
+		printCommit(c, false)
+	}
+	if culprits := run.CulpritNames(); len(culprits) > 0 {
+		fmt.Printf("  %s %s\n", grayStyle.Render("Culprits:"), strings.Join(culprits, ", "))
+	}
+	fmt.Println()
+}
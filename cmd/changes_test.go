@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"strings"
+	"testing"
+)
+
+func changesRun() *jenkins.WorkflowRun {
+	return &jenkins.WorkflowRun{
+		ID: "1234",
+		ChangeSets: []jenkins.ChangeSet{{Kind: "git", Items: []jenkins.ChangeSetItem{
+			{CommitID: "0123456789abcdef", Msg: "Fix the build", Author: jenkins.ChangeSetAuthor{FullName: "Alice"}, AffectedPaths: []string{"main.go"}},
+			{CommitID: "fedcba9876543210", Msg: "Break the build", Author: jenkins.ChangeSetAuthor{FullName: "Bob"}},
+		}}},
+		Culprits: []jenkins.ChangeSetAuthor{{FullName: "Alice"}, {FullName: "Bob"}},
+	}
+}
+
+func TestRunCommits(t *testing.T) {
+	commits := runCommits(changesRun())
+	if len(commits) != 2 || commits[0].Build != "1234" || commits[1].ShortID() != "fedcba98" {
+		t.Errorf("runCommits() = %+v", commits)
+	}
+	if len(runCommits(&jenkins.WorkflowRun{ID: "1"})) != 0 {
+		t.Error("runCommits() of a build without changes should be empty")
+	}
+}
+
+func TestEditMarker(t *testing.T) {
+	for editType, want := range map[string]string{"add": "A", "edit": "M", "delete": "D", "rename": "?"} {
+		if got := editMarker(editType); got != want {
+			t.Errorf("editMarker(%q) = %q, want %q", editType, got, want)
+		}
+	}
+}
+
+func TestDiagnosisCommits(t *testing.T) {
+	run := changesRun()
+	if refs := newCommitRefs(run, 1); len(refs) != 1 || refs[0].Subject != "Fix the build" || refs[0].Paths[0] != "main.go" {
+		t.Errorf("newCommitRefs() = %+v", refs)
+	}
+
+	r := testReport(50)
+	r.Commits, r.Culprits = newCommitRefs(run, diagnoseMaxCommits), run.CulpritNames()
+	out, _ := r.render("markdown")
+	for _, want := range []string{"## Commits since the previous build", "- `01234567` Fix the build (Alice): main.go", "Culprits: Alice, Bob"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, out)
+		}
+	}
+
+	r.Commits = []commitRef{}
+	if out, _ := r.render("markdown"); !strings.Contains(out, "No SCM changes") {
+		t.Errorf("markdown without commits should say so:\n%s", out)
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/extract"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	viper.SetDefault("diagnose.known_issues", "")
+}
+
+// stageDiagnosis is what a failed stage's log says about the failure
+type stageDiagnosis struct {
+	extract.Classification
+	Issue *extract.KnownIssue
+	// IssueLine is the log line that matched the known issue
+	IssueLine string
+}
+
+// knownIssues loads the known issues file from diagnose.known_issues or
+// ~/.jenkins-known-issues.yaml
+func knownIssues() ([]extract.KnownIssue, error) {
+	path := viper.GetString("diagnose.known_issues")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, ".jenkins-known-issues.yaml")
+	}
+	issues, err := extract.LoadKnownIssues(path)
+	if err != nil {
+		return nil, NewConfigError("diagnose.known_issues", err.Error())
+	}
+	verbose("Loaded %d known issue(s) from [%s]", len(issues), path)
+	return issues, nil
+}
+
+// diagnoseStage classifies a failed stage from its log lines and matches it
+// against the known issues
+func diagnoseStage(item StageWithPath, lines []string, issues []extract.KnownIssue) stageDiagnosis {
+	d := stageDiagnosis{Classification: extract.Classify(item.Stage.Status, lines)}
+	stagePath := strings.Join(append(item.Path, item.Stage.Name), " > ")
+	if issue, n := extract.MatchKnownIssue(issues, stagePath, lines); issue != nil {
+		d.Issue = issue
+		d.IssueLine = strings.TrimSpace(lines[n])
+	}
+	return d
+}
+
+// label returns the category, followed by the known issue ID if there is one
+func (d stageDiagnosis) label() string {
+	if d.Issue == nil {
+		return string(d.Category)
+	}
+	return fmt.Sprintf("%s, %s", d.Category, d.Issue.ID)
+}
+
+// printDiagnosis prints the category and any known issue, indented by indent
+func printDiagnosis(d stageDiagnosis, indent string) {
+	fmt.Printf("%sCategory: %s\n", indent, infoBoldStyle.Render(string(d.Category)))
+	if d.Issue == nil {
+		return
+	}
+	fmt.Printf("%s%s\n", indent, orangeStyle.Render("This looks like "+d.Issue.Summary()))
+	if d.Issue.Link != "" {
+		fmt.Printf("%s  Link:       %s\n", indent, d.Issue.Link)
+	}
+	if d.Issue.Workaround != "" {
+		fmt.Printf("%s  Workaround: %s\n", indent, d.Issue.Workaround)
+	}
+	fmt.Printf("%s  Matched:    %s\n", indent, grayStyle.Render(d.IssueLine))
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"jenkins/internal/extract"
+	"jenkins/internal/jenkins"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestDiagnoseStage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known.yaml")
+	os.WriteFile(path, []byte("- id: KNOWN-123\n  title: agent lost disk space\n  pattern: No space left\n  stage: Package\n"), 0600)
+	viper.Set("diagnose.known_issues", path)
+	defer viper.Set("diagnose.known_issues", nil)
+
+	issues, err := knownIssues()
+	if err != nil {
+		t.Fatalf("knownIssues() failed: %v", err)
+	}
+
+	item := StageWithPath{Stage: jenkins.Stage{Base: jenkins.Base{Name: "Package", Status: "FAILED"}}, Path: []string{"Build"}}
+	d := diagnoseStage(item, []string{"tar: write error", "  No space left on device  "}, issues)
+	if d.Category != extract.CategoryInfrastructure || d.Issue == nil || d.IssueLine != "No space left on device" {
+		t.Errorf("diagnoseStage() = %+v", d)
+	}
+	if d.label() != "infrastructure, KNOWN-123" {
+		t.Errorf("label() = %q", d.label())
+	}
+
+	item.Stage.Name = "Unit"
+	if d := diagnoseStage(item, []string{"No space left on device"}, issues); d.Issue != nil {
+		t.Errorf("issue for the Package stage matched %s", item.Stage.Name)
+	}
+
+	os.WriteFile(path, []byte("not: a list"), 0600)
+	var cfgErr *ConfigError
+	if _, err := knownIssues(); !errors.As(err, &cfgErr) {
+		t.Errorf("knownIssues() error = %v, want ConfigError", err)
+	}
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"jenkins/internal/secrets"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// Credential storage defaults (can be overridden in config file)
+	viper.SetDefault("credentials.backend", "file")
+	viper.SetDefault("credentials.file", "")
+	viper.SetDefault("credentials.command.get", "")
+	viper.SetDefault("credentials.command.store", "")
+	viper.SetDefault("credentials.command.erase", "")
+}
+
+// credentialAccount returns the account name used to key stored API keys
+func credentialAccount(user, host string) string {
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	return fmt.Sprintf("%s@%s", user, strings.TrimSuffix(host, "/"))
+}
+
+// newSecretBackend builds the secret backend selected by credentials.backend
+func newSecretBackend() (secrets.Backend, error) {
+	switch backend := viper.GetString("credentials.backend"); backend {
+	case "file":
+		path, err := credentialsFile()
+		if err != nil {
+			return nil, err
+		}
+		return &secrets.FileBackend{Path: path, Passphrase: credentialPassphrase}, nil
+	case "command":
+		return &secrets.CommandBackend{
+			GetCommand:   viper.GetString("credentials.command.get"),
+			StoreCommand: viper.GetString("credentials.command.store"),
+			EraseCommand: viper.GetString("credentials.command.erase"),
+		}, nil
+	default:
+		return nil, NewConfigError("credentials.backend", fmt.Sprintf("unknown backend '%s' (must be 'file' or 'command')", backend))
+	}
+}
+
+// credentialsFile returns credentials.file with a leading ~/ expanded, or
+// ~/.jenkins-credentials when it isn't set
+func credentialsFile() (string, error) {
+	path := viper.GetString("credentials.file")
+	if path != "" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return filepath.Join(home, ".jenkins-credentials"), nil
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~/")), nil
+}
+
+// storedAPIKey looks up the API key for user on host in the secret backend.
+// Returns an empty string when nothing has been stored.
+func storedAPIKey(user, host string) (string, error) {
+	backend, err := newSecretBackend()
+	if err != nil {
+		return "", err
+	}
+
+	account := credentialAccount(user, host)
+	verbose("Resolving API key for [%s] from [%s] backend", account, viper.GetString("credentials.backend"))
+	key, err := backend.Get(account)
+	if errors.Is(err, secrets.ErrNotFound) {
+		verbose("No stored API key [%v]", err)
+		return "", nil
+	}
+	return key, err
+}
+
+// credentialPassphrase returns the passphrase for the encrypted credentials file,
+// taken from JENKINS_PASSPHRASE or prompted for on the terminal
+func credentialPassphrase() (string, error) {
+	if p := viper.GetString("passphrase"); p != "" {
+		return p, nil
+	}
+	return readSecret("Credentials passphrase: ")
+}
+
+// readSecret prompts for a value without echoing it when stdin is a terminal,
+// and otherwise reads a single line from stdin
+func readSecret(prompt string) (string, error) {
+	if term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprint(os.Stderr, prompt)
+		value, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(value)), nil
+	}
+
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read %s from stdin: %w", strings.TrimSuffix(strings.TrimSpace(prompt), ":"), err)
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestCredentialAccount(t *testing.T) {
+	tests := []struct {
+		user     string
+		host     string
+		expected string
+	}{
+		{"alice", "https://jenkins.example.com", "alice@jenkins.example.com"},
+		{"alice", "http://jenkins.example.com/", "alice@jenkins.example.com"},
+		{"bob", "jenkins.example.com:8080", "bob@jenkins.example.com:8080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := credentialAccount(tt.user, tt.host); got != tt.expected {
+				t.Errorf("credentialAccount(%s, %s) = %s, want %s", tt.user, tt.host, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewSecretBackendUnknown(t *testing.T) {
+	old := viper.GetString("credentials.backend")
+	defer viper.Set("credentials.backend", old)
+
+	viper.Set("credentials.backend", "keyring")
+	if _, err := newSecretBackend(); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestCredentialsFile(t *testing.T) {
+	defer viper.Set("credentials.file", "")
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	tests := map[string]string{
+		"":                   filepath.Join(home, ".jenkins-credentials"),
+		"~/.jenkins-creds":   filepath.Join(home, ".jenkins-creds"),
+		"~/secrets/jenkins":  filepath.Join(home, "secrets", "jenkins"),
+		"/etc/jenkins/creds": "/etc/jenkins/creds",
+		"relative/creds":     "relative/creds",
+	}
+	for file, want := range tests {
+		viper.Set("credentials.file", file)
+		if got, err := credentialsFile(); err != nil || got != want {
+			t.Errorf("credentialsFile() with %q = %q, %v; want %q", file, got, err, want)
+		}
+	}
+}
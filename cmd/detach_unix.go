@@ -0,0 +1,10 @@
+//go:build !windows
+
+package cmd
+
+import "syscall"
+
+// detachAttr starts a process in its own session so it survives the terminal closing
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package cmd
+
+import "syscall"
+
+const (
+	createNewProcessGroup = 0x00000200
+	detachedProcess       = 0x00000008
+)
+
+// detachAttr starts a process without a console so it survives the terminal closing
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
@@ -1,22 +1,34 @@
 package cmd
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
+	"jenkins/internal/extract"
 	"jenkins/internal/formatting"
 	"jenkins/internal/jenkins"
-	"net/http"
+	"slices"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+const (
+	// diagnoseMaxTests is the number of failed tests diagnose shows
+	diagnoseMaxTests = 10
+	// diagnoseStackLines is the number of stack trace lines shown per test
+	diagnoseStackLines = 8
+	// diagnoseMaxCommits is the number of commits diagnose shows
+	diagnoseMaxCommits = 20
+)
+
 var (
 	showAllStages bool
 	maxLogLines   int
+	rawLogs       bool
+	diagFormat    string
+	diagMaxTokens int
 )
 
 func init() {
@@ -24,6 +36,9 @@ func init() {
 
 	diagnoseCmd.Flags().BoolVarP(&showAllStages, "all", "a", false, "Show all stages, not just failed ones")
 	diagnoseCmd.Flags().IntVarP(&maxLogLines, "log-lines", "l", 50, "Maximum lines of log to show per stage (0 for all)")
+	diagnoseCmd.Flags().StringVarP(&diagFormat, "format", "o", "text", "Output format: text, json or markdown")
+	diagnoseCmd.Flags().IntVar(&diagMaxTokens, "max-tokens", 0, "Trim logs to fit json or markdown output in about this many tokens (0 for no limit)")
+	diagnoseCmd.Flags().BoolVar(&rawLogs, "raw", false, "Show the start and end of each log instead of the lines around errors")
 }
 
 type StageWithPath struct {
@@ -36,12 +51,64 @@ var diagnoseCmd = &cobra.Command{
 	Short: "Analyze a build and show failed stages with logs",
 	Long: `Comprehensive build analysis that shows:
   - Build status and duration
-  - All failed stages with their logs
-  - Summary of issues for AI analysis`,
+  - The commits since the previous build and their culprits
+  - All failed stages with the errors found in their logs
+  - The kind of each failure, and any known issue it matches
+  - Failed tests from the JUnit results
+  - Summary of issues for AI analysis
+
+Each stage log is scanned for known errors (failed shell steps, compiler and
+test failures, out of memory kills, Gradle, Maven, npm and Go failures) and the
+matching lines are shown with some context. When nothing matches, or with
+--raw, the start and end of the log are shown instead.
+
+Extra patterns can be defined in the config file, and built-in ones disabled
+by name:
+  diagnose:
+    context: 3              # lines around matches of config patterns
+    disable: [exception]
+    patterns:
+      flyway:
+        regex: 'Migration .* failed'
+        after: 10
+
+Failures are classified as aborted by user, agent disconnected, timeout,
+infrastructure, compile error or test failure from their logs, and matched
+against the known issues file (diagnose.known_issues, default
+~/.jenkins-known-issues.yaml), a list of:
+  - id: KNOWN-123
+    title: agent lost disk space
+    pattern: 'No space left on device'   # regex matched against log lines
+    stage: 'Integration'                 # optional regex on the stage path
+    link: https://issues.example.com/KNOWN-123
+    workaround: Rebuild; the agent is cleaned nightly
+
+With --format json or markdown, the diagnosis is written as a document for
+tools and language models: build metadata and parameters, commits, test
+results, the stage tree, and for each failed stage its path, category, known
+issue, error snippets and a log excerpt where omitted lines are replaced by
+"[... N lines omitted ...]". --log-lines limits each excerpt, and
+--max-tokens trims excerpts, then stack traces, then snippets, least relevant
+first, until the document fits:
+  jenkins diagnose 1234 --format markdown --max-tokens 8000 | llm`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		buildID := args[0]
 
+		if diagFormat != "text" && !slices.Contains(diagnosisFormats, diagFormat) {
+			return NewValidationError("format", diagFormat, "must be text, json or markdown")
+		}
+
+		patterns, err := errorPatterns()
+		if err != nil {
+			return err
+		}
+		extractor := extract.Extractor{Patterns: patterns}
+		issues, err := knownIssues()
+		if err != nil {
+			return err
+		}
+
 		// Get build info
 		buildInfo, err := jenkinsClient.GetBuildInfo(viper.GetString("pipeline"), buildID)
 		if err != nil {
@@ -54,6 +121,16 @@ var diagnoseCmd = &cobra.Command{
 			return fmt.Errorf("failed to get build details: %w", err)
 		}
 
+		if diagFormat != "text" {
+			report := newDiagnosisReport(viper.GetString("pipeline"), buildID, buildInfo, job, extractor, issues, maxLogLines)
+			out, err := report.renderWithin(diagFormat, diagMaxTokens)
+			if err != nil {
+				return err
+			}
+			fmt.Println(out)
+			return nil
+		}
+
 		// Print build summary
 		fmt.Println("═" + strings.Repeat("═", 78) + "═")
 		fmt.Println(infoBoldStyle.Render(fmt.Sprintf("  BUILD DIAGNOSIS: %s #%s", viper.GetString("pipeline"), buildID)))
@@ -72,17 +149,27 @@ var diagnoseCmd = &cobra.Command{
 		fmt.Printf("URL:      %s\n", buildInfo.URL)
 		fmt.Println()
 
-		// Collect failed leaf stages with their paths
-		var failedLeaves []StageWithPath
-		var allLeaves []StageWithPath
-		collectFailedLeafStages(job.Stages, []string{}, &failedLeaves)
-		if showAllStages {
-			collectAllLeafStages(job.Stages, []string{}, &allLeaves)
+		printBuildChanges(buildInfo)
+
+		// Test results, which also explain unstable builds without failed stages
+		if tests, err := jenkinsClient.GetTestReport(viper.GetString("pipeline"), buildID); err == nil {
+			printTestCounts(buildID, tests)
+			if failed := tests.FailedCases(); len(failed) > 0 {
+				printFailedTests(failed, nil, diagnoseStackLines, diagnoseMaxTests)
+				fmt.Println(grayStyle.Render(fmt.Sprintf("Use 'jenkins tests %s' for suites, slow tests and history", buildID)))
+				fmt.Println()
+			}
+		} else if !errors.Is(err, jenkins.ErrNotFound) {
+			verbose("Failed to read test results [%v]", err)
 		}
 
+		// Collect failed leaf stages with their paths
+		tree := fetchStageTree(job.Stages)
+		failedLeaves := stagesWithPath(failedLeafNodes(tree))
+
 		stagesToShow := failedLeaves
 		if showAllStages {
-			stagesToShow = allLeaves
+			stagesToShow = stagesWithPath(leafNodes(tree))
 		}
 
 		if len(stagesToShow) == 0 {
@@ -106,6 +193,7 @@ var diagnoseCmd = &cobra.Command{
 		fmt.Println(infoBoldStyle.Render("STAGE LOGS:"))
 		fmt.Println()
 
+		diagnoses := map[string]stageDiagnosis{}
 		for i, item := range stagesToShow {
 			printStageDividerWithPath(i+1, item.Stage, item.Path, showAllStages)
 
@@ -123,20 +211,22 @@ var diagnoseCmd = &cobra.Command{
 				continue
 			}
 
-			// Print log (with line limit if specified)
 			lines := strings.Split(node.Text, "\n")
-			if maxLogLines > 0 && len(lines) > maxLogLines {
-				// Show first and last lines
-				half := maxLogLines / 2
-				for _, line := range lines[:half] {
-					fmt.Println(line)
-				}
-				fmt.Println(grayStyle.Render(fmt.Sprintf("\n  ... (%d lines omitted) ...\n", len(lines)-maxLogLines)))
-				for _, line := range lines[len(lines)-half:] {
-					fmt.Println(line)
+			if item.Stage.Status != "SUCCESS" {
+				d := diagnoseStage(item, lines, issues)
+				diagnoses[item.Stage.ID] = d
+				printDiagnosis(d, "")
+				fmt.Println()
+			}
+
+			snippets := extractor.ExtractLines(lines)
+			if rawLogs || len(snippets) == 0 {
+				if !rawLogs {
+					fmt.Println(grayStyle.Render("  (no known errors found in log)"))
 				}
+				printHeadTail(lines, maxLogLines)
 			} else {
-				fmt.Println(node.Text)
+				printSnippets(extract.Limit(snippets, maxLogLines))
 			}
 			fmt.Println()
 		}
@@ -149,7 +239,16 @@ var diagnoseCmd = &cobra.Command{
 			fmt.Println("  Failed stages:")
 			for _, item := range failedLeaves {
 				fullPath := strings.Join(append(item.Path, item.Stage.Name), " > ")
-				fmt.Printf("    - [%s] %s (%s)\n", item.Stage.ID, fullPath, item.Stage.Status)
+				fmt.Printf("    - [%s] %s (%s)", item.Stage.ID, fullPath, item.Stage.Status)
+				if d, ok := diagnoses[item.Stage.ID]; ok {
+					fmt.Printf(" - %s", d.label())
+				}
+				fmt.Println()
+			}
+		}
+		for _, item := range failedLeaves {
+			if d := diagnoses[item.Stage.ID]; d.Issue != nil {
+				fmt.Printf("  [%s] looks like %s\n", item.Stage.ID, d.Issue.Summary())
 			}
 		}
 		fmt.Println("═" + strings.Repeat("═", 78) + "═")
@@ -158,6 +257,44 @@ var diagnoseCmd = &cobra.Command{
 	},
 }
 
+// printSnippets prints the snippets of a log with their line numbers, marking
+// the lines that matched an error pattern
+func printSnippets(snippets []extract.Snippet, dropped int) {
+	for i, s := range snippets {
+		if i > 0 {
+			fmt.Println(grayStyle.Render("  ..."))
+		}
+		for j, line := range s.Lines {
+			n := s.Start + j
+			if s.IsMatch(n) {
+				fmt.Println(failureStyle.Render(fmt.Sprintf("%5d> ", n+1)) + line)
+			} else {
+				fmt.Println(grayStyle.Render(fmt.Sprintf("%5d  ", n+1)) + line)
+			}
+		}
+	}
+	if dropped > 0 {
+		fmt.Println(grayStyle.Render(fmt.Sprintf("\n  ... (%d more error snippet(s) omitted, raise --log-lines to see them) ...", dropped)))
+	}
+}
+
+// printHeadTail prints the first and last maxLines/2 lines, or every line if
+// maxLines is 0 or the log is short enough
+func printHeadTail(lines []string, maxLines int) {
+	if maxLines <= 0 || len(lines) <= maxLines {
+		fmt.Println(strings.Join(lines, "\n"))
+		return
+	}
+	half := maxLines / 2
+	for _, line := range lines[:half] {
+		fmt.Println(line)
+	}
+	fmt.Println(grayStyle.Render(fmt.Sprintf("\n  ... (%d lines omitted) ...\n", len(lines)-maxLines)))
+	for _, line := range lines[len(lines)-half:] {
+		fmt.Println(line)
+	}
+}
+
 func printStageDividerWithPath(index int, stage jenkins.Stage, path []string, showStatus bool) {
 	fullPath := strings.Join(append(path, stage.Name), " > ")
 
@@ -179,203 +316,3 @@ func printStageDividerWithPath(index int, stage jenkins.Stage, path []string, sh
 		stage.ExecNode)))
 	fmt.Println()
 }
-
-// stageFetchJob represents a stage to fetch with its context
-type stageFetchJob struct {
-	stage jenkins.Stage
-	path  []string
-}
-
-// stageFetchResult represents the result of fetching a stage
-type stageFetchResult struct {
-	stage jenkins.Stage
-	path  []string
-	err   error
-}
-
-// fetchStageWorker fetches stage details from Jenkins API
-func fetchStageWorker(jobs <-chan stageFetchJob, results chan<- stageFetchResult, wg *sync.WaitGroup) {
-	defer wg.Done()
-	for job := range jobs {
-		res, err := jenkinsClient.Request(http.MethodGet, job.stage.Links.Self.HREF)
-		if err != nil {
-			results <- stageFetchResult{err: err}
-			continue
-		}
-
-		var stage jenkins.Stage
-		if err := json.NewDecoder(res.Body).Decode(&stage); err != nil {
-			res.Body.Close()
-			results <- stageFetchResult{err: err}
-			continue
-		}
-		res.Body.Close()
-
-		results <- stageFetchResult{
-			stage: stage,
-			path:  job.path,
-			err:   nil,
-		}
-	}
-}
-
-// collectFailedLeafStages finds only the deepest failed stages (leaf nodes with logs)
-// Uses parallel fetching with a worker pool for better performance
-func collectFailedLeafStages(stages []jenkins.Stage, path []string, leaves *[]StageWithPath) {
-	const numWorkers = 10 // Number of concurrent workers
-
-	// Create jobs and results channels
-	jobs := make(chan stageFetchJob, len(stages)*2) // Buffer for all potential jobs
-	results := make(chan stageFetchResult, len(stages)*2)
-
-	// Start worker pool
-	var wg sync.WaitGroup
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go fetchStageWorker(jobs, results, &wg)
-	}
-
-	// Queue initial jobs
-	for _, stage := range stages {
-		jobs <- stageFetchJob{
-			stage: stage,
-			path:  append([]string{}, path...), // Copy the path
-		}
-	}
-
-	// Start a goroutine to close results when all workers are done
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	// Process results and queue additional jobs for children
-	processed := 0
-	expectedJobs := len(stages)
-
-	for result := range results {
-		processed++
-
-		if result.err != nil {
-			verbose("Error fetching stage: %v", result.err)
-			if processed >= expectedJobs {
-				break
-			}
-			continue
-		}
-
-		stage := result.stage
-
-		// Check if this stage has failed children
-		hasFailedChildren := false
-		if len(stage.StageFlowNodes) > 0 {
-			for _, child := range stage.StageFlowNodes {
-				if child.Status == "FAILED" || child.Status == "ABORTED" {
-					hasFailedChildren = true
-					break
-				}
-			}
-		}
-
-		// If this stage failed and either has no children or no failed children, it's a leaf
-		if (stage.Status == "FAILED" || stage.Status == "ABORTED") && !hasFailedChildren {
-			*leaves = append(*leaves, StageWithPath{
-				Stage: stage,
-				Path:  result.path,
-			})
-		}
-
-		// Queue child stages for processing
-		if len(stage.StageFlowNodes) > 0 {
-			newPath := append(result.path, stage.Name)
-			for _, child := range stage.StageFlowNodes {
-				expectedJobs++
-				jobs <- stageFetchJob{
-					stage: child,
-					path:  append([]string{}, newPath...), // Copy the path
-				}
-			}
-		}
-
-		// If we've processed all expected jobs, we're done
-		if processed >= expectedJobs {
-			break
-		}
-	}
-
-	close(jobs)
-}
-
-// collectAllLeafStages finds all leaf stages (deepest stages with logs)
-// Uses parallel fetching with a worker pool for better performance
-func collectAllLeafStages(stages []jenkins.Stage, path []string, leaves *[]StageWithPath) {
-	const numWorkers = 10 // Number of concurrent workers
-
-	// Create jobs and results channels
-	jobs := make(chan stageFetchJob, len(stages)*2)
-	results := make(chan stageFetchResult, len(stages)*2)
-
-	// Start worker pool
-	var wg sync.WaitGroup
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go fetchStageWorker(jobs, results, &wg)
-	}
-
-	// Queue initial jobs
-	for _, stage := range stages {
-		jobs <- stageFetchJob{
-			stage: stage,
-			path:  append([]string{}, path...), // Copy the path
-		}
-	}
-
-	// Start a goroutine to close results when all workers are done
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	// Process results and queue additional jobs for children
-	processed := 0
-	expectedJobs := len(stages)
-
-	for result := range results {
-		processed++
-
-		if result.err != nil {
-			verbose("Error fetching stage: %v", result.err)
-			if processed >= expectedJobs {
-				break
-			}
-			continue
-		}
-
-		stage := result.stage
-
-		// If this stage has no children, it's a leaf
-		if len(stage.StageFlowNodes) == 0 {
-			*leaves = append(*leaves, StageWithPath{
-				Stage: stage,
-				Path:  result.path,
-			})
-		} else {
-			// Queue child stages for processing
-			newPath := append(result.path, stage.Name)
-			for _, child := range stage.StageFlowNodes {
-				expectedJobs++
-				jobs <- stageFetchJob{
-					stage: child,
-					path:  append([]string{}, newPath...), // Copy the path
-				}
-			}
-		}
-
-		// If we've processed all expected jobs, we're done
-		if processed >= expectedJobs {
-			break
-		}
-	}
-
-	close(jobs)
-}
@@ -0,0 +1,557 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jenkins/internal/extract"
+	"jenkins/internal/formatting"
+	"jenkins/internal/jenkins"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diagnosisFormats are the structured output formats of diagnose
+var diagnosisFormats = []string{"json", "markdown"}
+
+// diagnosisReport is the structured form of diagnose's output
+type diagnosisReport struct {
+	Pipeline    string            `json:"pipeline"`
+	Build       string            `json:"build"`
+	DisplayName string            `json:"display_name"`
+	Result      string            `json:"result"`
+	Building    bool              `json:"building"`
+	Started     time.Time         `json:"started"`
+	DurationMS  int               `json:"duration_ms"`
+	URL         string            `json:"url"`
+	TriggeredBy string            `json:"triggered_by,omitempty"`
+	Parameters  map[string]string `json:"parameters"`
+	// Commits are the commits since the previous build
+	Commits  []commitRef    `json:"commits"`
+	Culprits []string       `json:"culprits,omitempty"`
+	Tests    *testResults   `json:"tests,omitempty"`
+	Stages   []*stageNode   `json:"stages"`
+	Failed   []*failedStage `json:"failed_stages"`
+	// Trimmed is set when logs or snippets were cut to fit the token budget
+	Trimmed bool `json:"trimmed,omitempty"`
+	// OverBudget is set when the report is still larger than the budget
+	// after removing every log and snippet that could be removed
+	OverBudget bool `json:"over_budget,omitempty"`
+}
+
+// commitRef is a commit a build picked up
+type commitRef struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author"`
+	Timestamp time.Time `json:"timestamp"`
+	Subject   string    `json:"subject"`
+	Paths     []string  `json:"paths,omitempty"`
+}
+
+// newCommitRefs lists the first max commits of a build
+func newCommitRefs(run *jenkins.WorkflowRun, max int) []commitRef {
+	refs := []commitRef{}
+	for _, c := range run.Commits() {
+		if len(refs) == max {
+			break
+		}
+		refs = append(refs, commitRef{
+			ID:        c.CommitID,
+			Author:    c.Author.FullName,
+			Timestamp: c.Timestamp.Time,
+			Subject:   c.Subject(),
+			Paths:     c.AffectedPaths,
+		})
+	}
+	return refs
+}
+
+// testResults summarizes the build's JUnit results
+type testResults struct {
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Skipped  int           `json:"skipped"`
+	Failures []testFailure `json:"failures"`
+}
+
+// testFailure is a failed test
+type testFailure struct {
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	DurationMS  int      `json:"duration_ms"`
+	Message     string   `json:"message,omitempty"`
+	Stack       []string `json:"stack,omitempty"`
+	Age         int      `json:"age,omitempty"`
+	FailedSince int      `json:"failed_since,omitempty"`
+}
+
+// newTestResults summarizes a test report, keeping the first max failures
+func newTestResults(report *jenkins.TestReport, max int) *testResults {
+	t := &testResults{Failures: []testFailure{}}
+	t.Passed, t.Failed, t.Skipped = report.Counts()
+	for _, c := range report.FailedCases() {
+		if len(t.Failures) == max {
+			break
+		}
+		t.Failures = append(t.Failures, testFailure{
+			Name:        c.FullName(),
+			Status:      c.Status,
+			DurationMS:  int(c.Time().Milliseconds()),
+			Message:     strings.TrimSpace(c.ErrorDetails),
+			Stack:       stackExcerpt(c.ErrorStackTrace, diagnoseStackLines),
+			Age:         c.Age,
+			FailedSince: c.FailedSince,
+		})
+	}
+	return t
+}
+
+// dropStacks removes the stack traces of failed tests and reports whether
+// there were any
+func (t *testResults) dropStacks() bool {
+	dropped := false
+	for i := range t.Failures {
+		if t.Failures[i].Stack != nil {
+			t.Failures[i].Stack = nil
+			dropped = true
+		}
+	}
+	return dropped
+}
+
+// stageNode is a stage or step in the build's stage tree
+type stageNode struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	Status     string       `json:"status"`
+	DurationMS int          `json:"duration_ms"`
+	Node       string       `json:"node,omitempty"`
+	Children   []*stageNode `json:"children,omitempty"`
+
+	stage jenkins.Stage
+	path  []string
+}
+
+// failedStage is a failed leaf stage with what its log says about the failure
+type failedStage struct {
+	ID         string         `json:"id"`
+	Path       []string       `json:"path"`
+	Status     string         `json:"status"`
+	Node       string         `json:"node,omitempty"`
+	DurationMS int            `json:"duration_ms"`
+	Category   string         `json:"category"`
+	KnownIssue *knownIssueRef `json:"known_issue,omitempty"`
+	Snippets   []logSnippet   `json:"snippets"`
+	Log        *logExcerpt    `json:"log,omitempty"`
+
+	lines    []string
+	snippets []extract.Snippet
+}
+
+// knownIssueRef is the known issue a failed stage matched
+type knownIssueRef struct {
+	ID          string `json:"id"`
+	Title       string `json:"title,omitempty"`
+	Link        string `json:"link,omitempty"`
+	Workaround  string `json:"workaround,omitempty"`
+	MatchedLine string `json:"matched_line"`
+}
+
+// logSnippet is an extracted error snippet. Line numbers are 1-based.
+type logSnippet struct {
+	StartLine    int      `json:"start_line"`
+	EndLine      int      `json:"end_line"`
+	MatchedLines []int    `json:"matched_lines"`
+	Patterns     []string `json:"patterns"`
+	Lines        []string `json:"lines"`
+}
+
+// logExcerpt is part of a stage log, with omitted ranges replaced by markers
+type logExcerpt struct {
+	TotalLines int  `json:"total_lines"`
+	Truncated  bool `json:"truncated"`
+	// Incomplete is set when Jenkins itself did not return the whole log
+	Incomplete bool     `json:"incomplete,omitempty"`
+	Lines      []string `json:"lines"`
+
+	limit int
+}
+
+// newDiagnosisReport collects everything diagnose knows about a build
+func newDiagnosisReport(pipeline, buildID string, build *jenkins.WorkflowRun, job *jenkins.Job, extractor extract.Extractor, issues []extract.KnownIssue, logLines int) *diagnosisReport {
+	r := &diagnosisReport{
+		Pipeline:    pipeline,
+		Build:       buildID,
+		DisplayName: build.DisplayName,
+		Result:      build.Result,
+		Building:    build.Building,
+		Started:     build.Timestamp.Time,
+		DurationMS:  build.Duration,
+		URL:         build.URL,
+		TriggeredBy: build.TriggeredBy(),
+		Parameters:  build.Parameters(),
+		Commits:     newCommitRefs(build, diagnoseMaxCommits),
+		Culprits:    build.CulpritNames(),
+		Stages:      fetchStageTree(job.Stages),
+		Failed:      []*failedStage{},
+	}
+
+	if report, err := jenkinsClient.GetTestReport(pipeline, buildID); err == nil {
+		r.Tests = newTestResults(report, diagnoseMaxTests)
+	} else if !errors.Is(err, jenkins.ErrNotFound) {
+		verbose("Failed to read test results [%v]", err)
+	}
+
+	for _, n := range failedLeafNodes(r.Stages) {
+		f := &failedStage{
+			ID:         n.ID,
+			Path:       append(append([]string{}, n.path...), n.Name),
+			Status:     n.Status,
+			Node:       n.Node,
+			DurationMS: n.DurationMS,
+			Category:   string(extract.CategoryUnknown),
+			Snippets:   []logSnippet{},
+		}
+		r.Failed = append(r.Failed, f)
+
+		if n.stage.Links.Log.HREF == "" {
+			continue
+		}
+		node, err := jenkinsClient.GetStageLog(n.stage.Links.Log.HREF)
+		if err != nil {
+			verbose("Failed to fetch log for stage [%s] [%v]", n.ID, err)
+			continue
+		}
+
+		f.lines = strings.Split(node.Text, "\n")
+		f.snippets = extractor.ExtractLines(f.lines)
+		f.Log = &logExcerpt{TotalLines: len(f.lines), Incomplete: node.HasMore, limit: logLines}
+		f.setSnippets(len(f.snippets))
+		f.Log.update(f.lines, f.snippets)
+
+		d := diagnoseStage(StageWithPath{Stage: n.stage, Path: n.path}, f.lines, issues)
+		f.Category = string(d.Category)
+		if d.Issue != nil {
+			f.KnownIssue = &knownIssueRef{
+				ID:          d.Issue.ID,
+				Title:       d.Issue.Title,
+				Link:        d.Issue.Link,
+				Workaround:  d.Issue.Workaround,
+				MatchedLine: d.IssueLine,
+			}
+		}
+	}
+	return r
+}
+
+// fetchStage fetches the full description of a stage, including its
+// children, falling back to the stage as listed if that fails
+func fetchStage(stage jenkins.Stage) (jenkins.Stage, error) {
+	full, err := jenkinsClient.GetStage(stage.Links.Self.HREF)
+	if err != nil {
+		return stage, err
+	}
+	return *full, nil
+}
+
+// fetchStageTree fetches the stages and all of their descendants, at most
+// 10 requests at a time. This is the stage walk behind diagnose and failed.
+func fetchStageTree(stages []jenkins.Stage) []*stageNode {
+	sem := make(chan struct{}, 10)
+	var wg sync.WaitGroup
+
+	var fetch func(stages []jenkins.Stage, path []string) []*stageNode
+	fetch = func(stages []jenkins.Stage, path []string) []*stageNode {
+		nodes := make([]*stageNode, len(stages))
+		for i, s := range stages {
+			n := &stageNode{path: path}
+			nodes[i] = n
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				full, err := fetchStage(s)
+				<-sem
+				if err != nil {
+					verbose("Error fetching stage: %v", err)
+				}
+				n.stage = full
+				n.ID, n.Name, n.Status = full.ID, full.Name, full.Status
+				n.DurationMS, n.Node = full.Duration, full.ExecNode
+				if len(full.StageFlowNodes) > 0 {
+					n.Children = fetch(full.StageFlowNodes, append(append([]string{}, path...), full.Name))
+				}
+			}()
+		}
+		return nodes
+	}
+
+	nodes := fetch(stages, []string{})
+	wg.Wait()
+	return nodes
+}
+
+// failedLeafNodes returns the failed nodes that have no failed children, in
+// tree order
+func failedLeafNodes(nodes []*stageNode) []*stageNode {
+	failed := func(n *stageNode) bool { return n.Status == "FAILED" || n.Status == "ABORTED" }
+	leaves := []*stageNode{}
+	for _, n := range nodes {
+		if failed(n) && !slices.ContainsFunc(n.Children, failed) {
+			leaves = append(leaves, n)
+		}
+		leaves = append(leaves, failedLeafNodes(n.Children)...)
+	}
+	return leaves
+}
+
+// leafNodes returns the nodes that have no children, in tree order
+func leafNodes(nodes []*stageNode) []*stageNode {
+	leaves := []*stageNode{}
+	for _, n := range nodes {
+		if len(n.Children) == 0 {
+			leaves = append(leaves, n)
+		}
+		leaves = append(leaves, leafNodes(n.Children)...)
+	}
+	return leaves
+}
+
+// stagesWithPath returns the stages of nodes along with their paths
+func stagesWithPath(nodes []*stageNode) []StageWithPath {
+	stages := make([]StageWithPath, len(nodes))
+	for i, n := range nodes {
+		stages[i] = StageWithPath{Stage: n.stage, Path: n.path}
+	}
+	return stages
+}
+
+// setSnippets keeps the first n extracted snippets in the report
+func (f *failedStage) setSnippets(n int) {
+	f.Snippets = []logSnippet{}
+	for _, s := range f.snippets[:n] {
+		ls := logSnippet{StartLine: s.Start + 1, EndLine: s.End(), Lines: s.Lines, MatchedLines: []int{}, Patterns: []string{}}
+		for _, m := range s.Matches {
+			ls.MatchedLines = append(ls.MatchedLines, m.Line+1)
+			if !slices.Contains(ls.Patterns, m.Pattern) {
+				ls.Patterns = append(ls.Patterns, m.Pattern)
+			}
+		}
+		f.Snippets = append(f.Snippets, ls)
+	}
+}
+
+// update recomputes the excerpt for its current line limit
+func (l *logExcerpt) update(lines []string, snippets []extract.Snippet) {
+	l.Lines = extract.Excerpt(lines, snippets, l.limit)
+	l.Truncated = l.limit > 0 && len(lines) > l.limit
+}
+
+// shrink removes some of the least relevant content from the report: it
+// halves the largest log excerpt, drops logs that are already small, then
+// drops test stack traces, and once those are gone, drops the last snippet
+// of the stage with the most.
+// Returns false when there is nothing left to remove.
+func (r *diagnosisReport) shrink() bool {
+	var largest *failedStage
+	for _, f := range r.Failed {
+		if f.Log != nil && (largest == nil || len(f.Log.Lines) > len(largest.Log.Lines)) {
+			largest = f
+		}
+	}
+	if largest != nil {
+		shown := min(len(largest.lines), largest.Log.limit)
+		if largest.Log.limit <= 0 {
+			shown = len(largest.lines)
+		}
+		if shown <= 10 {
+			largest.Log = nil
+		} else {
+			largest.Log.limit = shown / 2
+			largest.Log.update(largest.lines, largest.snippets)
+		}
+		return true
+	}
+
+	if r.Tests != nil && r.Tests.dropStacks() {
+		return true
+	}
+
+	var most *failedStage
+	for _, f := range r.Failed {
+		if len(f.Snippets) > 0 && (most == nil || len(f.Snippets) > len(most.Snippets)) {
+			most = f
+		}
+	}
+	if most == nil {
+		return false
+	}
+	most.setSnippets(len(most.Snippets) - 1)
+	return true
+}
+
+// estimateTokens approximates the number of model tokens in text, at about
+// four characters per token
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// render renders the report in format, which must be one of
+// diagnosisFormats
+func (r *diagnosisReport) render(format string) (string, error) {
+	if format == "json" {
+		out, err := json.MarshalIndent(r, "", "  ")
+		return string(out), err
+	}
+	var b strings.Builder
+	r.writeMarkdown(&b)
+	return b.String(), nil
+}
+
+// renderWithin renders the report, trimming logs and snippets until it fits
+// in maxTokens. maxTokens <= 0 means no limit.
+func (r *diagnosisReport) renderWithin(format string, maxTokens int) (string, error) {
+	for {
+		out, err := r.render(format)
+		if err != nil || maxTokens <= 0 || estimateTokens(out) <= maxTokens {
+			return out, err
+		}
+		if !r.shrink() {
+			if r.OverBudget {
+				return out, nil
+			}
+			r.OverBudget = true
+			continue
+		}
+		r.Trimmed = true
+	}
+}
+
+// writeMarkdown writes the report as a Markdown document
+func (r *diagnosisReport) writeMarkdown(w io.Writer) {
+	duration := func(ms int) string { return formatting.Duration(time.Duration(ms) * time.Millisecond) }
+
+	fmt.Fprintf(w, "# Build diagnosis: %s #%s\n\n", r.Pipeline, r.Build)
+	fmt.Fprintf(w, "- **Result:** %s\n", markdownResult(r.Result, r.Building))
+	fmt.Fprintf(w, "- **Started:** %s\n", r.Started.Format(time.RFC3339))
+	fmt.Fprintf(w, "- **Duration:** %s\n", duration(r.DurationMS))
+	if r.TriggeredBy != "" {
+		fmt.Fprintf(w, "- **Triggered by:** %s\n", r.TriggeredBy)
+	}
+	fmt.Fprintf(w, "- **URL:** %s\n", r.URL)
+	if r.Trimmed {
+		fmt.Fprintf(w, "- **Note:** logs and snippets were trimmed to fit the token budget\n")
+	}
+	if r.OverBudget {
+		fmt.Fprintf(w, "- **Note:** the diagnosis is larger than the token budget even without logs and snippets\n")
+	}
+
+	if len(r.Parameters) > 0 {
+		fmt.Fprintf(w, "\n## Parameters\n\n| Name | Value |\n| --- | --- |\n")
+		names := make([]string, 0, len(r.Parameters))
+		for name := range r.Parameters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "| %s | %s |\n", name, strings.ReplaceAll(r.Parameters[name], "|", `\|`))
+		}
+	}
+
+	fmt.Fprintf(w, "\n## Commits since the previous build\n\n")
+	if len(r.Commits) == 0 {
+		fmt.Fprintf(w, "No SCM changes\n")
+	}
+	for _, c := range r.Commits {
+		fmt.Fprintf(w, "- `%.8s` %s (%s)", c.ID, c.Subject, c.Author)
+		if len(c.Paths) > 0 {
+			fmt.Fprintf(w, ": %s", strings.Join(c.Paths, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	if len(r.Culprits) > 0 {
+		fmt.Fprintf(w, "\nCulprits: %s\n", strings.Join(r.Culprits, ", "))
+	}
+
+	if t := r.Tests; t != nil {
+		fmt.Fprintf(w, "\n## Tests\n\n%d passed, %d failed, %d skipped\n", t.Passed, t.Failed, t.Skipped)
+		for _, f := range t.Failures {
+			fmt.Fprintf(w, "\n- **%s** (%s", f.Name, f.Status)
+			if f.Age > 1 {
+				fmt.Fprintf(w, ", failing since #%d", f.FailedSince)
+			}
+			fmt.Fprintf(w, ")")
+			if f.Message != "" {
+				fmt.Fprintf(w, ": %s", strings.ReplaceAll(f.Message, "\n", " "))
+			}
+			fmt.Fprintln(w)
+			if len(f.Stack) > 0 {
+				fmt.Fprintf(w, "\n  ```text\n  %s\n  ```\n", strings.Join(f.Stack, "\n  "))
+			}
+		}
+		if len(t.Failures) < t.Failed {
+			fmt.Fprintf(w, "\n%d more failed test(s) not shown\n", t.Failed-len(t.Failures))
+		}
+	}
+
+	fmt.Fprintf(w, "\n## Stages\n\n")
+	var writeTree func(nodes []*stageNode, depth int)
+	writeTree = func(nodes []*stageNode, depth int) {
+		for _, n := range nodes {
+			fmt.Fprintf(w, "%s- %s: %s (%s)\n", strings.Repeat("  ", depth), n.Name, n.Status, duration(n.DurationMS))
+			writeTree(n.Children, depth+1)
+		}
+	}
+	writeTree(r.Stages, 0)
+
+	fmt.Fprintf(w, "\n## Failed stages\n")
+	if len(r.Failed) == 0 {
+		fmt.Fprintf(w, "\nNo failed stages.\n")
+	}
+	for i, f := range r.Failed {
+		fmt.Fprintf(w, "\n### %d. %s\n\n", i+1, strings.Join(f.Path, " > "))
+		fmt.Fprintf(w, "- **ID:** %s\n- **Status:** %s\n- **Category:** %s\n- **Duration:** %s\n", f.ID, f.Status, f.Category, duration(f.DurationMS))
+		if f.Node != "" {
+			fmt.Fprintf(w, "- **Node:** %s\n", f.Node)
+		}
+		if k := f.KnownIssue; k != nil {
+			fmt.Fprintf(w, "- **Known issue:** %s", k.ID)
+			if k.Title != "" {
+				fmt.Fprintf(w, ": %s", k.Title)
+			}
+			if k.Link != "" {
+				fmt.Fprintf(w, " (%s)", k.Link)
+			}
+			fmt.Fprintln(w)
+			if k.Workaround != "" {
+				fmt.Fprintf(w, "- **Workaround:** %s\n", k.Workaround)
+			}
+		}
+
+		for _, s := range f.Snippets {
+			fmt.Fprintf(w, "\n#### Error at lines %d-%d (%s)\n\n```text\n%s\n```\n", s.StartLine, s.EndLine, strings.Join(s.Patterns, ", "), strings.Join(s.Lines, "\n"))
+		}
+		if f.Log != nil {
+			fmt.Fprintf(w, "\n#### Log")
+			if f.Log.Truncated {
+				fmt.Fprintf(w, " (truncated from %d lines)", f.Log.TotalLines)
+			}
+			if f.Log.Incomplete {
+				fmt.Fprintf(w, " (Jenkins returned only part of the log)")
+			}
+			fmt.Fprintf(w, "\n\n```text\n%s\n```\n", strings.Join(f.Log.Lines, "\n"))
+		}
+	}
+}
+
+// markdownResult returns the build result, or BUILDING for a running build
+func markdownResult(result string, building bool) string {
+	if building {
+		return "BUILDING"
+	}
+	return result
+}
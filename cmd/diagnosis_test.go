@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"jenkins/internal/extract"
+	"jenkins/internal/jenkins"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testReport returns a report with one failed stage whose 200 line log has
+// an error at line 100
+func testReport(logLines int) *diagnosisReport {
+	lines := make([]string, 200)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("step output line %d", i+1)
+	}
+	lines[99] = "ERROR: script returned exit code 2"
+	snippets := extract.Extractor{Patterns: extract.DefaultPatterns()}.ExtractLines(lines)
+
+	f := &failedStage{ID: "42", Path: []string{"Test", "Unit"}, Status: "FAILED", Category: "unknown", lines: lines, snippets: snippets}
+	f.Log = &logExcerpt{TotalLines: len(lines), limit: logLines}
+	f.setSnippets(len(snippets))
+	f.Log.update(lines, snippets)
+
+	return &diagnosisReport{
+		Pipeline:   "master",
+		Build:      "1234",
+		Result:     "FAILURE",
+		Parameters: map[string]string{"PRODUCT": "rs"},
+		Stages: []*stageNode{
+			{ID: "1", Name: "Build", Status: "SUCCESS"},
+			{ID: "2", Name: "Test", Status: "FAILED", Children: []*stageNode{
+				{ID: "42", Name: "Unit", Status: "FAILED"},
+				{ID: "43", Name: "Lint", Status: "SUCCESS"},
+			}},
+		},
+		Failed: []*failedStage{f},
+	}
+}
+
+func TestFailedLeafNodes(t *testing.T) {
+	leaves := failedLeafNodes(testReport(50).Stages)
+	if len(leaves) != 1 || leaves[0].ID != "42" {
+		t.Errorf("failedLeafNodes() = %+v, want only stage 42", leaves)
+	}
+}
+
+func TestLeafNodes(t *testing.T) {
+	leaves := leafNodes(testReport(50).Stages)
+	if len(leaves) != 3 || leaves[0].ID != "1" || leaves[1].ID != "42" || leaves[2].ID != "43" {
+		t.Errorf("leafNodes() = %+v, want stages 1, 42 and 43", leaves)
+	}
+}
+
+func TestFetchStageTree(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "//stage/2":
+			w.Write([]byte(`{"id": "2", "name": "Test", "status": "FAILED", "stageFlowNodes": [{"id": "42", "name": "Unit", "status": "FAILED", "_links": {"self": {"href": "/stage/42"}}}]}`))
+		case "//stage/42":
+			w.Write([]byte(`{"id": "42", "name": "Unit", "status": "FAILED", "execNode": "agent-1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	oldClient := jenkinsClient
+	defer func() { jenkinsClient = oldClient }()
+	jenkinsClient = jenkins.NewClient(jenkins.Config{Host: server.URL, User: "test", APIKey: "test"})
+
+	stages := []jenkins.Stage{
+		{Base: jenkins.Base{ID: "1", Name: "Build", Status: "SUCCESS", Links: jenkins.ResultLink{Self: jenkins.Link{HREF: "/stage/1"}}}},
+		{Base: jenkins.Base{ID: "2", Name: "Test", Status: "FAILED", Links: jenkins.ResultLink{Self: jenkins.Link{HREF: "/stage/2"}}}},
+	}
+	tree := fetchStageTree(stages)
+
+	// Stage 1 is missing, so it keeps what the listing said
+	if len(tree) != 2 || tree[0].Name != "Build" || len(tree[1].Children) != 1 {
+		t.Fatalf("fetchStageTree() = %+v", tree)
+	}
+	failed := stagesWithPath(failedLeafNodes(tree))
+	if len(failed) != 1 || failed[0].Stage.ExecNode != "agent-1" || strings.Join(failed[0].Path, ">") != "Test" {
+		t.Errorf("failed leaves = %+v", failed)
+	}
+}
+
+func TestDiagnosisJSON(t *testing.T) {
+	out, err := testReport(50).render("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Parameters map[string]string
+		Stages     []struct{ Children []struct{ ID string } }
+		Failed     []struct {
+			Path     []string
+			Snippets []struct {
+				MatchedLines []int    `json:"matched_lines"`
+				Patterns     []string `json:"patterns"`
+			}
+			Log struct {
+				TotalLines int `json:"total_lines"`
+				Truncated  bool
+				Lines      []string
+			}
+		} `json:"failed_stages"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if doc.Parameters["PRODUCT"] != "rs" || len(doc.Stages) != 2 || len(doc.Stages[1].Children) != 2 {
+		t.Errorf("unexpected metadata or stage tree: %s", out)
+	}
+
+	f := doc.Failed[0]
+	if strings.Join(f.Path, " > ") != "Test > Unit" || len(f.Snippets) != 1 || f.Snippets[0].MatchedLines[0] != 100 || f.Snippets[0].Patterns[0] != "exit-code" {
+		t.Errorf("unexpected failed stage: %+v", f)
+	}
+	if !f.Log.Truncated || f.Log.TotalLines != 200 || !strings.Contains(strings.Join(f.Log.Lines, "\n"), "lines omitted") {
+		t.Errorf("log should be truncated with markers: %+v", f.Log)
+	}
+}
+
+func TestDiagnosisTokenBudget(t *testing.T) {
+	full, _ := testReport(0).render("markdown")
+
+	r := testReport(0)
+	out, err := r.renderWithin("markdown", estimateTokens(full)/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimateTokens(out) > estimateTokens(full)/2 || !r.Trimmed || r.OverBudget {
+		t.Errorf("report not trimmed to budget: %d tokens, trimmed %v", estimateTokens(out), r.Trimmed)
+	}
+	if !strings.Contains(out, "ERROR: script returned exit code 2") || !strings.Contains(out, "lines omitted") {
+		t.Errorf("trimmed report lost the error or the truncation marker:\n%s", out)
+	}
+
+	r = testReport(0)
+	out, _ = r.renderWithin("markdown", 10)
+	if !r.OverBudget || r.Failed[0].Log != nil || len(r.Failed[0].Snippets) != 0 || !strings.Contains(out, "larger than the token budget") {
+		t.Errorf("an impossible budget should remove logs and snippets and say so:\n%s", out)
+	}
+}
@@ -0,0 +1,430 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/formatting"
+	"jenkins/internal/jenkins"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// diffMaxBuilds is how many builds between the two compared builds are
+	// read for their changes
+	diffMaxBuilds = 25
+	// diffMinDelta is the smallest stage time change reported as large
+	diffMinDelta = 10 * time.Second
+)
+
+var (
+	diffLimit     int
+	diffThreshold float64
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().IntVarP(&diffLimit, "limit", "l", jenkins.DefaultQueryLimit, "Number of recent builds to scan for the last successful build")
+	diffCmd.Flags().Float64VarP(&diffThreshold, "threshold", "t", 25, "Highlight stages whose time changed by at least this many percent")
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [build_a] [build_b]",
+	Short: "Compare a build with another, by default the last successful one",
+	Long: `Compare two builds of the pipeline: their parameters, the SCM changes between
+them, their stages with statuses and durations, and the agents they ran on.
+
+Without build_b, build_a is compared with the last successful build before it
+that had the same parameters, which shows what changed since things last
+worked. Stages that fail in build_a but passed in build_b, and stages whose
+time changed by more than --threshold percent, are highlighted.
+
+Examples:
+  jenkins diff 1234
+  jenkins diff 1234 1200
+  jenkins diff rs`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline := viper.GetString("pipeline")
+
+		idA, err := resolveBuildID(args[0])
+		if err != nil {
+			return err
+		}
+		buildA, err := jenkinsClient.GetBuildInfo(pipeline, idA)
+		if err != nil {
+			return fmt.Errorf("failed to get build info for %s: %w", idA, err)
+		}
+
+		var buildB *jenkins.WorkflowRun
+		if len(args) > 1 {
+			idB, err := resolveBuildID(args[1])
+			if err != nil {
+				return err
+			}
+			if buildB, err = jenkinsClient.GetBuildInfo(pipeline, idB); err != nil {
+				return fmt.Errorf("failed to get build info for %s: %w", idB, err)
+			}
+		} else {
+			green, err := lastGreenBuild(pipeline, buildA)
+			if err != nil {
+				return err
+			}
+			if green == nil {
+				return fmt.Errorf("no successful build with the same parameters as %s in the last %d builds", idA, diffLimit)
+			}
+			if buildB, err = jenkinsClient.GetBuildInfo(pipeline, green.ID); err != nil {
+				return fmt.Errorf("failed to get build info for %s: %w", green.ID, err)
+			}
+		}
+
+		jobA, err := jenkinsClient.GetJobDetails(pipeline, buildA.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get build details for %s: %w", buildA.ID, err)
+		}
+		jobB, err := jenkinsClient.GetJobDetails(pipeline, buildB.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get build details for %s: %w", buildB.ID, err)
+		}
+
+		cmd.SilenceUsage = true
+
+		d := compareBuilds(buildA, jobA, buildB, jobB)
+		printBuildHeader(buildA, buildB, len(args) == 1)
+		printParamChanges(d.Params)
+		printCommits(buildA, buildB, commitsBetween(pipeline, buildA, buildB))
+		printStageChanges(buildA, buildB, d.Stages)
+		printAgentChanges(buildA, buildB, d.AgentsA, d.AgentsB)
+		return nil
+	},
+}
+
+// buildDiff is the difference between two builds
+type buildDiff struct {
+	// Params are the parameters whose values differ
+	Params  []paramChange
+	Stages  []stageChange
+	AgentsA []string
+	AgentsB []string
+}
+
+// paramChange is a parameter with different values in the two builds. A
+// missing parameter has an empty value.
+type paramChange struct {
+	Name string
+	A, B string
+}
+
+// stageChange pairs a top-level stage with the stage of the same name in the
+// other build. Either side is nil when the stage only ran in one build.
+type stageChange struct {
+	Name string
+	A, B *jenkins.Stage
+}
+
+// NewFailure reports whether the stage failed in build A but not in build B
+func (s stageChange) NewFailure() bool {
+	return s.A != nil && stageFailed(s.A.Status) && (s.B == nil || !stageFailed(s.B.Status))
+}
+
+// Delta returns how much longer the stage took in build A, and false if it
+// did not run in both builds
+func (s stageChange) Delta() (time.Duration, bool) {
+	if s.A == nil || s.B == nil {
+		return 0, false
+	}
+	return time.Duration(s.A.Duration-s.B.Duration) * time.Millisecond, true
+}
+
+// LargeDelta reports whether the stage time changed by at least threshold
+// percent and at least diffMinDelta
+func (s stageChange) LargeDelta(threshold float64) bool {
+	delta, ok := s.Delta()
+	if !ok || delta.Abs() < diffMinDelta {
+		return false
+	}
+	if s.B.Duration == 0 {
+		return true
+	}
+	return float64(delta.Abs().Milliseconds())*100/float64(s.B.Duration) >= threshold
+}
+
+// stageFailed reports whether a wfapi stage status is a failure
+func stageFailed(status string) bool {
+	return status == "FAILED" || status == "ABORTED" || status == "UNSTABLE"
+}
+
+// lastGreenBuild returns the most recent successful build before run with
+// exactly the same parameters, or nil if there is none in the last diffLimit
+// builds
+func lastGreenBuild(pipeline string, run *jenkins.WorkflowRun) (*jenkins.WorkflowRun, error) {
+	q := jenkins.BuildQuery{Results: []string{"SUCCESS"}, Limit: diffLimit}
+	for name, value := range run.Parameters() {
+		m, err := jenkins.NewParameterMatcher(name, jenkins.MatchExact, value)
+		if err != nil {
+			return nil, err
+		}
+		q.Parameters = append(q.Parameters, m)
+	}
+
+	builds, err := jenkinsClient.SearchBuilds(pipeline, q)
+	if err != nil {
+		return nil, err
+	}
+	number, _ := strconv.Atoi(run.ID)
+	for _, b := range builds {
+		if n, err := strconv.Atoi(b.ID); err == nil && n < number {
+			verbose("Last successful build like [%s] is [%s]", run.ID, b.ID)
+			return &b, nil
+		}
+	}
+	return nil, nil
+}
+
+// compareBuilds compares the parameters, top-level stages and agents of two
+// builds
+func compareBuilds(a *jenkins.WorkflowRun, jobA *jenkins.Job, b *jenkins.WorkflowRun, jobB *jenkins.Job) buildDiff {
+	d := buildDiff{Params: []paramChange{}, Stages: []stageChange{}}
+
+	paramsA, paramsB := a.Parameters(), b.Parameters()
+	names := []string{}
+	for _, params := range []map[string]string{paramsA, paramsB} {
+		for name := range params {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if paramsA[name] != paramsB[name] {
+			d.Params = append(d.Params, paramChange{Name: name, A: paramsA[name], B: paramsB[name]})
+		}
+	}
+
+	// Stages in build A's order, then those that only ran in build B
+	for i := range jobA.Stages {
+		sc := stageChange{Name: jobA.Stages[i].Name, A: &jobA.Stages[i]}
+		for j := range jobB.Stages {
+			if jobB.Stages[j].Name == sc.Name {
+				sc.B = &jobB.Stages[j]
+				break
+			}
+		}
+		d.Stages = append(d.Stages, sc)
+	}
+	for j := range jobB.Stages {
+		if !slices.ContainsFunc(d.Stages, func(sc stageChange) bool { return sc.Name == jobB.Stages[j].Name }) {
+			d.Stages = append(d.Stages, stageChange{Name: jobB.Stages[j].Name, B: &jobB.Stages[j]})
+		}
+	}
+
+	d.AgentsA, d.AgentsB = stageAgents(jobA.Stages), stageAgents(jobB.Stages)
+	return d
+}
+
+// stageAgents returns the sorted, distinct agents the stages ran on
+func stageAgents(stages []jenkins.Stage) []string {
+	agents := []string{}
+	for _, s := range stages {
+		if s.ExecNode != "" && !slices.Contains(agents, s.ExecNode) {
+			agents = append(agents, s.ExecNode)
+		}
+	}
+	sort.Strings(agents)
+	return agents
+}
+
+// buildCommit is a commit and the build that picked it up
+type buildCommit struct {
+	Build string
+	jenkins.ChangeSetItem
+}
+
+// commitsBetween returns the commits picked up by the builds after b up to
+// and including a that have a's parameters, reading at most diffMaxBuilds
+// builds. Returns nil if a is not newer than b.
+func commitsBetween(pipeline string, a, b *jenkins.WorkflowRun) []buildCommit {
+	numA, errA := strconv.Atoi(a.ID)
+	numB, errB := strconv.Atoi(b.ID)
+	if errA != nil || errB != nil || numA <= numB {
+		return nil
+	}
+
+	commits := []buildCommit{}
+	params := a.Parameters()
+	for n := numA; n > numB && n > numA-diffMaxBuilds; n-- {
+		run := a
+		if n != numA {
+			var err error
+			if run, err = jenkinsClient.GetBuildInfo(pipeline, strconv.Itoa(n)); err != nil {
+				verbose("Skipping build [%d] [%v]", n, err)
+				continue
+			}
+			if !maps.Equal(run.Parameters(), params) {
+				continue
+			}
+		}
+		for _, c := range run.Commits() {
+			commits = append(commits, buildCommit{Build: run.ID, ChangeSetItem: c})
+		}
+	}
+	return commits
+}
+
+// buildLabel renders "#id (RESULT)"
+func buildLabel(run *jenkins.WorkflowRun) string {
+	result := run.Result
+	if run.Building {
+		result = "BUILDING"
+	}
+	return fmt.Sprintf("#%s (%s)", infoBoldStyle.Render(run.ID), resultStyle(result).Render(result))
+}
+
+func printBuildHeader(a, b *jenkins.WorkflowRun, lastGreen bool) {
+	how := ""
+	if lastGreen {
+		how = ", the last successful build with the same parameters"
+	}
+	fmt.Println(noStyle.Render(fmt.Sprintf("Comparing %s with %s%s", buildLabel(a), buildLabel(b), how)))
+	fmt.Printf("  #%s: started %s, took %s\n", a.ID, a.Timestamp.Format("2006-01-02 15:04"), formatting.Duration(time.Duration(a.Duration)*time.Millisecond))
+	fmt.Printf("  #%s: started %s, took %s\n", b.ID, b.Timestamp.Format("2006-01-02 15:04"), formatting.Duration(time.Duration(b.Duration)*time.Millisecond))
+	fmt.Println()
+}
+
+func printParamChanges(changes []paramChange) {
+	fmt.Println(infoBoldStyle.Render("PARAMETERS"))
+	if len(changes) == 0 {
+		fmt.Println(grayStyle.Render("  identical"))
+	}
+	for _, c := range changes {
+		fmt.Printf("  %s: %q → %q\n", c.Name, c.B, c.A)
+	}
+	fmt.Println()
+}
+
+func printCommits(a, b *jenkins.WorkflowRun, commits []buildCommit) {
+	fmt.Println(infoBoldStyle.Render(fmt.Sprintf("CHANGES SINCE #%s", b.ID)))
+	switch {
+	case commits == nil:
+		fmt.Println(grayStyle.Render(fmt.Sprintf("  #%s is not newer than #%s", a.ID, b.ID)))
+	case len(commits) == 0:
+		fmt.Println(grayStyle.Render("  no SCM changes"))
+	}
+	for _, c := range commits {
+		printCommit(c, false)
+	}
+	fmt.Println()
+}
+
+func printStageChanges(a, b *jenkins.WorkflowRun, changes []stageChange) {
+	fmt.Println(infoBoldStyle.Render("STAGES"))
+
+	status := func(s *jenkins.Stage) string {
+		if s == nil {
+			return "-"
+		}
+		return s.Status
+	}
+	duration := func(s *jenkins.Stage) string {
+		if s == nil {
+			return "-"
+		}
+		return formatting.Duration(time.Duration(s.Duration) * time.Millisecond)
+	}
+
+	highlight := map[int]lipgloss.Style{}
+	t := table.New().
+		Border(lipgloss.ThickBorder()).
+		BorderStyle(BorderStyle).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return HeaderStyle
+			}
+			style := EvenRowStyle
+			if row%2 != 0 {
+				style = OddRowStyle
+			}
+			if h, ok := highlight[row]; ok && col > 0 {
+				style = h.Inherit(style)
+			}
+			return stdRe.NewStyle().Width(0).Inherit(style)
+		}).
+		Headers("STAGE", "#"+b.ID, "#"+a.ID, "TIME #"+b.ID, "TIME #"+a.ID, "DELTA")
+
+	newFailures, slower, faster := []string{}, []string{}, []string{}
+	for i, sc := range changes {
+		delta := "-"
+		if d, ok := sc.Delta(); ok {
+			delta = formatDelta(d)
+		}
+		t.Row(sc.Name, status(sc.B), status(sc.A), duration(sc.B), duration(sc.A), delta)
+
+		switch d, _ := sc.Delta(); {
+		case sc.NewFailure():
+			highlight[i+1] = stdRe.NewStyle().Bold(true).Foreground(red)
+			newFailures = append(newFailures, fmt.Sprintf("%s (%s → %s)", sc.Name, status(sc.B), status(sc.A)))
+		case sc.LargeDelta(diffThreshold) && d > 0:
+			highlight[i+1] = orangeStyle
+			slower = append(slower, fmt.Sprintf("%s %s", sc.Name, formatDelta(d)))
+		case sc.LargeDelta(diffThreshold):
+			faster = append(faster, fmt.Sprintf("%s %s", sc.Name, formatDelta(d)))
+		}
+	}
+	fmt.Println(t)
+
+	if len(newFailures) > 0 {
+		fmt.Println(failureStyle.Render("New failures:") + " " + strings.Join(newFailures, ", "))
+	}
+	if len(slower) > 0 {
+		fmt.Println(orangeStyle.Render("Slower:") + " " + strings.Join(slower, ", "))
+	}
+	if len(faster) > 0 {
+		fmt.Println(grayStyle.Render("Faster:") + " " + strings.Join(faster, ", "))
+	}
+	fmt.Println()
+}
+
+func printAgentChanges(a, b *jenkins.WorkflowRun, agentsA, agentsB []string) {
+	fmt.Println(infoBoldStyle.Render("AGENTS"))
+	if slices.Equal(agentsA, agentsB) {
+		fmt.Printf("  same agents: %s\n", joinOrNone(agentsA))
+		return
+	}
+	fmt.Printf("  #%s: %s\n", b.ID, joinOrNone(agentsB))
+	fmt.Printf("  #%s: %s\n", a.ID, joinOrNone(agentsA))
+	added := []string{}
+	for _, agent := range agentsA {
+		if !slices.Contains(agentsB, agent) {
+			added = append(added, agent)
+		}
+	}
+	if len(added) > 0 {
+		fmt.Println(orangeStyle.Render(fmt.Sprintf("  only in #%s: %s", a.ID, strings.Join(added, ", "))))
+	}
+}
+
+// formatDelta renders a signed duration such as +1m30s or -12s
+func formatDelta(d time.Duration) string {
+	if d >= 0 {
+		return "+" + roundDuration(d)
+	}
+	return "-" + roundDuration(-d)
+}
+
+// joinOrNone joins items with commas, or returns "none"
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
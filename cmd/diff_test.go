@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"slices"
+	"testing"
+	"time"
+)
+
+func paramRun(id string, params map[string]string) *jenkins.WorkflowRun {
+	action := jenkins.WorkflowAction{}
+	for name, value := range params {
+		action.Parameters = append(action.Parameters, jenkins.WorkflowParameter{Name: name, Value: value})
+	}
+	return &jenkins.WorkflowRun{ID: id, Actions: []jenkins.WorkflowAction{action}}
+}
+
+func TestCompareBuilds(t *testing.T) {
+	a := paramRun("20", map[string]string{"PRODUCT": "rs", "DEBUG": "true"})
+	b := paramRun("10", map[string]string{"PRODUCT": "rs", "CLEAN": "true"})
+	jobA := &jenkins.Job{Stages: []jenkins.Stage{
+		testStage("Build", "SUCCESS", 10*time.Minute, "agent-1"),
+		testStage("Test", "FAILED", 2*time.Minute, "agent-3"),
+		testStage("Lint", "FAILED", time.Minute, "agent-1"),
+	}}
+	jobB := &jenkins.Job{Stages: []jenkins.Stage{
+		testStage("Build", "SUCCESS", 5*time.Minute, "agent-1"),
+		testStage("Test", "SUCCESS", 2*time.Minute+5*time.Second, "agent-2"),
+		testStage("Deploy", "SUCCESS", time.Minute, "agent-2"),
+	}}
+
+	d := compareBuilds(a, jobA, b, jobB)
+
+	wantParams := []paramChange{{Name: "CLEAN", A: "", B: "true"}, {Name: "DEBUG", A: "true", B: ""}}
+	if !slices.Equal(d.Params, wantParams) {
+		t.Errorf("Params = %+v, want %+v", d.Params, wantParams)
+	}
+
+	names := []string{}
+	for _, sc := range d.Stages {
+		names = append(names, sc.Name)
+	}
+	if !slices.Equal(names, []string{"Build", "Test", "Lint", "Deploy"}) {
+		t.Errorf("stages = %v", names)
+	}
+
+	build, test, lint, deploy := d.Stages[0], d.Stages[1], d.Stages[2], d.Stages[3]
+	if !test.NewFailure() || !lint.NewFailure() || build.NewFailure() || deploy.NewFailure() {
+		t.Error("NewFailure() should be set for Test and Lint only")
+	}
+	if delta, ok := build.Delta(); !ok || delta != 5*time.Minute || !build.LargeDelta(25) {
+		t.Errorf("Build delta = %s, %v; want a large +5m", delta, ok)
+	}
+	if test.LargeDelta(1) {
+		t.Error("a 5s change is below the minimum delta")
+	}
+	if _, ok := deploy.Delta(); ok || deploy.A != nil {
+		t.Error("Deploy only ran in build B")
+	}
+
+	if !slices.Equal(d.AgentsA, []string{"agent-1", "agent-3"}) || !slices.Equal(d.AgentsB, []string{"agent-1", "agent-2"}) {
+		t.Errorf("agents = %v / %v", d.AgentsA, d.AgentsB)
+	}
+}
+
+func TestFormatDelta(t *testing.T) {
+	if got := formatDelta(90 * time.Second); got != "+1m30s" {
+		t.Errorf("formatDelta(90s) = %s", got)
+	}
+	if got := formatDelta(-12 * time.Second); got != "-12s" {
+		t.Errorf("formatDelta(-12s) = %s", got)
+	}
+}
@@ -66,7 +66,7 @@ func NewAuthError(message string) *AuthError {
 			"  JENKINS_HOST - Your Jenkins server URL\n" +
 			"  JENKINS_USER - Your Jenkins username\n" +
 			"  JENKINS_KEY  - Your Jenkins API key\n\n" +
-			"Or configure them in ~/.jenkins.yaml",
+			"Or configure them in ~/.jenkins.yaml, or store the key with 'jenkins login'",
 	}
 }
 
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"jenkins/internal/jenkins"
+	"net"
+)
+
+// Exit codes. Build results map to their own codes so scripts can tell a
+// failed build apart from a failure to talk to Jenkins.
+const (
+	ExitSuccess  = 0
+	ExitError    = 1
+	ExitFailure  = 2
+	ExitUnstable = 3
+	ExitAborted  = 4
+	ExitNotFound = 5
+	ExitAuth     = 6
+	ExitNetwork  = 7
+)
+
+// BuildResultError reports a build that finished without succeeding, so the
+// command exits with the code for its result
+type BuildResultError struct {
+	BuildID string
+	Result  string
+}
+
+func (e *BuildResultError) Error() string {
+	return fmt.Sprintf("build %s finished with result %s", e.BuildID, e.Result)
+}
+
+// ExitCode returns the exit code for the build's result
+func (e *BuildResultError) ExitCode() int {
+	switch e.Result {
+	case "SUCCESS":
+		return ExitSuccess
+	case "UNSTABLE":
+		return ExitUnstable
+	case "ABORTED", "NOT_BUILT":
+		return ExitAborted
+	default:
+		return ExitFailure
+	}
+}
+
+// resultSeverity orders build results from best to worst
+func resultSeverity(result string) int {
+	switch result {
+	case "SUCCESS":
+		return 0
+	case "UNSTABLE":
+		return 1
+	case "ABORTED", "NOT_BUILT":
+		return 2
+	default:
+		return 3
+	}
+}
+
+// worstResult returns an error for the worst result among finished builds,
+// or nil if they all succeeded. Running builds are ignored.
+func worstResult(builds []*jenkins.WorkflowRun) error {
+	var worst *jenkins.WorkflowRun
+	for _, b := range builds {
+		if b.Building || b.Result == "SUCCESS" {
+			continue
+		}
+		if worst == nil || resultSeverity(b.Result) > resultSeverity(worst.Result) {
+			worst = b
+		}
+	}
+	if worst == nil {
+		return nil
+	}
+	return &BuildResultError{BuildID: worst.ID, Result: worst.Result}
+}
+
+// exitCode maps an error returned by a command to the process exit code
+func exitCode(err error) int {
+	var resultErr *BuildResultError
+	var notFoundErr *BuildNotFoundError
+	var authErr *AuthError
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return ExitSuccess
+	case errors.As(err, &resultErr):
+		return resultErr.ExitCode()
+	case errors.As(err, &notFoundErr), errors.Is(err, jenkins.ErrNotFound):
+		return ExitNotFound
+	case errors.As(err, &authErr), errors.Is(err, jenkins.ErrUnauthorized):
+		return ExitAuth
+	case errors.As(err, &netErr):
+		return ExitNetwork
+	default:
+		return ExitError
+	}
+}
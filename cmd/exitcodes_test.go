@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"jenkins/internal/jenkins"
+	"net/url"
+	"syscall"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitSuccess},
+		{"failure", &BuildResultError{"1", "FAILURE"}, ExitFailure},
+		{"unstable", &BuildResultError{"1", "UNSTABLE"}, ExitUnstable},
+		{"aborted", &BuildResultError{"1", "ABORTED"}, ExitAborted},
+		{"not built", &BuildResultError{"1", "NOT_BUILT"}, ExitAborted},
+		{"build not found", NewBuildNotFoundError("1", "master"), ExitNotFound},
+		{"jenkins not found", fmt.Errorf("get build: %w", jenkins.ErrNotFound), ExitNotFound},
+		{"auth", NewAuthError("bad key"), ExitAuth},
+		{"unauthorized", fmt.Errorf("get build: %w", jenkins.ErrUnauthorized), ExitAuth},
+		{"network", &url.Error{Op: "Get", URL: "http://jenkins", Err: syscall.ECONNREFUSED}, ExitNetwork},
+		{"other", errors.New("boom"), ExitError},
+		{"validation", NewValidationError("stage", "x", "unknown"), ExitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorstResult(t *testing.T) {
+	run := func(id, result string, building bool) *jenkins.WorkflowRun {
+		return &jenkins.WorkflowRun{ID: id, Result: result, Building: building}
+	}
+
+	if err := worstResult([]*jenkins.WorkflowRun{run("1", "SUCCESS", false), run("2", "", true)}); err != nil {
+		t.Errorf("worstResult(success, running) = %v, want nil", err)
+	}
+
+	err := worstResult([]*jenkins.WorkflowRun{
+		run("1", "UNSTABLE", false),
+		run("2", "FAILURE", false),
+		run("3", "ABORTED", false),
+		run("4", "SUCCESS", false),
+	})
+	var resultErr *BuildResultError
+	if !errors.As(err, &resultErr) || resultErr.BuildID != "2" || exitCode(err) != ExitFailure {
+		t.Errorf("worstResult = %v, want build 2 FAILURE", err)
+	}
+
+	err = worstResult([]*jenkins.WorkflowRun{run("1", "UNSTABLE", false), run("2", "ABORTED", false)})
+	if exitCode(err) != ExitAborted {
+		t.Errorf("worstResult(unstable, aborted) exit code = %d, want %d", exitCode(err), ExitAborted)
+	}
+}
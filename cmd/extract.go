@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"jenkins/internal/extract"
+	"slices"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// Lines of context kept around matches of config-defined patterns
+	viper.SetDefault("diagnose.context", 3)
+}
+
+// patternKeys returns the names of the error patterns defined in the config
+// file, sorted
+func patternKeys() []string {
+	return configKeys("diagnose.patterns")
+}
+
+// errorPatterns returns the patterns used to find errors in stage logs:
+// config-defined patterns first, then the built-in ones that are neither
+// disabled with diagnose.disable nor replaced by a pattern of the same name
+func errorPatterns() ([]extract.Pattern, error) {
+	patterns := []extract.Pattern{}
+	for _, key := range patternKeys() {
+		prefix := "diagnose.patterns." + key + "."
+		expr := viper.GetString(prefix + "regex")
+		if expr == "" {
+			return nil, NewConfigError(prefix+"regex", "required for error patterns")
+		}
+
+		before, after := viper.GetInt("diagnose.context"), viper.GetInt("diagnose.context")
+		if viper.IsSet(prefix + "before") {
+			before = viper.GetInt(prefix + "before")
+		}
+		if viper.IsSet(prefix + "after") {
+			after = viper.GetInt(prefix + "after")
+		}
+
+		p, err := extract.Compile(key, expr, before, after)
+		if err != nil {
+			return nil, NewConfigError(prefix+"regex", err.Error())
+		}
+		patterns = append(patterns, p)
+	}
+
+	disabled := viper.GetStringSlice("diagnose.disable")
+	for _, p := range extract.DefaultPatterns() {
+		if !slices.Contains(disabled, p.Name) && !slices.ContainsFunc(patterns, func(c extract.Pattern) bool { return c.Name == p.Name }) {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestErrorPatterns(t *testing.T) {
+	viper.Set("diagnose.patterns.flyway.regex", `Migration .* failed`)
+	viper.Set("diagnose.patterns.flyway.after", 10)
+	viper.Set("diagnose.patterns.oom.regex", `heap exhausted`)
+	viper.Set("diagnose.disable", []string{"exception"})
+	defer func() {
+		viper.Set("diagnose.patterns.flyway.regex", nil)
+		viper.Set("diagnose.patterns.flyway.after", nil)
+		viper.Set("diagnose.patterns.oom.regex", nil)
+		viper.Set("diagnose.disable", nil)
+	}()
+
+	patterns, err := errorPatterns()
+	if err != nil {
+		t.Fatalf("errorPatterns() failed: %v", err)
+	}
+
+	names := map[string]int{}
+	for _, p := range patterns {
+		names[p.Name]++
+	}
+	if names["oom"] != 1 || names["exception"] != 0 || names["exit-code"] != 1 {
+		t.Errorf("patterns = %v, want oom once, no exception and the other built-ins", names)
+	}
+	if patterns[0].Name != "flyway" || patterns[1].Name != "oom" {
+		t.Fatalf("config patterns should come first, got %s, %s", patterns[0].Name, patterns[1].Name)
+	}
+	if flyway := patterns[0]; flyway.Before != 3 || flyway.After != 10 {
+		t.Errorf("flyway context = %d/%d, want 3/10", flyway.Before, flyway.After)
+	}
+	if !patterns[1].Regexp.MatchString("heap exhausted") || patterns[1].Regexp.MatchString("OOMKilled") {
+		t.Error("a config pattern should replace the built-in of the same name")
+	}
+
+	viper.Set("diagnose.patterns.flyway.regex", `(`)
+	var cfgErr *ConfigError
+	if _, err := errorPatterns(); !errors.As(err, &cfgErr) || cfgErr.Field != "diagnose.patterns.flyway.regex" {
+		t.Errorf("errorPatterns() error = %v, want ConfigError for the regex", err)
+	}
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/jenkins"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	flakyLimit int
+	flakyMax   int
+)
+
+func init() {
+	rootCmd.AddCommand(flakyCmd)
+
+	flakyCmd.Flags().IntVarP(&flakyLimit, "limit", "l", 50, "Number of recent builds to analyze")
+	flakyCmd.Flags().IntVarP(&flakyMax, "max", "n", 20, "Maximum number of stages to print (0 for all)")
+}
+
+var flakyCmd = &cobra.Command{
+	Use:   "flaky",
+	Short: "Rank stages by flakiness across recent builds",
+	Long: `Analyze the most recent builds of the pipeline and rank their top-level stages
+by flakiness.
+
+A flip is a stage that failed in one build and passed in the next build with
+identical parameters, which usually means the failure was not caused by a
+code change. Stages are ranked by flips, then by failure rate. Aborted and
+skipped stages are not counted.
+
+The agents (ExecNode) each stage ran on are compared too: an agent on which a
+stage fails at least twice as often as it does overall is shown as a suspect,
+and a per-agent failure table is printed at the end.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline := viper.GetString("pipeline")
+
+		builds, err := jenkinsClient.GetBuilds(pipeline, flakyLimit)
+		if err != nil {
+			return err
+		}
+		finished := []jenkins.WorkflowRun{}
+		for _, b := range builds {
+			if !b.Building {
+				finished = append(finished, b)
+			}
+		}
+		if len(finished) == 0 {
+			return fmt.Errorf("no finished builds found on %s", pipeline)
+		}
+
+		fmt.Printf("Analyzing stages of %d build(s) on [%s]...\n", len(finished), pipeline)
+		jobs := fetchJobDetails(pipeline, finished)
+
+		runs := []flakyRun{}
+		for _, b := range finished {
+			job, ok := jobs[b.ID]
+			if !ok {
+				continue
+			}
+			id, err := strconv.Atoi(b.ID)
+			if err != nil {
+				continue
+			}
+			runs = append(runs, flakyRun{ID: id, Params: paramsKey(b.Parameters()), Stages: job.Stages})
+		}
+
+		cmd.SilenceUsage = true
+
+		stages, agents := analyzeFlakiness(runs)
+		failing := []*stageFlakiness{}
+		for _, s := range stages {
+			if s.Overall.Failures > 0 {
+				failing = append(failing, s)
+			}
+		}
+		if len(failing) == 0 {
+			fmt.Println(successStyle.Render(fmt.Sprintf("✓ No stage failed in the last %d build(s)", len(runs))))
+			return nil
+		}
+
+		fmt.Println()
+		printFlakyStages(failing, flakyMax)
+		fmt.Println(grayStyle.Render(fmt.Sprintf("%d of %d stage(s) failed at least once in %d build(s)", len(failing), len(stages), len(runs))))
+		fmt.Println()
+		printAgentFailures(agents)
+		return nil
+	},
+}
+
+// flakyRun is a finished build with the key of its parameters
+type flakyRun struct {
+	ID     int
+	Params string
+	Stages []jenkins.Stage
+}
+
+// failureCount counts stage runs and failures
+type failureCount struct {
+	Runs     int
+	Failures int
+}
+
+// Rate returns the failure rate between 0 and 1
+func (c failureCount) Rate() float64 {
+	if c.Runs == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Runs)
+}
+
+// add counts a run of stage, which failed unless it succeeded
+func (c *failureCount) add(stage jenkins.Stage) {
+	c.Runs++
+	if stage.Status != "SUCCESS" {
+		c.Failures++
+	}
+}
+
+// countedRun reports whether a stage run counts towards failure rates.
+// Aborted, skipped and unfinished runs don't.
+func countedRun(stage jenkins.Stage) bool {
+	return stage.Status == "SUCCESS" || stage.Status == "FAILED" || stage.Status == "UNSTABLE"
+}
+
+// stageFlakiness is the failure history of a stage
+type stageFlakiness struct {
+	stageByAgent
+	// Flips counts failures followed by a pass with identical parameters
+	Flips int
+}
+
+// paramsKey returns a canonical string for a set of build parameters
+func paramsKey(params map[string]string) string {
+	pairs := make([]string, 0, len(params))
+	for name, value := range params {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\x00")
+}
+
+// analyzeFlakiness counts runs, failures and flips of every top-level stage,
+// and runs and failures per agent. Stages are ranked by flips, then failure
+// rate, then name.
+func analyzeFlakiness(runs []flakyRun) ([]*stageFlakiness, map[string]*failureCount) {
+	runs = append([]flakyRun{}, runs...)
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID < runs[j].ID })
+
+	stages := map[string]*stageFlakiness{}
+	agents := map[string]*failureCount{}
+	// lastFailed tracks, per parameter set, whether each stage failed in the
+	// previous build with those parameters
+	lastFailed := map[string]map[string]bool{}
+
+	for _, run := range runs {
+		if lastFailed[run.Params] == nil {
+			lastFailed[run.Params] = map[string]bool{}
+		}
+		for _, stage := range run.Stages {
+			if !countedRun(stage) {
+				continue
+			}
+			failed := stage.Status != "SUCCESS"
+
+			s, ok := stages[stage.Name]
+			if !ok {
+				s = &stageFlakiness{stageByAgent: stageByAgent{Name: stage.Name, Agents: map[string]*agentStats{}}}
+				stages[stage.Name] = s
+			}
+			s.add(stage)
+			if !failed && lastFailed[run.Params][stage.Name] {
+				s.Flips++
+			}
+			lastFailed[run.Params][stage.Name] = failed
+
+			if stage.ExecNode == "" {
+				continue
+			}
+			if agents[stage.ExecNode] == nil {
+				agents[stage.ExecNode] = &failureCount{}
+			}
+			agents[stage.ExecNode].add(stage)
+		}
+	}
+
+	ranked := make([]*stageFlakiness, 0, len(stages))
+	for _, s := range stages {
+		ranked = append(ranked, s)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Flips != b.Flips {
+			return a.Flips > b.Flips
+		}
+		if a.Overall.Rate() != b.Overall.Rate() {
+			return a.Overall.Rate() > b.Overall.Rate()
+		}
+		return a.Name < b.Name
+	})
+	return ranked, agents
+}
+
+// percent formats a rate between 0 and 1 as a percentage
+func percent(rate float64) string {
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
+
+func printFlakyStages(stages []*stageFlakiness, max int) {
+	if max > 0 && len(stages) > max {
+		stages = stages[:max]
+	}
+
+	t := reportTable("STAGE", "RUNS", "FAILED", "RATE", "FLIPS", "SUSPECT AGENT")
+	for _, s := range stages {
+		suspect := "-"
+		if name, ok := s.SuspectAgent(); ok {
+			a := s.Agents[name]
+			suspect = fmt.Sprintf("%s (%d/%d)", name, a.Failures, a.Runs)
+		}
+		t.Row(s.Name, strconv.Itoa(s.Overall.Runs), strconv.Itoa(s.Overall.Failures), percent(s.Overall.Rate()), strconv.Itoa(s.Flips), suspect)
+	}
+	fmt.Println(t)
+}
+
+func printAgentFailures(agents map[string]*failureCount) {
+	if len(agents) == 0 {
+		fmt.Println(grayStyle.Render("No agent information in the stage data"))
+		return
+	}
+
+	names := make([]string, 0, len(agents))
+	for name := range agents {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := agents[names[i]], agents[names[j]]
+		if a.Rate() != b.Rate() {
+			return a.Rate() > b.Rate()
+		}
+		return names[i] < names[j]
+	})
+
+	t := reportTable("AGENT", "STAGE RUNS", "FAILED", "RATE")
+	for _, name := range names {
+		a := agents[name]
+		t.Row(name, strconv.Itoa(a.Runs), strconv.Itoa(a.Failures), percent(a.Rate()))
+	}
+	fmt.Println(t)
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"testing"
+)
+
+func TestAnalyzeFlakiness(t *testing.T) {
+	rs := paramsKey(map[string]string{"PRODUCT": "rs", "BRANCH": "main"})
+	pra := paramsKey(map[string]string{"PRODUCT": "pra", "BRANCH": "main"})
+
+	runs := []flakyRun{
+		// listed newest first, as Jenkins returns them
+		{ID: 5, Params: rs, Stages: []jenkins.Stage{testStage("Build", "SUCCESS", 0, "a1"), testStage("Test", "SUCCESS", 0, "a1")}},
+		{ID: 4, Params: pra, Stages: []jenkins.Stage{testStage("Build", "SUCCESS", 0, "a2"), testStage("Test", "SUCCESS", 0, "a1")}},
+		{ID: 3, Params: rs, Stages: []jenkins.Stage{testStage("Build", "SUCCESS", 0, "a2"), testStage("Test", "FAILED", 0, "a2")}},
+		{ID: 2, Params: pra, Stages: []jenkins.Stage{testStage("Build", "FAILED", 0, "a2"), testStage("Test", "NOT_EXECUTED", 0, "")}},
+		{ID: 1, Params: rs, Stages: []jenkins.Stage{testStage("Build", "SUCCESS", 0, "a1"), testStage("Test", "FAILED", 0, "a2")}},
+	}
+
+	stages, agents := analyzeFlakiness(runs)
+	if len(stages) != 2 || stages[0].Name != "Test" {
+		t.Fatalf("expected Test to rank first, got %+v", stages)
+	}
+
+	test, build := stages[0], stages[1]
+	// Test failed in 1 and 3 (rs) and passed in 5 (rs): one flip. 4 is pra.
+	if test.Overall.Runs != 4 || test.Overall.Failures != 2 || test.Flips != 1 {
+		t.Errorf("Test = %d runs, %d failures, %d flips; want 4, 2, 1", test.Overall.Runs, test.Overall.Failures, test.Flips)
+	}
+	// Build failed in 2 (pra) and passed in 4 (pra): one flip, but a lower rate
+	if build.Overall.Runs != 5 || build.Overall.Failures != 1 || build.Flips != 1 {
+		t.Errorf("Build = %d runs, %d failures, %d flips; want 5, 1, 1", build.Overall.Runs, build.Overall.Failures, build.Flips)
+	}
+
+	if name, ok := test.SuspectAgent(); !ok || name != "a2" {
+		t.Errorf("SuspectAgent() = %q, %v; want a2", name, ok)
+	}
+	if _, ok := build.SuspectAgent(); ok {
+		t.Error("a single failure should not make a suspect agent")
+	}
+
+	if a2 := agents["a2"]; a2.Runs != 5 || a2.Failures != 3 {
+		t.Errorf("agent a2 = %+v, want 5 runs and 3 failures", a2)
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func init() {
+	// Health check defaults (can be overridden in config file)
+	viper.SetDefault("deployment.health_url", "https://{{.Subdomain}}.{{.Domain}}/version")
+	viper.SetDefault("deployment.health_pattern", "")
+	viper.SetDefault("deployment.health_interval", 10*time.Second)
+}
+
+// deployValues holds the values available to a deploy target's parameter
+// and health URL templates
+type deployValues struct {
+	Pipeline    string
+	BuildNumber string
+	Subdomain   string
+	Domain      string
+}
+
+// expandTemplate expands a deploy template with values. field names the
+// config key the template came from, for errors.
+func expandTemplate(field, tmpl string, values deployValues) (string, error) {
+	t, err := template.New(field).Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", NewConfigError(field, err.Error())
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, values); err != nil {
+		return "", NewConfigError(field, err.Error())
+	}
+	return buf.String(), nil
+}
+
+// healthChecker decides whether a site is serving a given build
+type healthChecker struct {
+	client      *http.Client
+	url         string
+	buildNumber string
+	// pattern extracts the reported build from the response in its first
+	// group. Without it, the body only has to contain the build number.
+	pattern *regexp.Regexp
+}
+
+// check fetches the health URL once and returns whether the pushed build is
+// being served, along with a short description of what was seen
+func (h *healthChecker) check() (bool, string) {
+	res, err := h.client.Get(h.url)
+	if err != nil {
+		return false, fmt.Sprintf("unreachable (%v)", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return false, fmt.Sprintf("failed to read response (%v)", err)
+	}
+	if res.StatusCode >= 400 {
+		return false, fmt.Sprintf("HTTP %d", res.StatusCode)
+	}
+
+	if h.pattern != nil {
+		m := h.pattern.FindSubmatch(body)
+		if len(m) < 2 {
+			return false, fmt.Sprintf("HTTP %d, no version reported", res.StatusCode)
+		}
+		reported := strings.TrimSpace(string(m[1]))
+		return reported == h.buildNumber, fmt.Sprintf("serving build %s", reported)
+	}
+
+	if regexp.MustCompile(`\b` + regexp.QuoteMeta(h.buildNumber) + `\b`).Match(body) {
+		return true, fmt.Sprintf("serving build %s", h.buildNumber)
+	}
+	return false, fmt.Sprintf("HTTP %d, build %s not reported yet", res.StatusCode, h.buildNumber)
+}
+
+// waitHealthy polls the target's health URL until it reports the pushed
+// build or the timeout passes, printing each change in what it sees
+func waitHealthy(t deployTarget, target deployValues, timeout time.Duration) error {
+	url, err := expandTemplate(t.field("health_url"), t.HealthURL, target)
+	if err != nil {
+		return err
+	}
+
+	h := &healthChecker{client: &http.Client{Timeout: 30 * time.Second}, url: url, buildNumber: target.BuildNumber}
+	if t.HealthPattern != "" {
+		if h.pattern, err = regexp.Compile(t.HealthPattern); err != nil {
+			return NewConfigError(t.field("health_pattern"), err.Error())
+		}
+		if h.pattern.NumSubexp() < 1 {
+			return NewConfigError(t.field("health_pattern"), "must have a group capturing the build number")
+		}
+	}
+
+	fmt.Printf("Waiting for %s to serve build %s (timeout %s)...\n", url, target.BuildNumber, timeout)
+	start := time.Now()
+	last := ""
+	for {
+		healthy, seen := h.check()
+		if seen != last {
+			fmt.Println(noStyle.Render(fmt.Sprintf("[%s] %s", roundDuration(time.Since(start)), seen)))
+			last = seen
+		}
+		if healthy {
+			fmt.Println(infoBoldStyle.Render(fmt.Sprintf("%s.%s is serving build %s", target.Subdomain, target.Domain, target.BuildNumber)))
+			return nil
+		}
+		if time.Since(start) >= timeout {
+			return fmt.Errorf("%s.%s was not serving build %s after %s (last: %s)", target.Subdomain, target.Domain, target.BuildNumber, timeout, last)
+		}
+		time.Sleep(viper.GetDuration("deployment.health_interval"))
+	}
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	values := deployValues{Pipeline: "master", BuildNumber: "1234", Subdomain: "qa1", Domain: "dev.example.com"}
+
+	url, err := expandTemplate("health_url", "https://{{.Subdomain}}.{{.Domain}}/version?b={{.BuildNumber}}", values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://qa1.dev.example.com/version?b=1234" {
+		t.Errorf("expandTemplate = %s", url)
+	}
+
+	if _, err := expandTemplate("health_url", "https://{{.Host}}/", values); err == nil {
+		t.Error("expected error for an unknown template field")
+	}
+	if _, err := expandTemplate("health_url", "https://{{.Subdomain", values); err == nil {
+		t.Error("expected error for an invalid template")
+	}
+}
+
+func TestHealthCheckerCheck(t *testing.T) {
+	body, status := "", http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	h := &healthChecker{client: server.Client(), url: server.URL, buildNumber: "1234"}
+
+	status = http.StatusServiceUnavailable
+	if healthy, seen := h.check(); healthy || seen != "HTTP 503" {
+		t.Errorf("check() on 503 = %v, %q", healthy, seen)
+	}
+
+	status, body = http.StatusOK, `{"version": "7.1.0", "build": 12345}`
+	if healthy, _ := h.check(); healthy {
+		t.Error("check() matched a build number that only contains the pushed one")
+	}
+
+	body = `{"version": "7.1.0", "build": 1234}`
+	if healthy, _ := h.check(); !healthy {
+		t.Error("check() didn't match the pushed build")
+	}
+
+	h.pattern = regexp.MustCompile(`"build":\s*(\d+)`)
+	body = `{"build": 1233, "previous": 1234}`
+	if healthy, seen := h.check(); healthy || !strings.Contains(seen, "1233") {
+		t.Errorf("check() with pattern = %v, %q", healthy, seen)
+	}
+	body = `{"build": 1234}`
+	if healthy, _ := h.check(); !healthy {
+		t.Error("check() with pattern didn't match the pushed build")
+	}
+
+	h.url = "http://127.0.0.1:1"
+	if healthy, seen := h.check(); healthy || !strings.HasPrefix(seen, "unreachable") {
+		t.Errorf("check() on a closed port = %v, %q", healthy, seen)
+	}
+}
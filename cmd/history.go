@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"sync"
+)
+
+// historyWorkers is how many builds' stages are fetched at once
+const historyWorkers = 10
+
+// fetchJobDetails fetches the stages of each build, concurrently. Builds
+// whose stages cannot be fetched are left out of the result, which is keyed
+// by build ID.
+func fetchJobDetails(pipeline string, builds []jenkins.WorkflowRun) map[string]*jenkins.Job {
+	jobs := map[string]*jenkins.Job{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, historyWorkers)
+
+	for _, b := range builds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			job, err := jenkinsClient.GetJobDetails(pipeline, b.ID)
+			if err != nil {
+				verbose("Failed to get stages for build [%s] [%v]", b.ID, err)
+				return
+			}
+			mu.Lock()
+			jobs[b.ID] = job
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return jobs
+}
+
+// fetchTestReports fetches the test report of each build, concurrently.
+// Builds without a report are left out of the result, which is keyed by
+// build ID.
+func fetchTestReports(pipeline string, builds []jenkins.WorkflowRun) map[string]*jenkins.TestReport {
+	reports := map[string]*jenkins.TestReport{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, historyWorkers)
+
+	for _, b := range builds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			report, err := jenkinsClient.GetTestReport(pipeline, b.ID)
+			if err != nil {
+				verbose("No test report for build [%s] [%v]", b.ID, err)
+				return
+			}
+			mu.Lock()
+			reports[b.ID] = report
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return reports
+}
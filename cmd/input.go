@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/jenkins"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	inputID      string
+	inputParams  []string
+	inputProceed bool
+	inputAbort   bool
+)
+
+func init() {
+	rootCmd.AddCommand(inputCmd)
+
+	inputCmd.Flags().StringVar(&inputID, "id", "", "Input step to respond to when the build is waiting on several")
+	inputCmd.Flags().StringArrayVarP(&inputParams, "param", "p", []string{}, "Input parameter as NAME=VALUE (repeatable)")
+	inputCmd.Flags().BoolVar(&inputProceed, "proceed", false, "Proceed past the input step")
+	inputCmd.Flags().BoolVar(&inputAbort, "abort", false, "Abort the input step, aborting the build")
+	inputCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	inputCmd.MarkFlagsMutuallyExclusive("proceed", "abort")
+}
+
+var inputCmd = &cobra.Command{
+	Use:   "input [build_id]",
+	Short: "List, approve or reject the input steps a build is waiting on",
+	Long: `List the input steps a running pipeline build is paused on, with their
+messages and parameters.
+
+With --proceed the build continues past the input step, using parameter values
+given with -p. Parameters without a value are prompted for on a terminal and
+otherwise left to their defaults. With --abort the input is rejected, which
+aborts the build.
+
+Examples:
+  jenkins input 1234
+  jenkins input 1234 --proceed -p VERSION=2.1 -p NOTIFY=true
+  jenkins input 1234 --abort --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline := viper.GetString("pipeline")
+
+		buildID, err := resolveBuildID(args[0])
+		if err != nil {
+			return err
+		}
+
+		inputs, err := jenkinsClient.GetPendingInputs(pipeline, buildID)
+		if err != nil {
+			return fmt.Errorf("failed to get pending input for build %s: %w", buildID, err)
+		}
+		if len(inputs) == 0 {
+			fmt.Println(grayStyle.Render(fmt.Sprintf("Build #%s is not waiting for input", buildID)))
+			return nil
+		}
+
+		if !inputProceed && !inputAbort {
+			for _, input := range inputs {
+				printPendingInput(input)
+			}
+			fmt.Printf("Proceed with: jenkins input %s --proceed [-p NAME=VALUE...]\n", buildID)
+			fmt.Printf("Abort with:   jenkins input %s --abort\n", buildID)
+			return nil
+		}
+
+		input, err := selectInput(inputs, inputID)
+		if err != nil {
+			return err
+		}
+
+		if inputAbort {
+			if !assumeYes {
+				ok, err := confirm(stdinReader, os.Stdout, fmt.Sprintf("Abort input [%s] of build #%s? This aborts the build.", input.Message, buildID))
+				if err != nil || !ok {
+					fmt.Println(grayStyle.Render("Skipped"))
+					return err
+				}
+			}
+			if err := jenkinsClient.AbortInput(pipeline, buildID, input); err != nil {
+				return fmt.Errorf("failed to abort input %s: %w", input.ID, err)
+			}
+			fmt.Println(infoBoldStyle.Render(fmt.Sprintf("Aborted input [%s] of build #%s", input.ID, buildID)))
+			return nil
+		}
+
+		defs := input.Parameters()
+		params, err := parseParamFlags(inputParams)
+		if err != nil {
+			return err
+		}
+		if term.IsTerminal(os.Stdin.Fd()) {
+			if err := promptParams(missingParams(defs, params), params, stdinReader, os.Stdout); err != nil {
+				return err
+			}
+		}
+		for _, def := range defs {
+			if _, ok := params[def.Name]; !ok && def.Default() != "" {
+				params[def.Name] = def.Default()
+			}
+		}
+		if err := validateParams(defs, params); err != nil {
+			return err
+		}
+
+		if !assumeYes {
+			printParams(params)
+			ok, err := confirm(stdinReader, os.Stdout, fmt.Sprintf("%s build #%s past [%s]?", proceedText(input), buildID, input.Message))
+			if err != nil || !ok {
+				fmt.Println(grayStyle.Render("Skipped"))
+				return err
+			}
+		}
+
+		if err := jenkinsClient.SubmitInput(pipeline, buildID, input, params); err != nil {
+			return fmt.Errorf("failed to submit input %s: %w", input.ID, err)
+		}
+		fmt.Println(infoBoldStyle.Render(fmt.Sprintf("Submitted input [%s] of build #%s", input.ID, buildID)))
+		return nil
+	},
+}
+
+// selectInput picks the input with the given ID, or the only pending input
+// when id is empty
+func selectInput(inputs []jenkins.PendingInput, id string) (jenkins.PendingInput, error) {
+	ids := make([]string, len(inputs))
+	for i, input := range inputs {
+		if id != "" && strings.EqualFold(input.ID, id) {
+			return input, nil
+		}
+		ids[i] = input.ID
+	}
+
+	if id != "" {
+		return jenkins.PendingInput{}, NewValidationError("id", id, fmt.Sprintf("must be one of: %s", strings.Join(ids, ", ")))
+	}
+	if len(inputs) > 1 {
+		return jenkins.PendingInput{}, NewValidationError("id", "", fmt.Sprintf("build is waiting on several inputs, choose one of: %s", strings.Join(ids, ", ")))
+	}
+	return inputs[0], nil
+}
+
+// proceedText returns the label of the input's proceed button
+func proceedText(input jenkins.PendingInput) string {
+	if input.ProceedText != "" {
+		return input.ProceedText
+	}
+	return "Proceed"
+}
+
+// printPendingInput prints an input step's message and parameters
+func printPendingInput(input jenkins.PendingInput) {
+	fmt.Printf("%s %s\n", infoBoldStyle.Render(fmt.Sprintf("[%s]", input.ID)), input.Message)
+	for _, def := range input.Parameters() {
+		line := fmt.Sprintf("  %s (%s)", infoBoldStyle.Render(def.Name), strings.TrimSuffix(def.Type, "ParameterDefinition"))
+		if def.Default() != "" {
+			line += fmt.Sprintf(" default: %s", def.Default())
+		}
+		if len(def.Choices) > 0 {
+			line += fmt.Sprintf(" choices: %s", strings.Join(def.Choices, ", "))
+		}
+		fmt.Println(line)
+		if def.Description != "" {
+			fmt.Println(grayStyle.Render("    " + def.Description))
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"testing"
+)
+
+func TestSelectInput(t *testing.T) {
+	one := []jenkins.PendingInput{{ID: "Deploy"}}
+	two := []jenkins.PendingInput{{ID: "Deploy"}, {ID: "Rollback"}}
+
+	if input, err := selectInput(one, ""); err != nil || input.ID != "Deploy" {
+		t.Errorf("selectInput(one, \"\") = %v, %v", input.ID, err)
+	}
+	if input, err := selectInput(two, "rollback"); err != nil || input.ID != "Rollback" {
+		t.Errorf("selectInput(two, rollback) = %v, %v", input.ID, err)
+	}
+	if _, err := selectInput(two, ""); err == nil {
+		t.Error("expected error when several inputs are pending and no id is given")
+	}
+	if _, err := selectInput(one, "Other"); err == nil {
+		t.Error("expected error for an unknown input id")
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/jenkins"
+	"path"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	recurseJobs bool
+	jobsDepth   int
+)
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+
+	jobsCmd.Flags().BoolVarP(&recurseJobs, "recursive", "r", false, "Descend into folders, multibranch projects and organizations")
+	jobsCmd.Flags().IntVarP(&jobsDepth, "depth", "d", 3, "Maximum folder depth when --recursive is set")
+}
+
+// jobRow is a job listing entry together with its full slash-separated path
+type jobRow struct {
+	Path string
+	Job  jenkins.JobSummary
+}
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs [folder]",
+	Short: "Browse jobs, folders and multibranch branches",
+	Long: `List the jobs inside a folder, multibranch project or organization (or the
+top level of the server when no folder is given).
+
+The PATH column can be passed straight to --pipeline, e.g.:
+  jenkins jobs team/service
+  jenkins --pipeline team/service/PR-123 timing`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		folder := ""
+		if len(args) > 0 {
+			folder = args[0]
+		}
+
+		depth := 1
+		if recurseJobs {
+			depth = jobsDepth
+		}
+
+		rows, err := listJobs(folder, depth)
+		if err != nil {
+			return err
+		}
+
+		if len(rows) == 0 {
+			fmt.Println(grayStyle.Render(fmt.Sprintf("No jobs found in [%s]", folder)))
+			return nil
+		}
+
+		t := table.New().
+			Border(lipgloss.ThickBorder()).
+			BorderStyle(BorderStyle).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == 0 {
+					return HeaderStyle
+				}
+				style := EvenRowStyle
+				if row%2 != 0 {
+					style = OddRowStyle
+				}
+				if col == 0 {
+					return stdRe.NewStyle().Inline(true).Width(STAGE_COL_WIDTH).Inherit(style)
+				}
+				return stdRe.NewStyle().Width(13).Inherit(style)
+			}).
+			Headers("PATH", "TYPE", "LAST BUILD", "RESULT")
+
+		for _, r := range rows {
+			lastBuild, result := "", ""
+			if r.Job.LastBuild != nil {
+				lastBuild = strconv.Itoa(r.Job.LastBuild.Number)
+				result = r.Job.LastBuild.Result
+				if r.Job.LastBuild.Building {
+					result = "BUILDING"
+				}
+			}
+			t.Row(r.Path, r.Job.Kind(), lastBuild, result)
+		}
+
+		fmt.Println(t)
+		return nil
+	},
+}
+
+// listJobs lists the jobs in folder, descending into nested folders up to depth levels
+func listJobs(folder string, depth int) ([]jobRow, error) {
+	jobs, err := jenkinsClient.GetFolder(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []jobRow{}
+	for _, job := range jobs {
+		jobPath := path.Join(folder, job.Name)
+		vVerbose("Found job [%s] of class [%s]", jobPath, job.Class)
+		rows = append(rows, jobRow{Path: jobPath, Job: job})
+
+		if job.IsFolder() && depth > 1 {
+			children, err := listJobs(jobPath, depth-1)
+			if err != nil {
+				verbose("Failed to list folder [%s]: %v", jobPath, err)
+				continue
+			}
+			rows = append(rows, children...)
+		}
+	}
+
+	return rows, nil
+}
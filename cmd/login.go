@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"jenkins/internal/jenkins"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+}
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Validate and store a Jenkins API key",
+	Long: `Validate an API key against the Jenkins server and store it in the configured
+secret backend, so it no longer has to live in the environment or in plaintext
+in ~/.jenkins.yaml.
+
+The key is read from --key, or prompted for if not given. Backends are selected
+with credentials.backend in the config file:
+
+  credentials:
+    backend: file             # AES-GCM encrypted file, passphrase from JENKINS_PASSPHRASE or prompt
+    file: ~/.jenkins-credentials
+
+  credentials:
+    backend: command          # shell out to a password manager, account in $JENKINS_ACCOUNT
+    command:
+      get: pass show jenkins/$JENKINS_ACCOUNT
+      store: pass insert -m -f jenkins/$JENKINS_ACCOUNT
+      erase: pass rm -f jenkins/$JENKINS_ACCOUNT`,
+	Args: cobra.NoArgs,
+	// login supplies its own key, so skip the root key resolution
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vHost := viper.GetString("host")
+		vUser := viper.GetString("user")
+		if vHost == "" {
+			return fmt.Errorf("you must provide a host")
+		}
+		if vUser == "" {
+			return fmt.Errorf("you must provide a username")
+		}
+
+		apiKey := viper.GetString("key")
+		if apiKey == "" {
+			var err error
+			if apiKey, err = readSecret(fmt.Sprintf("API key for %s: ", vUser)); err != nil {
+				return err
+			}
+		}
+		if apiKey == "" {
+			return NewValidationError("key", "", "API key must not be empty")
+		}
+
+		client := jenkins.NewClient(jenkins.Config{
+			Host:    vHost,
+			User:    vUser,
+			APIKey:  apiKey,
+			Verbose: verbose,
+		})
+
+		who, err := client.WhoAmI()
+		if errors.Is(err, jenkins.ErrUnauthorized) {
+			return NewAuthError(fmt.Sprintf("Jenkins rejected the API key for %s", vUser))
+		} else if err != nil {
+			return fmt.Errorf("failed to validate credentials: %w", err)
+		}
+		verbose("Authenticated as [%s] with authorities [%v]", who.Name, who.Authorities)
+
+		backend, err := newSecretBackend()
+		if err != nil {
+			return err
+		}
+
+		account := credentialAccount(vUser, vHost)
+		if err := backend.Set(account, apiKey); err != nil {
+			return fmt.Errorf("failed to store API key: %w", err)
+		}
+
+		fmt.Println(infoBoxStyle.Render(fmt.Sprintf("Logged in to %s as %s", vHost, who.Name)))
+		fmt.Println(grayStyle.Render(fmt.Sprintf("API key stored in the %s backend as [%s]", viper.GetString("credentials.backend"), account)))
+
+		return nil
+	},
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"jenkins/internal/secrets"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(logoutCmd)
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove a stored Jenkins API key",
+	Long:  `Remove the API key stored by 'jenkins login' for the current host and user from the configured secret backend.`,
+	Args:  cobra.NoArgs,
+	// logout never talks to Jenkins, so skip the root key resolution
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vHost := viper.GetString("host")
+		vUser := viper.GetString("user")
+		if vHost == "" || vUser == "" {
+			return fmt.Errorf("you must provide a host and a username")
+		}
+
+		backend, err := newSecretBackend()
+		if err != nil {
+			return err
+		}
+
+		account := credentialAccount(vUser, vHost)
+		if err := backend.Delete(account); errors.Is(err, secrets.ErrNotFound) {
+			fmt.Println(grayStyle.Render(fmt.Sprintf("No stored API key for [%s]", account)))
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to remove API key: %w", err)
+		}
+
+		fmt.Println(infoBoxStyle.Render(fmt.Sprintf("Removed stored API key for %s", account)))
+		return nil
+	},
+}
@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"jenkins/internal/jenkins"
+	"jenkins/internal/notify"
+	"jenkins/internal/watcher"
+	"os"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -17,43 +24,78 @@ const (
 )
 
 var (
-	doNotSpawn bool
+	monitorDetach   bool
+	monitorPlain    bool
+	monitorExitCode bool
 )
 
 func init() {
 	rootCmd.AddCommand(monitorCmd)
+	monitorCmd.AddCommand(monitorListCmd)
+	monitorCmd.AddCommand(monitorStopCmd)
+	monitorCmd.AddCommand(monitorDaemonCmd)
 
-	monitorCmd.Flags().BoolVarP(&doNotSpawn, "bg", "", false, "")
-	monitorCmd.Flags().MarkHidden("bg")
+	monitorCmd.Flags().BoolVarP(&monitorDetach, "detach", "d", false, "Hand the builds to the background watcher and return")
+	monitorCmd.Flags().BoolVar(&monitorPlain, "plain", false, "Print progress as plain lines instead of updating in place")
+	monitorCmd.Flags().BoolVar(&monitorExitCode, "exit-code", false, "Exit with the code for the worst result across the builds")
+	monitorCmd.MarkFlagsMutuallyExclusive("detach", "exit-code")
+	addNotifyFlag(monitorCmd)
 }
 
 var monitorCmd = &cobra.Command{
 	Use:   "monitor [build_id] [...build_id]",
 	Short: "Monitor a build and print a message when it completes of a given build IDs",
-	Long:  `Query Jenkins for the status of a build given the build ID until it finishes`,
-	Args:  cobra.MinimumNArgs(1),
+	Long: `Query Jenkins for the status of a build given the build ID until it finishes.
+
+While builds run, their current, completed and remaining stages are shown along
+with the elapsed time and an ETA based on the stage times of recent successful
+builds. On a terminal the view updates in place; otherwise, or with --plain, a
+line is printed whenever a build moves to a new stage.
+
+With --notify, the named notifiers from the config file are alerted when a
+build finishes or starts waiting for input. The bell notifier only works
+without --detach.
+
+With --detach, the builds are handed to a background watcher that keeps
+running after the terminal is closed. Use 'monitor list' to see what it is
+watching and 'monitor stop' to stop it.
+
+With --exit-code, the exit code reflects the worst result across the builds
+(see 'jenkins --help'):
+  jenkins monitor --exit-code 1234 1235 && deploy`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if !doNotSpawn {
-			pipeline := viper.GetString("pipeline")
-			fmt.Printf("Monitoring build(s) %s on pipeline [%s]...\n", strings.Join(args, ", "), pipeline)
-			buildArgs := []string{"monitor", "--bg", "--pipeline", pipeline}
-			buildArgs = append(buildArgs, args...)
-			verbose("Spawning and passing args [%+v]", buildArgs)
-			cmd, err := SpawnBG(buildArgs...)
-			if err != nil {
+		if monitorDetach {
+			if err := checkNotifiers(); err != nil {
 				return err
 			}
+			return watchBuilds(viper.GetString("pipeline"), args...)
+		}
 
-			return cmd.Wait()
+		notifiers, err := newNotifiers(notifyNames)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Monitoring build(s) %s on pipeline [%s]...\n", strings.Join(args, ", "), viper.GetString("pipeline"))
+
+		var history stageHistory
+		if jobs, err := jenkinsClient.GetJobs(viper.GetString("pipeline")); err != nil {
+			verbose("Failed to read stage history, ETA will use Jenkins' estimate [%v]", err)
+		} else {
+			history = newStageHistory(jobs)
 		}
 
 		er := make(chan error)
 		bld := make(chan *jenkins.WorkflowRun)
+		inp := make(chan pendingInputNotice)
+		prog := make(chan buildProgress)
 		done := make(chan bool)
 		ticker := time.NewTicker(MonitorPollInterval)
 		defer ticker.Stop()
 		go func() {
 			finished := []string{}
+			notified := map[string]bool{}
 			for ; true; <-ticker.C {
 				for _, buildID := range args {
 					if slices.Contains(finished, buildID) {
@@ -69,6 +111,25 @@ var monitorCmd = &cobra.Command{
 					if !build.Building {
 						bld <- build
 						finished = append(finished, build.ID)
+						continue
+					}
+
+					if job, err := jenkinsClient.GetJobDetails(viper.GetString("pipeline"), buildID); err != nil {
+						verbose("Failed to get stages for build [%s] [%v]", buildID, err)
+					} else {
+						prog <- computeProgress(build, job, history, time.Now())
+					}
+
+					inputs, err := jenkinsClient.GetPendingInputs(viper.GetString("pipeline"), buildID)
+					if err != nil {
+						verbose("Failed to check pending input for build [%s] [%v]", buildID, err)
+						continue
+					}
+					for _, input := range inputs {
+						if key := buildID + "/" + input.ID; !notified[key] {
+							notified[key] = true
+							inp <- pendingInputNotice{build: build, input: input}
+						}
 					}
 				}
 				vVerbose("Looping [%d] == [%d]", len(finished), len(args))
@@ -81,25 +142,203 @@ var monitorCmd = &cobra.Command{
 
 		pipeline := infoBoldStyle.Render(viper.GetString("pipeline"))
 
+		live := !monitorPlain && term.IsTerminal(os.Stdout.Fd())
+		view := &liveView{out: os.Stdout}
+		if width, _, err := term.GetSize(os.Stdout.Fd()); err == nil {
+			view.width = width
+		}
+		printLine := fmt.Println
+		if live {
+			printLine = func(a ...any) (int, error) {
+				view.Println(fmt.Sprint(a...))
+				return 0, nil
+			}
+		}
+
+		cmd.SilenceUsage = true
+
+		results := []*jenkins.WorkflowRun{}
+		running := map[string]buildProgress{}
+		lastStages := map[string]string{}
 		for {
 			select {
+			case p := <-prog:
+				if live {
+					running[p.ID] = p
+					view.Render(renderProgress(args, running))
+					continue
+				}
+				if stages := strings.Join(p.Current, ","); stages != lastStages[p.ID] {
+					lastStages[p.ID] = stages
+					fmt.Println(noStyle.Render(fmt.Sprintf("%s: %s", infoBoldStyle.Render(p.ID), p.summary())))
+				}
 			case build := <-bld:
+				results = append(results, build)
+				delete(running, build.ID)
+				if live {
+					view.Render(renderProgress(args, running))
+				}
 				id := infoBoldStyle.Render(build.ID)
 				name := infoBoldStyle.Render(build.DisplayName)
-				rStyle := infoBoldStyle
-				if build.Result == "SUCCESS" {
-					rStyle = successStyle
-				} else if build.Result == "FAILURE" {
-					rStyle = failureStyle
+				result := resultStyle(build.Result).Render(build.Result)
+				printLine(noStyle.Render(fmt.Sprintf("%s: The monitor for [%s] on branch [%s] is [%s]", id, name, pipeline, result)))
+				if err := sendNotifications(notifiers, buildEvent(notify.EventFinished, viper.GetString("pipeline"), build)); err != nil {
+					printLine(orangeStyle.Render(fmt.Sprintf("Notification failed: %v", err)))
+				}
+			case notice := <-inp:
+				id := infoBoldStyle.Render(notice.build.ID)
+				name := infoBoldStyle.Render(notice.build.DisplayName)
+				printLine(noStyle.Render(fmt.Sprintf("%s: [%s] on branch [%s] is %s: %s", id, name, pipeline,
+					orangeStyle.Render("waiting for input"), notice.input.Message)))
+				printLine(noStyle.Render(fmt.Sprintf("%s: Respond with: jenkins input %s --proceed|--abort", id, notice.build.ID)))
+				event := buildEvent(notify.EventInput, viper.GetString("pipeline"), notice.build)
+				event.Message = notice.input.Message
+				if err := sendNotifications(notifiers, event); err != nil {
+					printLine(orangeStyle.Render(fmt.Sprintf("Notification failed: %v", err)))
 				}
-				result := rStyle.Render(build.Result)
-				fmt.Println(noStyle.Render(fmt.Sprintf("%s: The monitor for [%s] on branch [%s] is [%s]", id, name, pipeline, result)))
 			case err := <-er:
 				return err
 			case <-done:
 				fmt.Println(noStyle.Render(fmt.Sprintf("Done monitoring %d build(s) on pipeline [%s].", len(args), pipeline)))
+				if monitorExitCode {
+					return worstResult(results)
+				}
+				return nil
+			}
+		}
+	},
+}
+
+var monitorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the builds the background watcher is watching",
+	Args:  cobra.NoArgs,
+	// The watcher is local, so no Jenkins connection is needed
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := watcherClient()
+		if err != nil {
+			return err
+		}
+		res, err := client.List()
+		if errors.Is(err, watcher.ErrNotRunning) {
+			fmt.Println(grayStyle.Render("The watcher is not running"))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		paths, _ := watcherPaths()
+		fmt.Printf("Watcher running (pid %d), log: %s\n", res.PID, paths.Log())
+		if len(res.Builds) == 0 {
+			fmt.Println(grayStyle.Render("No builds being watched"))
+			return nil
+		}
+		printWatchedBuilds(res.Builds)
+		return nil
+	},
+}
+
+var monitorStopCmd = &cobra.Command{
+	Use:   "stop [build_id...]",
+	Short: "Stop watching builds, or stop the background watcher",
+	Long: `Stop watching the given builds on the pipeline. Without build IDs, the
+background watcher is shut down and forgets every build it was watching.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := watcherClient()
+		if err != nil {
+			return err
+		}
+
+		if len(args) == 0 {
+			_, err := client.Stop()
+			if errors.Is(err, watcher.ErrNotRunning) {
+				fmt.Println(grayStyle.Render("The watcher is not running"))
 				return nil
 			}
+			if err == nil {
+				fmt.Println(infoBoldStyle.Render("Stopped the watcher"))
+			}
+			return err
+		}
+
+		// The client isn't set up here, so read the host straight from the config
+		pipeline, host := viper.GetString("pipeline"), viper.GetString("host")
+		builds := make([]watcher.Build, len(args))
+		for i, id := range args {
+			builds[i] = watcher.Build{Host: host, Pipeline: pipeline, ID: id}
+		}
+		res, err := client.Remove(builds...)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Stopped watching build(s) %s on [%s]; %d still watched\n", strings.Join(args, ", "), pipeline, len(res.Builds))
+		return nil
+	},
+}
+
+var monitorDaemonCmd = &cobra.Command{
+	Use:    "daemon",
+	Short:  "Run the background watcher in the foreground",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// A watcher spawned by ensureWatcher gets its API key on stdin
+		if os.Getenv(childEnv) != "" {
+			if err := readWatcherKey(os.Stdin); err != nil {
+				return err
+			}
 		}
+		return rootCmd.PersistentPreRunE(cmd, args)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWatcher()
 	},
 }
+
+// printWatchedBuilds prints the watcher's builds
+func printWatchedBuilds(builds []watcher.Build) {
+	t := table.New().
+		Border(lipgloss.ThickBorder()).
+		BorderStyle(BorderStyle).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return HeaderStyle
+			}
+			style := EvenRowStyle
+			if row%2 != 0 {
+				style = OddRowStyle
+			}
+			return stdRe.NewStyle().Width(0).Inherit(style)
+		}).
+		Headers("BUILD", "PIPELINE", "STATUS", "NOTIFY", "SINCE")
+
+	for _, b := range builds {
+		status := b.Status
+		if status == "" {
+			status = "pending"
+		}
+		t.Row(b.ID, b.Pipeline, status, strings.Join(b.Notify, ", "), b.Added.Format("2006-01-02 15:04"))
+	}
+
+	fmt.Println(t)
+}
+
+// renderProgress renders the running builds in the order they were given
+func renderProgress(order []string, running map[string]buildProgress) string {
+	blocks := []string{}
+	for _, id := range order {
+		if p, ok := running[id]; ok {
+			blocks = append(blocks, p.render())
+		}
+	}
+	return strings.Join(blocks, "\n")
+}
+
+// pendingInputNotice reports an input step a monitored build is waiting on
+type pendingInputNotice struct {
+	build *jenkins.WorkflowRun
+	input jenkins.PendingInput
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"jenkins/internal/jenkins"
+	"jenkins/internal/notify"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// notifyNames holds the notifiers selected with --notify
+var notifyNames []string
+
+// newNotifier builds the notifier configured under notifiers.<name>. The
+// "bell" notifier is available without any configuration.
+func newNotifier(name string) (notify.Notifier, error) {
+	key := "notifiers." + name
+	kind := viper.GetString(key + ".type")
+	if kind == "" && name == "bell" {
+		kind = "bell"
+	}
+
+	require := func(field string) (string, error) {
+		value := viper.GetString(key + "." + field)
+		if value == "" {
+			return "", NewConfigError(key+"."+field, fmt.Sprintf("required for %s notifiers", kind))
+		}
+		return value, nil
+	}
+
+	switch kind {
+	case "command":
+		command, err := require("command")
+		if err != nil {
+			return nil, err
+		}
+		return &notify.CommandNotifier{Command: command}, nil
+	case "webhook":
+		url, err := require("url")
+		if err != nil {
+			return nil, err
+		}
+		return &notify.WebhookNotifier{URL: url, Headers: viper.GetStringMapString(key + ".headers")}, nil
+	case "slack", "teams":
+		url, err := require("url")
+		if err != nil {
+			return nil, err
+		}
+		return &notify.ChatNotifier{URL: url}, nil
+	case "pipe":
+		path, err := require("path")
+		if err != nil {
+			return nil, err
+		}
+		return &notify.PipeNotifier{Path: path}, nil
+	case "bell":
+		return &notify.BellNotifier{Out: os.Stdout}, nil
+	case "":
+		return nil, NewConfigError(key, "no such notifier configured")
+	default:
+		return nil, NewConfigError(key+".type", fmt.Sprintf("unknown type '%s' (must be command, webhook, slack, teams, pipe or bell)", kind))
+	}
+}
+
+// newNotifiers builds every notifier in names
+func newNotifiers(names []string) ([]notify.Notifier, error) {
+	notifiers := make([]notify.Notifier, 0, len(names))
+	for _, name := range names {
+		n, err := newNotifier(name)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+// checkNotifiers validates the notifiers selected with --notify for builds
+// handed to the background watcher, so config errors surface before a build is
+// started rather than in the watcher. The watcher has no terminal to ring the
+// bell on, so bell notifiers are rejected.
+func checkNotifiers() error {
+	notifiers, err := newNotifiers(notifyNames)
+	if err != nil {
+		return err
+	}
+	for i, n := range notifiers {
+		if _, ok := n.(*notify.BellNotifier); ok {
+			return NewValidationError("notify", notifyNames[i], "the bell only rings for builds followed with monitor (without --detach)")
+		}
+	}
+	return nil
+}
+
+// addNotifyFlag registers --notify on a command that monitors the builds it starts
+func addNotifyFlag(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&notifyNames, "notify", []string{}, "Notifier from the config file to alert when builds finish or wait for input (repeatable)")
+}
+
+// buildEvent describes a build for notifiers
+func buildEvent(kind, pipeline string, build *jenkins.WorkflowRun) notify.Event {
+	return notify.Event{
+		Kind:        kind,
+		Pipeline:    pipeline,
+		BuildID:     build.ID,
+		DisplayName: build.DisplayName,
+		Result:      build.Result,
+		URL:         build.URL,
+		Duration:    time.Duration(build.Duration) * time.Millisecond,
+	}
+}
+
+// sendNotifications delivers e to every notifier, returning the failures
+// joined together
+func sendNotifications(notifiers []notify.Notifier, e notify.Event) error {
+	var errs []error
+	for _, n := range notifiers {
+		if err := n.Notify(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"jenkins/internal/notify"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestNewNotifier(t *testing.T) {
+	viper.Set("notifiers", map[string]any{
+		"desktop": map[string]any{"type": "command", "command": "notify-send done"},
+		"team":    map[string]any{"type": "slack", "url": "https://hooks.example.com/x"},
+		"ci":      map[string]any{"type": "webhook", "url": "https://ci.example.com", "headers": map[string]any{"X-Token": "t"}},
+		"broken":  map[string]any{"type": "webhook"},
+		"odd":     map[string]any{"type": "carrier-pigeon"},
+	})
+	defer viper.Set("notifiers", nil)
+
+	tests := []struct {
+		name    string
+		check   func(notify.Notifier) bool
+		wantErr bool
+	}{
+		{"desktop", func(n notify.Notifier) bool { return n.(*notify.CommandNotifier).Command == "notify-send done" }, false},
+		{"team", func(n notify.Notifier) bool { _, ok := n.(*notify.ChatNotifier); return ok }, false},
+		{"ci", func(n notify.Notifier) bool { return n.(*notify.WebhookNotifier).Headers["x-token"] == "t" }, false},
+		{"bell", func(n notify.Notifier) bool { _, ok := n.(*notify.BellNotifier); return ok }, false},
+		{"broken", nil, true},
+		{"odd", nil, true},
+		{"missing", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := newNotifier(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newNotifier(%s) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if tt.check != nil && !tt.check(n) {
+				t.Errorf("newNotifier(%s) = %#v", tt.name, n)
+			}
+		})
+	}
+}
+
+func TestCheckNotifiers(t *testing.T) {
+	viper.Set("notifiers", map[string]any{
+		"desktop": map[string]any{"type": "command", "command": "notify-send done"},
+		"ding":    map[string]any{"type": "bell"},
+	})
+	defer viper.Set("notifiers", nil)
+	defer func(names []string) { notifyNames = names }(notifyNames)
+
+	notifyNames = []string{"desktop"}
+	if err := checkNotifiers(); err != nil {
+		t.Errorf("checkNotifiers() failed: %v", err)
+	}
+
+	for _, name := range []string{"bell", "ding"} {
+		notifyNames = []string{"desktop", name}
+		var vErr *ValidationError
+		if err := checkNotifiers(); !errors.As(err, &vErr) {
+			t.Errorf("checkNotifiers() with %s error = %v, want ValidationError", name, err)
+		}
+	}
+}
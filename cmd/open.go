@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"jenkins/internal/jenkins"
 	"runtime"
 
 	"github.com/spf13/cobra"
@@ -18,7 +19,7 @@ var openCmd = &cobra.Command{
 	Long:  `Open a build log in your browser and also print the URL.`,
 	Args:  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		url := fmt.Sprintf("%s/job/%s/%s/flowGraphTable", viper.Get("host"), viper.Get("pipeline"), args[0])
+		url := fmt.Sprintf("%s/%s/%s/flowGraphTable", viper.Get("host"), jenkins.JobPath(viper.GetString("pipeline")), args[0])
 
 		style := stdRe.NewStyle().
 			Bold(true)
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"jenkins/internal/jenkins"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseParamFlags parses repeated NAME=VALUE flags into a parameter map
+func parseParamFlags(pairs []string) (map[string]string, error) {
+	parsed, err := parseParamPairs(pairs)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]string{}
+	for _, p := range parsed {
+		params[p.Key] = p.Value
+	}
+	return params, nil
+}
+
+// parseParamPairs parses repeated NAME=VALUE flags, keeping their order and
+// any repeated names
+func parseParamPairs(pairs []string) ([]pair[string], error) {
+	parsed := []pair[string]{}
+	for _, p := range pairs {
+		name, value, ok := strings.Cut(p, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, NewValidationError("param", p, "must be in the form NAME=VALUE")
+		}
+		parsed = append(parsed, pair[string]{name, value})
+	}
+	return parsed, nil
+}
+
+// validateParams checks params against a job's parameter definitions: every
+// name must be declared, choice values must be one of the allowed choices and
+// boolean values must parse as booleans
+func validateParams(defs []jenkins.ParameterDefinition, params map[string]string) error {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := params[name]
+		idx := slices.IndexFunc(defs, func(d jenkins.ParameterDefinition) bool { return d.Name == name })
+		if idx < 0 {
+			known := make([]string, len(defs))
+			for i, d := range defs {
+				known[i] = d.Name
+			}
+			return NewValidationError(name, value, fmt.Sprintf("unknown parameter (job accepts: %s)", strings.Join(known, ", ")))
+		}
+
+		def := defs[idx]
+		switch def.Type {
+		case "ChoiceParameterDefinition":
+			if !slices.Contains(def.Choices, value) {
+				return NewValidationError(name, value, fmt.Sprintf("must be one of: %s", strings.Join(def.Choices, ", ")))
+			}
+		case "BooleanParameterDefinition":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return NewValidationError(name, value, "must be true or false")
+			}
+			params[name] = strconv.FormatBool(b)
+		}
+	}
+
+	return nil
+}
+
+// missingParams returns the definitions that have no value in params and no default
+func missingParams(defs []jenkins.ParameterDefinition, params map[string]string) []jenkins.ParameterDefinition {
+	missing := []jenkins.ParameterDefinition{}
+	for _, def := range defs {
+		if _, ok := params[def.Name]; ok {
+			continue
+		}
+		if def.Default() != "" || def.Type == "BooleanParameterDefinition" {
+			continue
+		}
+		missing = append(missing, def)
+	}
+	return missing
+}
+
+// promptParams asks for a value for each definition on in, writing prompts to out.
+// An empty answer leaves the parameter unset so the job default applies.
+func promptParams(defs []jenkins.ParameterDefinition, params map[string]string, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	for _, def := range defs {
+		if def.Description != "" {
+			fmt.Fprintln(out, grayStyle.Render(def.Description))
+		}
+		if len(def.Choices) > 0 {
+			for i, choice := range def.Choices {
+				fmt.Fprintf(out, "  %d) %s\n", i+1, choice)
+			}
+		}
+		fmt.Fprint(out, infoBoldStyle.Render(def.Name)+": ")
+
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("failed to read value for %s: %w", def.Name, err)
+		}
+		value := strings.TrimSpace(line)
+		if value == "" {
+			continue
+		}
+
+		// Allow picking a choice by its number, unless the answer is itself a choice
+		if n, err := strconv.Atoi(value); err == nil && !slices.Contains(def.Choices, value) && n >= 1 && n <= len(def.Choices) {
+			value = def.Choices[n-1]
+		}
+		params[def.Name] = value
+	}
+	return nil
+}
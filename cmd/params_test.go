@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"jenkins/internal/jenkins"
+	"strings"
+	"testing"
+)
+
+var testParamDefs = []jenkins.ParameterDefinition{
+	{Name: "PRODUCT", Type: "ChoiceParameterDefinition", Choices: []string{"ingredi", "bpam"},
+		DefaultParameterValue: &jenkins.WorkflowParameter{Name: "PRODUCT", Value: "ingredi"}},
+	{Name: "TRYMAX_BRANCH", Type: "StringParameterDefinition"},
+	{Name: "CLEAN", Type: "BooleanParameterDefinition",
+		DefaultParameterValue: &jenkins.WorkflowParameter{Name: "CLEAN", Value: false}},
+}
+
+func TestParseParamFlags(t *testing.T) {
+	params, err := parseParamFlags([]string{"A=1", "B=x=y", "C="})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"A": "1", "B": "x=y", "C": ""}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Errorf("params[%s] = %q, want %q", k, params[k], v)
+		}
+	}
+
+	for _, bad := range []string{"novalue", "=value"} {
+		var vErr *ValidationError
+		if _, err := parseParamFlags([]string{bad}); !errors.As(err, &vErr) {
+			t.Errorf("parseParamFlags(%q) error = %v, want ValidationError", bad, err)
+		}
+	}
+
+	// Pairs keep their order and repeated names
+	pairs, err := parseParamPairs([]string{"B=2", "A=1", "B=3"})
+	if err != nil || len(pairs) != 3 || pairs[0].Key != "B" || pairs[2].Value != "3" {
+		t.Errorf("parseParamPairs() = %+v, %v", pairs, err)
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		wantErr bool
+	}{
+		{"valid choice", map[string]string{"PRODUCT": "bpam"}, false},
+		{"invalid choice", map[string]string{"PRODUCT": "other"}, true},
+		{"unknown parameter", map[string]string{"NOPE": "1"}, true},
+		{"invalid boolean", map[string]string{"CLEAN": "yes"}, true},
+		{"boolean", map[string]string{"CLEAN": "1"}, false},
+		{"free string", map[string]string{"TRYMAX_BRANCH": "origin/anything"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateParams(testParamDefs, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	params := map[string]string{"CLEAN": "1"}
+	validateParams(testParamDefs, params)
+	if params["CLEAN"] != "true" {
+		t.Errorf("boolean should be normalized, got %q", params["CLEAN"])
+	}
+}
+
+func TestMissingParams(t *testing.T) {
+	missing := missingParams(testParamDefs, map[string]string{})
+	if len(missing) != 1 || missing[0].Name != "TRYMAX_BRANCH" {
+		t.Errorf("missingParams() = %+v, want only TRYMAX_BRANCH", missing)
+	}
+
+	missing = missingParams(testParamDefs, map[string]string{"TRYMAX_BRANCH": "origin/master"})
+	if len(missing) != 0 {
+		t.Errorf("missingParams() = %+v, want none", missing)
+	}
+}
+
+func TestPromptParams(t *testing.T) {
+	params := map[string]string{}
+	in := strings.NewReader("2\n\n")
+	var out bytes.Buffer
+
+	defs := []jenkins.ParameterDefinition{testParamDefs[0], testParamDefs[1]}
+	if err := promptParams(defs, params, in, &out); err != nil {
+		t.Fatalf("promptParams() failed: %v", err)
+	}
+
+	if params["PRODUCT"] != "bpam" {
+		t.Errorf("PRODUCT = %q, want bpam (chosen by number)", params["PRODUCT"])
+	}
+	if _, ok := params["TRYMAX_BRANCH"]; ok {
+		t.Error("empty answer should leave the parameter unset")
+	}
+	if !strings.Contains(out.String(), "ingredi") {
+		t.Errorf("prompt should list choices, got %q", out.String())
+	}
+
+	// A numeric answer that is a choice is taken as that choice, not an index
+	numeric := []jenkins.ParameterDefinition{{Name: "SHARDS", Type: "ChoiceParameterDefinition", Choices: []string{"5", "1"}}}
+	for answer, want := range map[string]string{"1": "1", "5": "5", "2": "1"} {
+		params := map[string]string{}
+		if err := promptParams(numeric, params, strings.NewReader(answer+"\n"), &out); err != nil || params["SHARDS"] != want {
+			t.Errorf("answer %s: SHARDS = %q, %v; want %s", answer, params["SHARDS"], err, want)
+		}
+	}
+}
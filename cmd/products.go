@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// product is a product line built by the pipeline, defined under products.<key>
+// in the config file
+type product struct {
+	Key string
+	// SearchName is the value of ProductParameter that identifies the product's builds
+	SearchName  string
+	DisplayName string
+	Aliases     []string
+
+	ProductParameter string
+	BranchParameter  string
+	BranchPrefix     string
+	DefaultBranch    string
+	// Parameters are extra NAME=VALUE pairs applied when building the product
+	Parameters []string
+}
+
+func init() {
+	setProductDefaults("rs", "ingredi", "RS")
+	setProductDefaults("pra", "bpam", "PRA")
+}
+
+// setProductDefaults registers a built-in product definition
+func setProductDefaults(key, searchName, displayName string) {
+	prefix := "products." + key + "."
+	viper.SetDefault(prefix+"search_name", searchName)
+	viper.SetDefault(prefix+"display_name", displayName)
+	viper.SetDefault(prefix+"aliases", []string{searchName})
+}
+
+// productKeys returns the keys of all configured products, sorted
+func productKeys() []string {
+	return configKeys("products")
+}
+
+// configKeys returns the names of the entries under a config section such as
+// products.<key> or diagnose.patterns.<key>, sorted
+func configKeys(section string) []string {
+	keys := []string{}
+	for _, k := range viper.AllKeys() {
+		rest, ok := strings.CutPrefix(k, section+".")
+		if !ok {
+			continue
+		}
+		if key, _, ok := strings.Cut(rest, "."); ok && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getProduct reads the product definition for key. Parameter and branch
+// fields not set in the config file fall back to the pipeline's PRODUCT and
+// TRYMAX_BRANCH parameters on origin/master; setting one to "" disables it.
+func getProduct(key string) product {
+	prefix := "products." + key + "."
+	p := product{
+		Key:              key,
+		SearchName:       viper.GetString(prefix + "search_name"),
+		DisplayName:      viper.GetString(prefix + "display_name"),
+		Aliases:          viper.GetStringSlice(prefix + "aliases"),
+		ProductParameter: productSetting(prefix+"product_parameter", "PRODUCT"),
+		BranchParameter:  productSetting(prefix+"branch_parameter", "TRYMAX_BRANCH"),
+		BranchPrefix:     productSetting(prefix+"branch_prefix", "origin/"),
+		DefaultBranch:    productSetting(prefix+"default_branch", "master"),
+		Parameters:       viper.GetStringSlice(prefix + "parameters"),
+	}
+	if p.SearchName == "" {
+		p.SearchName = key
+	}
+	if p.DisplayName == "" {
+		p.DisplayName = strings.ToUpper(key)
+	}
+	return p
+}
+
+// productSetting returns the config value of key, or fallback when it is not set
+func productSetting(key, fallback string) string {
+	if !viper.IsSet(key) {
+		return fallback
+	}
+	return viper.GetString(key)
+}
+
+// lookupProduct finds a product by key or alias (case insensitive)
+func lookupProduct(name string) (*product, bool) {
+	name = strings.ToLower(name)
+	for _, key := range productKeys() {
+		p := getProduct(key)
+		if key == name || slices.ContainsFunc(p.Aliases, func(a string) bool { return strings.ToLower(a) == name }) {
+			return &p, true
+		}
+	}
+	return nil, false
+}
+
+// resolveProduct is lookupProduct returning a ValidationError for unknown names
+func resolveProduct(name string) (*product, error) {
+	if p, ok := lookupProduct(name); ok {
+		return p, nil
+	}
+	return nil, NewValidationError("product", name, fmt.Sprintf("unknown product (must be one of: %s)", strings.Join(productKeys(), ", ")))
+}
+
+// Branch returns the fully qualified branch name, using the product's
+// default branch when branch is empty
+func (p product) Branch(branch string) string {
+	if branch == "" {
+		branch = p.DefaultBranch
+	}
+	if branch != "" && p.BranchPrefix != "" && !strings.HasPrefix(branch, p.BranchPrefix) {
+		branch = p.BranchPrefix + branch
+	}
+	return branch
+}
+
+// Filter returns the parameter values that identify the product's builds on branch
+func (p product) Filter(branch string) map[string]string {
+	filter := map[string]string{}
+	if p.ProductParameter != "" {
+		filter[p.ProductParameter] = p.SearchName
+	}
+	if b := p.Branch(branch); p.BranchParameter != "" && b != "" {
+		filter[p.BranchParameter] = b
+	}
+	return filter
+}
+
+// BuildParams returns the parameters used to build the product on branch
+func (p product) BuildParams(branch string) (map[string]string, error) {
+	params, err := parseParamFlags(p.Parameters)
+	if err != nil {
+		return nil, NewConfigError("products."+p.Key+".parameters", err.Error())
+	}
+	for k, v := range p.Filter(branch) {
+		params[k] = v
+	}
+	return params, nil
+}
+
+// resolveBuildID returns arg unchanged if it is a build number, or the latest
+// build on the default branch if it names a product
+func resolveBuildID(arg string) (string, error) {
+	p, ok := lookupProduct(arg)
+	if !ok {
+		return arg, nil
+	}
+
+	latestBuild, err := jenkinsClient.GetLatestBuildMatching(viper.GetString("pipeline"), p.Filter(""))
+	if err != nil {
+		return "", err
+	}
+	if latestBuild == nil {
+		return "", fmt.Errorf("no builds found for %s on branch [%s]", p.DisplayName, p.Branch(""))
+	}
+	verbose("Resolved [%s] to latest build [%s]", arg, latestBuild.ID)
+	return latestBuild.ID, nil
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestDefaultProducts(t *testing.T) {
+	keys := productKeys()
+	if !slices.Contains(keys, "rs") || !slices.Contains(keys, "pra") {
+		t.Fatalf("productKeys() = %v, want rs and pra", keys)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		search string
+	}{
+		{"rs", "rs", "ingredi"},
+		{"RS", "rs", "ingredi"},
+		{"ingredi", "rs", "ingredi"},
+		{"pra", "pra", "bpam"},
+		{"bpam", "pra", "bpam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := lookupProduct(tt.name)
+			if !ok {
+				t.Fatalf("lookupProduct(%s) not found", tt.name)
+			}
+			if p.Key != tt.key || p.SearchName != tt.search {
+				t.Errorf("lookupProduct(%s) = %s/%s, want %s/%s", tt.name, p.Key, p.SearchName, tt.key, tt.search)
+			}
+		})
+	}
+
+	if _, ok := lookupProduct("1234"); ok {
+		t.Error("build numbers should not resolve to a product")
+	}
+}
+
+func TestProductFromConfig(t *testing.T) {
+	viper.Set("products.agent.search_name", "agent-core")
+	viper.Set("products.agent.aliases", []string{"ac"})
+	viper.Set("products.agent.product_parameter", "COMPONENT")
+	viper.Set("products.agent.branch_parameter", "GIT_REF")
+	viper.Set("products.agent.branch_prefix", "")
+	viper.Set("products.agent.default_branch", "main")
+	viper.Set("products.agent.parameters", []string{"CLEAN=true"})
+	defer func() {
+		for _, k := range []string{"search_name", "aliases", "product_parameter", "branch_parameter", "branch_prefix", "default_branch", "parameters"} {
+			viper.Set("products.agent."+k, nil)
+		}
+	}()
+
+	p, err := resolveProduct("AC")
+	if err != nil {
+		t.Fatalf("resolveProduct() failed: %v", err)
+	}
+	if p.DisplayName != "AGENT" {
+		t.Errorf("DisplayName = %s, want AGENT", p.DisplayName)
+	}
+
+	params, err := p.BuildParams("")
+	if err != nil {
+		t.Fatalf("BuildParams() failed: %v", err)
+	}
+	expected := map[string]string{"COMPONENT": "agent-core", "GIT_REF": "main", "CLEAN": "true"}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Errorf("params[%s] = %q, want %q", k, params[k], v)
+		}
+	}
+}
+
+func TestProductConfigDefaults(t *testing.T) {
+	viper.Set("products.svc.search_name", "service")
+	defer viper.Set("products.svc.search_name", nil)
+
+	filter := getProduct("svc").Filter("")
+	if len(filter) != 2 || filter["PRODUCT"] != "service" || filter["TRYMAX_BRANCH"] != "origin/master" {
+		t.Errorf("Filter() = %v, want the default product and branch parameters", filter)
+	}
+
+	// An explicitly empty setting disables the default
+	viper.Set("products.svc.branch_parameter", "")
+	defer viper.Set("products.svc.branch_parameter", nil)
+	if filter := getProduct("svc").Filter(""); len(filter) != 1 {
+		t.Errorf("Filter() = %v, want only PRODUCT", filter)
+	}
+}
+
+func TestProductBranch(t *testing.T) {
+	p := getProduct("rs")
+
+	tests := []struct {
+		branch   string
+		expected string
+	}{
+		{"", "origin/master"},
+		{"feature/x", "origin/feature/x"},
+		{"origin/feature/x", "origin/feature/x"},
+	}
+
+	for _, tt := range tests {
+		if got := p.Branch(tt.branch); got != tt.expected {
+			t.Errorf("Branch(%q) = %q, want %q", tt.branch, got, tt.expected)
+		}
+	}
+
+	filter := p.Filter("release/1.0")
+	if filter["PRODUCT"] != "ingredi" || filter["TRYMAX_BRANCH"] != "origin/release/1.0" {
+		t.Errorf("Filter() = %v", filter)
+	}
+}
+
+func TestResolveProductUnknown(t *testing.T) {
+	var vErr *ValidationError
+	if _, err := resolveProduct("nope"); !errors.As(err, &vErr) {
+		t.Errorf("resolveProduct(nope) error = %v, want ValidationError", err)
+	}
+}
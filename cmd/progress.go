@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"jenkins/internal/jenkins"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// stageHistory holds per-stage durations from recent successful builds, used
+// to estimate how long a running build has left
+type stageHistory struct {
+	// Order lists stage names in the order the latest successful build ran them
+	Order []string
+	// Avg is the average duration of each stage in milliseconds
+	Avg map[string]float64
+}
+
+// newStageHistory averages the stage durations of the successful jobs
+func newStageHistory(jobs []jenkins.Job) stageHistory {
+	stageMap, order, _ := successfulStages(jobs, nil, false)
+	h := stageHistory{Order: order, Avg: map[string]float64{}}
+	for name, times := range stageTimes(stageMap) {
+		h.Avg[name] = times.Avg
+	}
+	return h
+}
+
+// buildProgress is a snapshot of how far along a running build is
+type buildProgress struct {
+	ID          string
+	DisplayName string
+	Current     []string
+	Completed   []string
+	Remaining   []string
+	Elapsed     time.Duration
+	Estimated   time.Duration
+	// ETA is the estimated time left, valid only if HasETA is set
+	ETA    time.Duration
+	HasETA bool
+}
+
+// Total returns the number of stages the build is expected to run
+func (p buildProgress) Total() int {
+	return len(p.Completed) + len(p.Current) + len(p.Remaining)
+}
+
+// computeProgress works out the current, completed and remaining stages of a
+// running build, and how long it has left based on history. Without history
+// the ETA falls back to Jenkins' estimated duration.
+func computeProgress(run *jenkins.WorkflowRun, job *jenkins.Job, history stageHistory, now time.Time) buildProgress {
+	p := buildProgress{
+		ID:          run.ID,
+		DisplayName: run.DisplayName,
+		Elapsed:     now.Sub(run.Timestamp.Time),
+		Estimated:   time.Duration(run.EstimatedDuration) * time.Millisecond,
+	}
+
+	var left time.Duration
+	seen := map[string]bool{}
+	for _, stage := range job.Stages {
+		seen[stage.Name] = true
+		switch stage.Status {
+		case "IN_PROGRESS", "PAUSED_PENDING_INPUT":
+			p.Current = append(p.Current, stage.Name)
+			if avg, ok := history.Avg[stage.Name]; ok {
+				// Parallel current stages overlap, so only the longest counts
+				left = max(left, time.Duration(avg)*time.Millisecond-time.Duration(stage.Duration)*time.Millisecond)
+			}
+		default:
+			p.Completed = append(p.Completed, stage.Name)
+		}
+	}
+
+	for _, name := range history.Order {
+		if seen[name] {
+			continue
+		}
+		p.Remaining = append(p.Remaining, name)
+		left += time.Duration(history.Avg[name]) * time.Millisecond
+	}
+
+	switch {
+	case len(history.Avg) > 0:
+		p.ETA, p.HasETA = left, true
+	case p.Estimated > 0:
+		p.ETA, p.HasETA = max(p.Estimated-p.Elapsed, 0), true
+	}
+	return p
+}
+
+// summary renders the progress as a single plain line
+func (p buildProgress) summary() string {
+	current := "starting"
+	if len(p.Current) > 0 {
+		current = strings.Join(p.Current, ", ")
+	}
+	line := fmt.Sprintf("[%s] %d/%d stages, running [%s], elapsed %s", p.DisplayName, len(p.Completed), p.Total(), current, roundDuration(p.Elapsed))
+	if p.Estimated > 0 {
+		line += fmt.Sprintf(" of ~%s", roundDuration(p.Estimated))
+	}
+	if p.HasETA {
+		line += fmt.Sprintf(", ETA %s", roundDuration(p.ETA))
+	}
+	return line
+}
+
+// render renders the progress for the live view, with the upcoming stages on
+// a second line
+func (p buildProgress) render() string {
+	line := fmt.Sprintf("%s: %s", infoBoldStyle.Render(p.ID), p.summary())
+	if len(p.Remaining) > 0 {
+		line += "\n" + grayStyle.Render(fmt.Sprintf("    next: %s", strings.Join(p.Remaining, ", ")))
+	}
+	return line
+}
+
+// roundDuration rounds to whole seconds for display
+func roundDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+// liveView redraws a block of lines in place on a terminal, while letting
+// permanent messages be printed above it
+type liveView struct {
+	out io.Writer
+	// width truncates lines so they don't wrap, which would break redrawing
+	width int
+	block string
+	lines int
+}
+
+// Render replaces the previously drawn block with block
+func (v *liveView) Render(block string) {
+	v.clear()
+	v.block = block
+	v.draw()
+}
+
+// Println prints a permanent message above the block
+func (v *liveView) Println(msg string) {
+	v.clear()
+	fmt.Fprintln(v.out, msg)
+	v.draw()
+}
+
+func (v *liveView) clear() {
+	for range v.lines {
+		// Move up a line and erase it
+		fmt.Fprint(v.out, "\x1b[1A\x1b[2K")
+	}
+	v.lines = 0
+}
+
+func (v *liveView) draw() {
+	if v.block == "" {
+		return
+	}
+	lines := strings.Split(v.block, "\n")
+	for _, line := range lines {
+		if v.width > 0 {
+			line = ansi.Truncate(line, v.width, "…")
+		}
+		fmt.Fprintln(v.out, line)
+	}
+	v.lines = len(lines)
+}
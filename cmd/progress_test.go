@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"jenkins/internal/jenkins"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStage returns a stage that ran on node for duration
+func testStage(name, status string, duration time.Duration, node string) jenkins.Stage {
+	return jenkins.Stage{Base: jenkins.Base{Name: name, Status: status, Duration: int(duration.Milliseconds())}, ExecNode: node}
+}
+
+func TestNewStageHistory(t *testing.T) {
+	jobs := []jenkins.Job{
+		{Base: jenkins.Base{Status: "SUCCESS"}, Stages: []jenkins.Stage{testStage("Build", "SUCCESS", time.Second, ""), testStage("Test", "SUCCESS", 3*time.Second, "")}},
+		{Base: jenkins.Base{Status: "FAILED"}, Stages: []jenkins.Stage{testStage("Build", "FAILED", 99*time.Second, "")}},
+		{Base: jenkins.Base{Status: "SUCCESS"}, Stages: []jenkins.Stage{testStage("Build", "SUCCESS", 3*time.Second, ""), testStage("Lint", "SUCCESS", 500*time.Millisecond, ""), testStage("Test", "SUCCESS", 5*time.Second, "")}},
+	}
+
+	h := newStageHistory(jobs)
+	if strings.Join(h.Order, ",") != "Build,Test,Lint" {
+		t.Errorf("Order = %v", h.Order)
+	}
+	if h.Avg["Build"] != 2000 || h.Avg["Test"] != 4000 || h.Avg["Lint"] != 500 {
+		t.Errorf("Avg = %v", h.Avg)
+	}
+}
+
+func TestComputeProgress(t *testing.T) {
+	now := time.Unix(10000, 0)
+	run := &jenkins.WorkflowRun{
+		ID:                "42",
+		DisplayName:       "#42",
+		Timestamp:         jenkins.Timestamp{Time: now.Add(-2 * time.Minute)},
+		EstimatedDuration: 10 * 60 * 1000,
+	}
+	job := &jenkins.Job{Stages: []jenkins.Stage{
+		testStage("Build", "SUCCESS", time.Minute, ""),
+		testStage("Test", "IN_PROGRESS", 30*time.Second, ""),
+	}}
+	history := stageHistory{
+		Order: []string{"Build", "Test", "Deploy"},
+		Avg:   map[string]float64{"Build": 60000, "Test": 90000, "Deploy": 120000},
+	}
+
+	p := computeProgress(run, job, history, now)
+	if p.Elapsed != 2*time.Minute || p.Estimated != 10*time.Minute {
+		t.Errorf("Elapsed = %v, Estimated = %v", p.Elapsed, p.Estimated)
+	}
+	if len(p.Completed) != 1 || len(p.Current) != 1 || strings.Join(p.Remaining, ",") != "Deploy" || p.Total() != 3 {
+		t.Errorf("stages = %v / %v / %v", p.Completed, p.Current, p.Remaining)
+	}
+	// 60s left of Test plus 120s of Deploy
+	if !p.HasETA || p.ETA != 3*time.Minute {
+		t.Errorf("ETA = %v (%v), want 3m", p.ETA, p.HasETA)
+	}
+
+	// Without history, fall back to Jenkins' estimate
+	p = computeProgress(run, job, stageHistory{}, now)
+	if !p.HasETA || p.ETA != 8*time.Minute {
+		t.Errorf("fallback ETA = %v (%v), want 8m", p.ETA, p.HasETA)
+	}
+
+	run.EstimatedDuration = -1
+	if p = computeProgress(run, job, stageHistory{}, now); p.HasETA {
+		t.Errorf("expected no ETA without history or estimate, got %v", p.ETA)
+	}
+}
+
+func TestLiveView(t *testing.T) {
+	var out bytes.Buffer
+	v := &liveView{out: &out, width: 10}
+
+	v.Render("one\ntwo")
+	if out.String() != "one\ntwo\n" {
+		t.Errorf("first render = %q", out.String())
+	}
+
+	out.Reset()
+	v.Println("done")
+	if out.String() != "\x1b[1A\x1b[2K\x1b[1A\x1b[2Kdone\none\ntwo\n" {
+		t.Errorf("println = %q", out.String())
+	}
+
+	out.Reset()
+	v.Render("a line that is far too long")
+	if !strings.HasSuffix(out.String(), "a line th…\n") {
+		t.Errorf("truncated render = %q", out.String())
+	}
+}
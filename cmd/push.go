@@ -1,117 +1,118 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"jenkins/internal/jenkins"
-	"net/url"
-	"strconv"
-	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var pushWaitHealthy bool
+
 func init() {
 	rootCmd.AddCommand(pushCmd)
+
+	addNotifyFlag(pushCmd)
+	pushCmd.Flags().StringP("target", "t", "", "Deploy target from the config file (default: deployment.target)")
+	viper.BindPFlag("deployment.target", pushCmd.Flags().Lookup("target"))
+	pushCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Allow pushing to a protected subdomain")
+	pushCmd.Flags().BoolVar(&pushWaitHealthy, "wait-healthy", false, "Wait for the deploy job to finish and the site to serve the pushed build")
+	pushCmd.Flags().Duration("health-timeout", 15*time.Minute, "How long --wait-healthy waits in all for the deploy job to finish and the site to serve the pushed build")
+	viper.BindPFlag("deployment.health_timeout", pushCmd.Flags().Lookup("health-timeout"))
 }
 
 var pushCmd = &cobra.Command{
-	Use:   "push [build_id] [subdomain]",
-	Short: "Run the build-site pipeline and push a build to a site.",
-	Long:  `Push the given build for the specified pipeline to [subdomain].dev.bomgar.com`,
-	Args:  cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
+	Use:   "push [build_id|product] [subdomain]",
+	Short: "Run a deploy job to push a build to a site.",
+	Long: `Push the given build for the specified pipeline to [subdomain].[domain]
+
+Instead of a build ID, a product key or alias (e.g. rs or pra) pushes the
+latest build of that product's default branch.
+
+The deploy job, its parameters and the domain come from the deploy target
+selected with --target (default: the built-in 'site' target, which runs
+build-site). Subdomains the target marks as protected need --yes.
+
+With --wait-healthy, push waits for the deploy job to succeed and then polls
+the target's health URL until the site reports the pushed build number, giving
+up once --health-timeout (default 15m) has passed for the two together. The
+URL is a template with {{.Subdomain}}, {{.Domain}}, {{.BuildNumber}} and
+{{.Pipeline}}. By default the response only has to contain the build number;
+set health_pattern to a regex whose first group captures the reported build
+to match it exactly.`,
+	Args: cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		buildNumber := strings.ToLower(args[0])
-
-		if buildNumber == "rs" || buildNumber == "pra" {
-			searchProduct := viper.GetString("products.rs.search_name")
-			if buildNumber == "pra" {
-				searchProduct = viper.GetString("products.pra.search_name")
-			}
-			latestBuild, err := jenkinsClient.GetLatestBuild(viper.GetString("pipeline"), searchProduct, "origin/master")
-			if err != nil {
-				return err
-			}
-			buildNumber = latestBuild.ID
+		if err := checkNotifiers(); err != nil {
+			return err
 		}
 
-		domain := viper.GetString("deployment.domain")
-		verbose("Pushing [%s] to [%s.%s]", buildNumber, args[1], domain)
+		target, err := resolveTarget(viper.GetString("deployment.target"))
+		if err != nil {
+			return err
+		}
 
-		query := map[string]string{
-			"PROJECT_NAME": viper.GetString("pipeline"),
-			"BUILD_NUMBER": buildNumber,
-			"SUBDOMAIN":    args[1],
+		subdomain := args[1]
+		if target.IsProtected(subdomain) && !assumeYes {
+			return NewValidationError("subdomain", subdomain, fmt.Sprintf("is protected on target %s; pass --yes to push to it", target.Key))
 		}
-		vVerbose("build-site params [%#+v]", query)
-		res, err := jenkinsClient.TriggerBuild("build-site", query)
+
+		buildNumber, err := resolveBuildID(args[0])
 		if err != nil {
-			verbose("Request error")
 			return err
 		}
 
-		{
-			defer res.Body.Close()
-			bodyBytes, err := io.ReadAll(res.Body)
-			if err != nil {
-				return err
-			}
-			verbose("Body [%s]", string(bodyBytes))
+		values := deployValues{
+			Pipeline:    viper.GetString("pipeline"),
+			BuildNumber: buildNumber,
+			Subdomain:   subdomain,
+			Domain:      target.Domain,
+		}
+		verbose("Pushing [%s] to [%s.%s] with target [%s]", buildNumber, subdomain, target.Domain, target.Key)
+
+		query, err := target.JobParams(values)
+		if err != nil {
+			return err
+		}
+		vVerbose("%s params [%#+v]", target.Job, query)
+		location, err := triggerBuild(target.Job, query)
+		if err != nil {
+			return err
 		}
 
 		fmt.Printf("Push queued successfully!\n")
 		fmt.Printf("Build:     %s\n", buildNumber)
-		fmt.Printf("Target:    %s.%s\n", args[1], domain)
+		fmt.Printf("Target:    %s.%s (%s)\n", subdomain, target.Domain, target.Job)
 		fmt.Println()
 
-		location := res.Header.Get("Location")
-		u, err := url.Parse(location)
+		deployNumber, err := waitForBuildNumber(location)
+		if err != nil || deployNumber == "" {
+			return err
+		}
+
+		fmt.Printf("%s started: #%s\n", target.Job, deployNumber)
+		fmt.Printf("Monitor with: jenkins monitor --pipeline %s %s\n", target.Job, deployNumber)
+
+		if !pushWaitHealthy {
+			return watchBuilds(target.Job, deployNumber)
+		}
+
+		cmd.SilenceUsage = true
+		timeout := viper.GetDuration("deployment.health_timeout")
+		deadline := time.Now().Add(timeout)
+		fmt.Printf("Waiting for %s #%s to finish...\n", target.Job, deployNumber)
+		build, err := waitForBuildToFinish(target.Job, deployNumber, timeout)
 		if err != nil {
 			return err
 		}
-		path := u.Path[1:] + u.Fragment + u.RawQuery
-		queueNumber := QueueNumberFromPath(location)
-		verbose("Polling location [%s][%s] (queue #%s)", location, path, queueNumber)
-		p := NewURLPoller(path)
-		defer p.Stop()
-
-		for res := range p.Response {
-			var queue jenkins.QueueItem
-			if err := json.NewDecoder(res.Body).Decode(&queue); err != nil {
-				verbose("JSON decode error trying to parse build id [%v]", err)
-				res.Body.Close()
-				fmt.Printf("Could not resolve queue item %s to a build number.\n", queueNumber)
-				fmt.Printf("Resolve manually with: jenkins queue %s\n", queueNumber)
-				return nil
-			}
-			res.Body.Close()
-
-			if queue.Executable.Number == 0 {
-				verbose("Build not yet started, still queued...")
-				continue
-			}
-
-			buildSiteNumber := strconv.Itoa(queue.Executable.Number)
-			fmt.Printf("build-site started: #%s\n", buildSiteNumber)
-			fmt.Printf("Monitor with: jenkins monitor --pipeline build-site %s\n", buildSiteNumber)
-
-			buildArgs := []string{"monitor", "--bg", "--pipeline", "build-site"}
-			buildArgs = append(buildArgs, buildSiteNumber)
-			verbose("Spawning and passing args [%+v]", buildArgs)
-			cmd, err := SpawnBG(buildArgs...)
-			if err != nil {
-				return err
-			}
-
-			if err := cmd.Wait(); err != nil {
-				return err
-			}
-
-			break
+		if build == nil {
+			return fmt.Errorf("%s #%s was still running after %s", target.Job, deployNumber, timeout)
+		}
+		fmt.Println(noStyle.Render(fmt.Sprintf("%s: %s finished as [%s]", infoBoldStyle.Render(deployNumber), target.Job, resultStyle(build.Result).Render(build.Result))))
+		if build.Result != "SUCCESS" {
+			return &BuildResultError{BuildID: deployNumber, Result: build.Result}
 		}
 
-		return nil
+		return waitHealthy(*target, values, time.Until(deadline).Round(time.Second))
 	},
 }
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"jenkins/internal/jenkins"
+	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -52,3 +54,44 @@ Useful when a build was queued but the build number couldn't be determined autom
 		return fmt.Errorf("queue item %s was not resolved to a build number", queueNumber)
 	},
 }
+
+// waitForBuildNumber polls the queue item at location (as returned in the
+// Location header when triggering a build) until Jenkins assigns it a build
+// number. If the queue item can't be resolved it tells the user how to
+// resolve it manually and returns an empty build number.
+func waitForBuildNumber(location string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("no queue location in response")
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSuffix(u.Path[1:], "/") + "/api/json"
+	queueNumber := QueueNumberFromPath(location)
+	verbose("Polling queue location [%s] (queue #%s)", path, queueNumber)
+	p := NewURLPoller(path)
+	defer p.Stop()
+
+	for res := range p.Response {
+		var queue jenkins.QueueItem
+		if err := json.NewDecoder(res.Body).Decode(&queue); err != nil {
+			verbose("JSON decode error trying to parse build id [%v]", err)
+			res.Body.Close()
+			break
+		}
+		res.Body.Close()
+
+		if queue.Executable.Number == 0 {
+			verbose("Build not yet started, still queued...")
+			continue
+		}
+
+		return strconv.Itoa(queue.Executable.Number), nil
+	}
+
+	fmt.Printf("Could not resolve queue item %s to a build number.\n", queueNumber)
+	fmt.Printf("Resolve manually with: jenkins queue %s\n", queueNumber)
+	return "", nil
+}
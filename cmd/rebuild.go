@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/jenkins"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	rebuildParams  []string
+	rebuildMonitor bool
+)
+
+func init() {
+	rootCmd.AddCommand(rebuildCmd)
+
+	rebuildCmd.Flags().StringArrayVarP(&rebuildParams, "param", "p", []string{}, "Override a parameter as NAME=VALUE (repeatable)")
+	rebuildCmd.Flags().BoolVarP(&rebuildMonitor, "monitor", "m", false, "Monitor the new build in the background")
+	addNotifyFlag(rebuildCmd)
+}
+
+var rebuildCmd = &cobra.Command{
+	Use:   "rebuild [build_id|product]",
+	Short: "Re-run a build with the same parameters",
+	Long: `Trigger a new build using the parameters of an earlier build, optionally
+overriding some of them. A product key or alias rebuilds the latest build of
+that product's default branch.
+
+Parameters the job no longer declares are dropped, and password parameters are
+left to their defaults since Jenkins never returns their values.
+
+Examples:
+  jenkins rebuild 1234
+  jenkins rebuild 1234 -p TRYMAX_BRANCH=origin/hotfix --monitor`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkNotifiers(); err != nil {
+			return err
+		}
+
+		pipeline := viper.GetString("pipeline")
+
+		buildID, err := resolveBuildID(args[0])
+		if err != nil {
+			return err
+		}
+
+		original, err := jenkinsClient.GetBuildInfo(pipeline, buildID)
+		if err != nil {
+			return fmt.Errorf("failed to get build %s: %w", buildID, err)
+		}
+
+		defs, err := jenkinsClient.GetJobParameters(pipeline)
+		if err != nil {
+			return fmt.Errorf("failed to read parameter definitions for %s: %w", pipeline, err)
+		}
+
+		params := reusableParams(defs, original.Parameters())
+
+		overrides, err := parseParamFlags(rebuildParams)
+		if err != nil {
+			return err
+		}
+		for name, value := range overrides {
+			params[name] = value
+		}
+
+		if err := validateParams(defs, params); err != nil {
+			return err
+		}
+
+		location, err := triggerBuild(pipeline, params)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Rebuild of #%s queued successfully!\n", buildID)
+		printParams(params)
+		fmt.Printf("Queue:   %s\n", location)
+		fmt.Println()
+
+		_, err = trackQueuedBuild(pipeline, location, rebuildMonitor)
+		return err
+	},
+}
+
+// reusableParams returns the parameters of an earlier build that can be
+// passed to a new one: those the job still declares, excluding passwords
+func reusableParams(defs []jenkins.ParameterDefinition, original map[string]string) map[string]string {
+	params := map[string]string{}
+	for name, value := range original {
+		idx := slices.IndexFunc(defs, func(d jenkins.ParameterDefinition) bool { return d.Name == name })
+		if idx < 0 {
+			verbose("Dropping parameter [%s] that the job no longer declares", name)
+			continue
+		}
+		if defs[idx].Type == "PasswordParameterDefinition" {
+			verbose("Leaving password parameter [%s] to its default", name)
+			continue
+		}
+		params[name] = value
+	}
+	return params
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"testing"
+)
+
+func TestReusableParams(t *testing.T) {
+	defs := []jenkins.ParameterDefinition{
+		{Name: "PRODUCT", Type: "ChoiceParameterDefinition", Choices: []string{"ingredi", "bpam"}},
+		{Name: "TOKEN", Type: "PasswordParameterDefinition"},
+	}
+	original := map[string]string{
+		"PRODUCT": "bpam",
+		"TOKEN":   "",
+		"REMOVED": "old",
+	}
+
+	params := reusableParams(defs, original)
+	if len(params) != 1 || params["PRODUCT"] != "bpam" {
+		t.Errorf("reusableParams() = %v, want only PRODUCT=bpam", params)
+	}
+}
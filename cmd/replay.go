@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/jenkins"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ReplayStartTimeout is how long to wait for a replayed or restarted build to appear
+	ReplayStartTimeout = 2 * time.Minute
+)
+
+var (
+	replayScriptFile string
+	replaySaveFile   string
+	replayMonitor    bool
+)
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+
+	replayCmd.Flags().StringVarP(&replayScriptFile, "file", "f", "", "Jenkinsfile to replay with (default: edit the original in $EDITOR)")
+	replayCmd.Flags().StringVar(&replaySaveFile, "save", "", "Only save the build's original Jenkinsfile to this path")
+	replayCmd.Flags().BoolVarP(&replayMonitor, "monitor", "m", false, "Monitor the new build in the background")
+	replayCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	addNotifyFlag(replayCmd)
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [build_id]",
+	Short: "Replay a pipeline build with a modified Jenkinsfile",
+	Long: `Re-run a pipeline build through the Replay endpoint with a locally edited
+Jenkinsfile, keeping the original build's parameters and SCM revision.
+
+Without --file, the original script is opened in $EDITOR and the edited
+version is replayed.
+
+Examples:
+  jenkins replay 1234 --save Jenkinsfile   # fetch the script to edit
+  jenkins replay 1234 -f Jenkinsfile       # replay with the edited copy
+  jenkins replay 1234                      # edit in $EDITOR and replay`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkNotifiers(); err != nil {
+			return err
+		}
+
+		pipeline := viper.GetString("pipeline")
+		buildID := args[0]
+
+		if replaySaveFile != "" {
+			script, err := jenkinsClient.GetReplayScript(pipeline, buildID)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(replaySaveFile, []byte(script), 0o644); err != nil {
+				return err
+			}
+			fmt.Printf("Saved the Jenkinsfile of build #%s to %s\n", buildID, replaySaveFile)
+			fmt.Printf("Replay with: jenkins replay %s -f %s\n", buildID, replaySaveFile)
+			return nil
+		}
+
+		script, err := replayScript(pipeline, buildID)
+		if err != nil {
+			return err
+		}
+
+		if !assumeYes {
+			ok, err := confirm(stdinReader, os.Stdout, fmt.Sprintf("Replay build #%s on [%s]?", buildID, pipeline))
+			if err != nil || !ok {
+				fmt.Println(grayStyle.Render("Skipped"))
+				return err
+			}
+		}
+
+		next, err := jenkinsClient.GetNextBuildNumber(pipeline)
+		if err != nil {
+			return err
+		}
+
+		if err := jenkinsClient.ReplayBuild(pipeline, buildID, script); err != nil {
+			return fmt.Errorf("failed to replay build %s: %w", buildID, err)
+		}
+		fmt.Printf("Replay of #%s queued successfully!\n", buildID)
+
+		buildNumber, err := waitForCausedBuild(pipeline, next, jenkins.ReplayCauseClass)
+		if err != nil {
+			return err
+		}
+		if buildNumber == "" {
+			fmt.Printf("Could not find the replayed build; check with: jenkins search --since 10m\n")
+			return nil
+		}
+
+		return trackStartedBuild(pipeline, buildNumber, replayMonitor)
+	},
+}
+
+// replayScript returns the script given with --file, or lets the user edit
+// the build's original script in $EDITOR
+func replayScript(pipeline, buildID string) (string, error) {
+	if replayScriptFile != "" {
+		script, err := os.ReadFile(replayScriptFile)
+		if err != nil {
+			return "", err
+		}
+		return string(script), nil
+	}
+
+	original, err := jenkinsClient.GetReplayScript(pipeline, buildID)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", "Jenkinsfile-*.groovy")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(original); err != nil {
+		f.Close()
+		return "", err
+	}
+	f.Close()
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	edit := exec.Command(editor, f.Name())
+	edit.Stdin, edit.Stdout, edit.Stderr = os.Stdin, os.Stdout, os.Stderr
+	verbose("Editing replay script with [%s]", editor)
+	if err := edit.Run(); err != nil {
+		return "", fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	if string(edited) == original {
+		fmt.Println(grayStyle.Render("The Jenkinsfile is unchanged."))
+	}
+	return string(edited), nil
+}
+
+// waitForCausedBuild polls recent builds for one numbered next or later that
+// has a cause of the given class
+func waitForCausedBuild(pipeline string, next int, causeClass string) (string, error) {
+	deadline := time.Now().Add(ReplayStartTimeout)
+	for time.Now().Before(deadline) {
+		builds, err := jenkinsClient.GetBuilds(pipeline, 5)
+		if err != nil {
+			return "", err
+		}
+		for _, b := range builds {
+			id, _ := strconv.Atoi(b.ID)
+			if id >= next && slices.ContainsFunc(b.Causes(), func(c jenkins.Cause) bool { return c.Class == causeClass }) {
+				return b.ID, nil
+			}
+		}
+		verbose("Build with cause [%s] not started yet...", causeClass)
+		time.Sleep(DefaultPollInterval)
+	}
+	return "", nil
+}
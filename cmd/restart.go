@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/formatting"
+	"jenkins/internal/jenkins"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(restartCmd)
+
+	restartCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Do not ask for confirmation")
+	addNotifyFlag(restartCmd)
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart [build_id] [stage]",
+	Short: "Restart a declarative pipeline build from a top-level stage",
+	Long: `Restart a completed declarative pipeline build from one of its top-level
+stages, skipping the stages before it. Stage names are matched
+case-insensitively, and a unique prefix is enough.
+
+Without a stage, lists the stages the build can be restarted from along with
+how they ran.
+
+Examples:
+  jenkins restart 1234              # list restartable stages
+  jenkins restart 1234 "Run Tests"
+  jenkins restart rs deploy --yes`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkNotifiers(); err != nil {
+			return err
+		}
+
+		pipeline := viper.GetString("pipeline")
+
+		buildID, err := resolveBuildID(args[0])
+		if err != nil {
+			return err
+		}
+
+		build, err := jenkinsClient.GetBuildInfo(pipeline, buildID)
+		if err != nil {
+			return fmt.Errorf("failed to get build %s: %w", buildID, err)
+		}
+		if build.Building {
+			return fmt.Errorf("build %s is still running; only completed builds can be restarted", buildID)
+		}
+
+		restartable := build.RestartableStages()
+		if len(restartable) == 0 {
+			return fmt.Errorf("build %s cannot be restarted from a stage (is it a declarative pipeline with restart enabled?)", buildID)
+		}
+
+		job, err := jenkinsClient.GetJobDetails(pipeline, buildID)
+		if err != nil {
+			return fmt.Errorf("failed to get stages of build %s: %w", buildID, err)
+		}
+
+		if len(args) == 1 {
+			printRestartableStages(restartable, job.Stages)
+			fmt.Printf("\nRestart with: jenkins restart %s <stage>\n", buildID)
+			return nil
+		}
+
+		stage, err := matchStageName(restartable, args[1])
+		if err != nil {
+			return err
+		}
+
+		if !assumeYes {
+			ok, err := confirm(stdinReader, os.Stdout, fmt.Sprintf("Restart build #%s on [%s] from stage [%s]?", buildID, pipeline, stage))
+			if err != nil || !ok {
+				fmt.Println(grayStyle.Render("Skipped"))
+				return err
+			}
+		}
+
+		next, err := jenkinsClient.GetNextBuildNumber(pipeline)
+		if err != nil {
+			return err
+		}
+
+		if err := jenkinsClient.RestartFromStage(pipeline, buildID, stage); err != nil {
+			return fmt.Errorf("failed to restart build %s from stage %s: %w", buildID, stage, err)
+		}
+		fmt.Printf("Restart of #%s from [%s] queued successfully!\n", buildID, stage)
+
+		// Restarting doesn't return the queue item, so find it in the queue. If
+		// it has already left the queue, look for the started build instead.
+		item, err := jenkinsClient.FindQueuedBuild(pipeline, jenkins.RestartCauseClass)
+		if err != nil {
+			verbose("Failed to read the build queue [%v]", err)
+		}
+		if item != nil {
+			location := fmt.Sprintf("/queue/item/%d/", item.ID)
+			fmt.Printf("Queue:   %s\n", location)
+			fmt.Println()
+			_, err = trackQueuedBuild(pipeline, location, true)
+			return err
+		}
+
+		buildNumber, err := waitForCausedBuild(pipeline, next, jenkins.RestartCauseClass)
+		if err != nil {
+			return err
+		}
+		if buildNumber == "" {
+			fmt.Printf("Could not find the restarted build; check with: jenkins search --since 10m\n")
+			return nil
+		}
+
+		return trackStartedBuild(pipeline, buildNumber, true)
+	},
+}
+
+// matchStageName resolves name against the restartable stages, accepting an
+// exact case-insensitive match or a unique case-insensitive prefix
+func matchStageName(stages []string, name string) (string, error) {
+	var matches []string
+	for _, s := range stages {
+		if strings.EqualFold(s, name) {
+			return s, nil
+		}
+		if strings.HasPrefix(strings.ToLower(s), strings.ToLower(name)) {
+			matches = append(matches, s)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return "", NewValidationError("stage", name, fmt.Sprintf("must be one of: %s", strings.Join(stages, ", ")))
+	default:
+		return "", NewValidationError("stage", name, fmt.Sprintf("is ambiguous between: %s", strings.Join(matches, ", ")))
+	}
+}
+
+// printRestartableStages prints the restartable stages with the status and
+// duration they had in the original build
+func printRestartableStages(restartable []string, stages []jenkins.Stage) {
+	t := table.New().
+		Border(lipgloss.ThickBorder()).
+		BorderStyle(BorderStyle).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return HeaderStyle
+			}
+			style := EvenRowStyle
+			if row%2 != 0 {
+				style = OddRowStyle
+			}
+			return stdRe.NewStyle().Width(0).Inherit(style)
+		}).
+		Headers("STAGE", "STATUS", "DURATION")
+
+	for _, name := range restartable {
+		status, duration := "NOT RUN", ""
+		for _, s := range stages {
+			if strings.EqualFold(s.Name, name) {
+				status = s.Status
+				duration = formatting.Duration(time.Duration(s.Duration) * time.Millisecond)
+				break
+			}
+		}
+		t.Row(name, status, duration)
+	}
+
+	fmt.Println(t)
+}
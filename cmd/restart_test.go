@@ -0,0 +1,31 @@
+package cmd
+
+import "testing"
+
+func TestMatchStageName(t *testing.T) {
+	stages := []string{"Build", "Test", "Test Report", "Deploy"}
+
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"deploy", "Deploy", false},
+		{"test", "Test", false},
+		{"test r", "Test Report", false},
+		{"Dep", "Deploy", false},
+		{"te", "", true},
+		{"Lint", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := matchStageName(stages, tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("matchStageName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("matchStageName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
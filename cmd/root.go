@@ -27,13 +27,25 @@ var (
 
 	// jenkinsClient is the shared Jenkins API client instance
 	jenkinsClient *jenkins.Client
+	// clientConfig is the resolved configuration jenkinsClient was created with
+	clientConfig jenkins.Config
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "jenkins",
 	Short: "Summarize recent Jenkins jobs",
 	Long: `Read the last 10 Jenkins jobs and summarize the
-	pipeline data.`,
+	pipeline data.
+
+Exit codes:
+  0  success
+  1  other error
+  2  build failed
+  3  build unstable
+  4  build aborted
+  5  build, job or item not found
+  6  authentication error
+  7  network error`,
 	Version: Version,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		var (
@@ -44,17 +56,30 @@ var rootCmd = &cobra.Command{
 		if vHost == nil || vHost == "" {
 			return fmt.Errorf("you must provide a host")
 		}
-		if vUser == nil || vKey == nil || vUser == "" || vKey == "" {
+		if vUser == nil || vUser == "" {
 			return fmt.Errorf("you must provide both a username and an API key")
 		}
 
+		// Fall back to the secret backend when no key was given directly
+		if vKey == nil || vKey == "" {
+			stored, err := storedAPIKey(vUser.(string), vHost.(string))
+			if err != nil {
+				return fmt.Errorf("failed to read stored API key: %w", err)
+			}
+			if stored == "" {
+				return fmt.Errorf("you must provide both a username and an API key (or run 'jenkins login')")
+			}
+			vKey = stored
+		}
+
 		// Initialize Jenkins client
-		jenkinsClient = jenkins.NewClient(jenkins.Config{
+		clientConfig = jenkins.Config{
 			Host:    vHost.(string),
 			User:    vUser.(string),
 			APIKey:  vKey.(string),
 			Verbose: verbose,
-		})
+		}
+		jenkinsClient = jenkins.NewClient(clientConfig)
 
 		return nil
 	},
@@ -73,15 +98,10 @@ func init() {
 	viper.BindPFlag("user", rootCmd.PersistentFlags().Lookup("user"))
 	rootCmd.PersistentFlags().StringVar(&key, "key", "", "Jenkins host")
 	viper.BindPFlag("key", rootCmd.PersistentFlags().Lookup("key"))
-	rootCmd.PersistentFlags().StringVar(&pipeline, "pipeline", "master", "Jenkins pipeline to analyze")
+	rootCmd.PersistentFlags().StringVar(&pipeline, "pipeline", "master", "Jenkins pipeline to analyze (use slashes for jobs in folders, e.g. team/service/PR-123)")
 	viper.BindPFlag("pipeline", rootCmd.PersistentFlags().Lookup("pipeline"))
 	viper.SetDefault("pipeline", "master")
 
-	// Product configuration defaults (can be overridden in config file)
-	viper.SetDefault("products.rs.search_name", "ingredi")
-	viper.SetDefault("products.rs.display_name", "RS")
-	viper.SetDefault("products.pra.search_name", "bpam")
-	viper.SetDefault("products.pra.display_name", "PRA")
 	viper.SetDefault("deployment.domain", "dev.bomgar.com")
 
 	rootCmd.PersistentFlags().CountVarP(&Verbose, "verbose", "v", "verbose output")
@@ -141,6 +161,6 @@ func Execute() {
 	setDefaultCommandIfNonePresent("timing")
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
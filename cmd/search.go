@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"fmt"
+	"jenkins/internal/formatting"
+	"jenkins/internal/jenkins"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	searchExact   []string
+	searchGlob    []string
+	searchRegex   []string
+	searchResults []string
+	searchUser    string
+	searchSince   string
+	searchUntil   string
+	searchMinDur  time.Duration
+	searchMaxDur  time.Duration
+	searchLimit   int
+	searchMax     int
+	searchSort    string
+	searchAsc     bool
+	searchShow    []string
+)
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().StringArrayVarP(&searchExact, "param", "p", []string{}, "Parameter equals value, as NAME=VALUE (repeatable)")
+	searchCmd.Flags().StringArrayVarP(&searchGlob, "glob", "g", []string{}, "Parameter matches a glob, as NAME=PATTERN (repeatable)")
+	searchCmd.Flags().StringArrayVarP(&searchRegex, "regex", "x", []string{}, "Parameter matches a regular expression, as NAME=PATTERN (repeatable)")
+	searchCmd.Flags().StringArrayVarP(&searchResults, "result", "r", []string{}, "Build result: SUCCESS, FAILURE, UNSTABLE, ABORTED or BUILDING (repeatable)")
+	searchCmd.Flags().StringVarP(&searchUser, "user", "u", "", "User ID or name that triggered the build")
+	searchCmd.Flags().StringVar(&searchSince, "since", "", "Only builds started after this time (e.g. 36h, 7d, 2024-01-31)")
+	searchCmd.Flags().StringVar(&searchUntil, "until", "", "Only builds started before this time (e.g. 36h, 7d, 2024-01-31)")
+	searchCmd.Flags().DurationVar(&searchMinDur, "min-duration", 0, "Only builds that took at least this long (e.g. 30m)")
+	searchCmd.Flags().DurationVar(&searchMaxDur, "max-duration", 0, "Only builds that took at most this long (e.g. 1h)")
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", jenkins.DefaultQueryLimit, "Number of recent builds to scan")
+	searchCmd.Flags().IntVarP(&searchMax, "max", "n", 0, "Maximum number of matches to print (0 for all)")
+	searchCmd.Flags().StringVarP(&searchSort, "sort", "s", "time", "Sort by: time, id, duration, result or user")
+	searchCmd.Flags().BoolVar(&searchAsc, "asc", false, "Sort ascending instead of descending")
+	searchCmd.Flags().StringArrayVar(&searchShow, "show", []string{}, "Extra parameter to show as a column (repeatable)")
+}
+
+var searchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "Search recent builds by parameters, result, user and time",
+	Long: `Scan the most recent builds of the pipeline and print those matching every filter.
+
+Parameters can be matched exactly (-p), by glob (-g) or by regular expression (-x).
+Every parameter used in a filter is shown as a column.
+
+Examples:
+  # last successful bpam build of any release branch
+  jenkins search -p PRODUCT=bpam -g 'TRYMAX_BRANCH=origin/release/*' -r SUCCESS -n 1
+
+  # failed builds triggered by alice in the last two days that ran over an hour
+  jenkins search -r FAILURE -u alice --since 2d --min-duration 1h`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		q, columns, err := buildSearchQuery(time.Now())
+		if err != nil {
+			return err
+		}
+
+		builds, err := jenkinsClient.SearchBuilds(viper.GetString("pipeline"), q)
+		if err != nil {
+			return err
+		}
+
+		if len(builds) == 0 {
+			fmt.Println(grayStyle.Render(fmt.Sprintf("No matching builds in the last %d builds", q.Limit)))
+			return nil
+		}
+
+		if err := sortBuilds(builds, searchSort, searchAsc); err != nil {
+			return err
+		}
+		if searchMax > 0 && len(builds) > searchMax {
+			builds = builds[:searchMax]
+		}
+
+		printBuildTable(builds, columns)
+		fmt.Println(grayStyle.Render(fmt.Sprintf("%d matching build(s) in the last %d builds", len(builds), q.Limit)))
+
+		return nil
+	},
+}
+
+// buildSearchQuery assembles the query from the search flags, returning the
+// parameter names to show as columns
+func buildSearchQuery(now time.Time) (jenkins.BuildQuery, []string, error) {
+	q := jenkins.BuildQuery{
+		User:        searchUser,
+		MinDuration: searchMinDur,
+		MaxDuration: searchMaxDur,
+		Limit:       searchLimit,
+	}
+	columns := []string{}
+
+	for _, flag := range []struct {
+		mode  jenkins.MatchMode
+		pairs []string
+	}{
+		{jenkins.MatchExact, searchExact},
+		{jenkins.MatchGlob, searchGlob},
+		{jenkins.MatchRegex, searchRegex},
+	} {
+		parsed, err := parseParamPairs(flag.pairs)
+		if err != nil {
+			return q, nil, err
+		}
+		for _, p := range parsed {
+			m, err := jenkins.NewParameterMatcher(p.Key, flag.mode, p.Value)
+			if err != nil {
+				return q, nil, NewValidationError(p.Key, p.Value, err.Error())
+			}
+			q.Parameters = append(q.Parameters, m)
+			columns = appendUnique(columns, p.Key)
+		}
+	}
+	sort.Strings(columns)
+	for _, c := range searchShow {
+		columns = appendUnique(columns, c)
+	}
+
+	for _, r := range searchResults {
+		q.Results = append(q.Results, strings.ToUpper(r))
+	}
+
+	var err error
+	if searchSince != "" {
+		if q.Since, err = parseTimeFlag(searchSince, now); err != nil {
+			return q, nil, NewValidationError("since", searchSince, err.Error())
+		}
+	}
+	if searchUntil != "" {
+		if q.Until, err = parseTimeFlag(searchUntil, now); err != nil {
+			return q, nil, NewValidationError("until", searchUntil, err.Error())
+		}
+	}
+
+	return q, columns, nil
+}
+
+// appendUnique appends value to list unless it is already present
+func appendUnique(list []string, value string) []string {
+	if slices.Contains(list, value) {
+		return list
+	}
+	return append(list, value)
+}
+
+// parseTimeFlag parses an absolute date/time or a relative age such as
+// "36h" or "7d" (meaning that long before now)
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected a duration (36h, 7d) or a date (2006-01-02)")
+}
+
+// sortBuilds sorts builds in place by the named column
+func sortBuilds(builds []jenkins.WorkflowRun, by string, asc bool) error {
+	var less func(a, b jenkins.WorkflowRun) bool
+	switch by {
+	case "time":
+		less = func(a, b jenkins.WorkflowRun) bool { return a.Timestamp.Time.Before(b.Timestamp.Time) }
+	case "id":
+		less = func(a, b jenkins.WorkflowRun) bool {
+			ai, _ := strconv.Atoi(a.ID)
+			bi, _ := strconv.Atoi(b.ID)
+			return ai < bi
+		}
+	case "duration":
+		less = func(a, b jenkins.WorkflowRun) bool { return a.Duration < b.Duration }
+	case "result":
+		less = func(a, b jenkins.WorkflowRun) bool { return a.Result < b.Result }
+	case "user":
+		less = func(a, b jenkins.WorkflowRun) bool { return a.TriggeredBy() < b.TriggeredBy() }
+	default:
+		return NewValidationError("sort", by, "must be one of: time, id, duration, result, user")
+	}
+
+	sort.SliceStable(builds, func(i, j int) bool {
+		if asc {
+			return less(builds[i], builds[j])
+		}
+		return less(builds[j], builds[i])
+	})
+	return nil
+}
+
+// printBuildTable prints builds with the given parameters as extra columns
+func printBuildTable(builds []jenkins.WorkflowRun, paramColumns []string) {
+	t := table.New().
+		Border(lipgloss.ThickBorder()).
+		BorderStyle(BorderStyle).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return HeaderStyle
+			}
+			style := EvenRowStyle
+			if row%2 != 0 {
+				style = OddRowStyle
+			}
+			return stdRe.NewStyle().Width(0).Inherit(style)
+		}).
+		Headers(append([]string{"BUILD", "RESULT", "STARTED", "DURATION", "USER"}, paramColumns...)...)
+
+	for _, b := range builds {
+		result := b.Result
+		if b.Building {
+			result = "BUILDING"
+		}
+		params := b.Parameters()
+		row := []string{
+			b.ID,
+			result,
+			b.Timestamp.Time.Format("2006-01-02 15:04"),
+			formatting.Duration(time.Duration(b.Duration) * time.Millisecond),
+			b.TriggeredBy(),
+		}
+		for _, c := range paramColumns {
+			row = append(row, params[c])
+		}
+		t.Row(row...)
+	}
+
+	fmt.Println(t)
+}
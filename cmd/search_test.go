@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"testing"
+	"time"
+)
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{"36h", now.Add(-36 * time.Hour), false},
+		{"7d", now.AddDate(0, 0, -7), false},
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local), false},
+		{"2024-01-31 08:30", time.Date(2024, 1, 31, 8, 30, 0, 0, time.Local), false},
+		{"yesterday", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTimeFlag(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeFlag(%s) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.expected) {
+				t.Errorf("parseTimeFlag(%s) = %v, want %v", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSortBuilds(t *testing.T) {
+	builds := []jenkins.WorkflowRun{
+		{ID: "9", Duration: 300},
+		{ID: "10", Duration: 100},
+		{ID: "8", Duration: 200},
+	}
+
+	if err := sortBuilds(builds, "id", true); err != nil {
+		t.Fatalf("sortBuilds failed: %v", err)
+	}
+	if builds[0].ID != "8" || builds[2].ID != "10" {
+		t.Errorf("id sort should be numeric, got %s,%s,%s", builds[0].ID, builds[1].ID, builds[2].ID)
+	}
+
+	if err := sortBuilds(builds, "duration", false); err != nil {
+		t.Fatalf("sortBuilds failed: %v", err)
+	}
+	if builds[0].Duration != 300 {
+		t.Errorf("duration desc sort first = %d, want 300", builds[0].Duration)
+	}
+
+	if err := sortBuilds(builds, "color", false); err == nil {
+		t.Error("expected error for unknown sort column")
+	}
+}
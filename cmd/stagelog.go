@@ -60,7 +60,7 @@ var stageLogCmd = &cobra.Command{
 		}
 		logURL := stage.Links.Log.HREF
 		if fetchFullLog {
-			logURL = fmt.Sprintf("job/%s/%s/execution/node/%s/log/?consoleFull", viper.GetString("pipeline"), buildID, stageID)
+			logURL = fmt.Sprintf("%s/%s/execution/node/%s/log/?consoleFull", jenkins.JobPath(viper.GetString("pipeline")), buildID, stageID)
 		}
 
 		var lines []string
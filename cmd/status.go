@@ -3,20 +3,26 @@ package cmd
 import (
 	"fmt"
 	"jenkins/internal/jenkins"
+	"math"
 	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
-	buildIDs []string
+	buildIDs      []string
+	statusWait    bool
+	statusTimeout time.Duration
 )
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
 
 	statusCmd.Flags().StringArrayVarP(&buildIDs, "build", "b", []string{}, "Build ID")
+	statusCmd.Flags().BoolVarP(&statusWait, "wait", "w", false, "Wait for running builds to finish")
+	statusCmd.Flags().DurationVar(&statusTimeout, "timeout", 0, "Give up waiting after this long (default: no limit)")
 }
 
 var statusCmd = &cobra.Command{
@@ -26,13 +32,19 @@ var statusCmd = &cobra.Command{
 
 Build IDs can be passed as positional arguments or with the -b flag:
   jenkins status 1234 5678
-  jenkins status -b 1234 -b 5678`,
+  jenkins status -b 1234 -b 5678
+
+The exit code reflects the worst result among the finished builds (see
+'jenkins --help'). With --wait, running builds are waited on first:
+  jenkins status --wait 1234 && deploy`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		allIDs := append(buildIDs, args...)
 		if len(allIDs) == 0 {
 			return fmt.Errorf("at least one build ID is required (as argument or with -b flag)")
 		}
 
+		cmd.SilenceUsage = true
+
 		builds := []*jenkins.WorkflowRun{}
 		for _, buildID := range allIDs {
 			build, err := jenkinsClient.GetBuildInfo(viper.GetString("pipeline"), buildID)
@@ -40,6 +52,11 @@ Build IDs can be passed as positional arguments or with the -b flag:
 				verbose("getBuildInfo returned error")
 				return err
 			}
+			if build.Building && statusWait {
+				if build, err = waitForStatus(viper.GetString("pipeline"), buildID); err != nil {
+					return err
+				}
+			}
 			builds = append(builds, build)
 		}
 
@@ -51,16 +68,27 @@ Build IDs can be passed as positional arguments or with the -b flag:
 		for _, build := range builds {
 			id := infoBoldStyle.Render(build.ID)
 			name := infoBoldStyle.Render(build.DisplayName)
-			rStyle := infoBoldStyle
-			if build.Result == "SUCCESS" {
-				rStyle = successStyle
-			} else if build.Result == "FAILURE" {
-				rStyle = failureStyle
-			}
-			result := rStyle.Render(build.Result)
+			result := resultStyle(build.Result).Render(build.Result)
 			fmt.Println(noStyle.Render(fmt.Sprintf("%s: The status for [%s] on branch [%s] is [%s]", id, name, pipeline, result)))
 		}
 
-		return nil
+		return worstResult(builds)
 	},
 }
+
+// waitForStatus waits for a running build to finish, within --timeout if set
+func waitForStatus(pipeline, buildID string) (*jenkins.WorkflowRun, error) {
+	verbose("Waiting for build [%s] to finish", buildID)
+	timeout := statusTimeout
+	if timeout <= 0 {
+		timeout = time.Duration(math.MaxInt64)
+	}
+	build, err := waitForBuildToFinish(pipeline, buildID, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if build == nil {
+		return nil, fmt.Errorf("build %s still running after %s", buildID, statusTimeout)
+	}
+	return build, nil
+}
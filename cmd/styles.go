@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
 )
 
 // Color definitions
@@ -46,5 +47,39 @@ var (
 	failureStyle = stdRe.NewStyle().Bold(true).Foreground(white).Background(red)
 )
 
+// resultStyle returns the style used to render a build result
+func resultStyle(result string) lipgloss.Style {
+	switch result {
+	case "SUCCESS":
+		return successStyle
+	case "FAILURE":
+		return failureStyle
+	default:
+		return infoBoldStyle
+	}
+}
+
 // vPrefix is used for verbose logging to show process ID
 var vPrefix = fmt.Sprintf(" →(%d) ", os.Getpid())
+
+// reportTable returns a table styled like the other reports, with the first
+// column left aligned and the rest right aligned
+func reportTable(headers ...string) *table.Table {
+	return table.New().
+		Border(lipgloss.ThickBorder()).
+		BorderStyle(BorderStyle).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == 0 {
+				return HeaderStyle
+			}
+			style := EvenRowStyle
+			if row%2 != 0 {
+				style = OddRowStyle
+			}
+			if col > 0 {
+				style = stdRe.NewStyle().Align(lipgloss.Right).Inherit(style)
+			}
+			return stdRe.NewStyle().Width(0).Inherit(style)
+		}).
+		Headers(headers...)
+}
@@ -60,3 +60,23 @@ func TestVPrefixInitialized(t *testing.T) {
 		t.Errorf("vPrefix seems too short: %s", vPrefix)
 	}
 }
+
+func TestResultStyle(t *testing.T) {
+	tests := []struct {
+		result   string
+		expected lipgloss.Style
+	}{
+		{"SUCCESS", successStyle},
+		{"FAILURE", failureStyle},
+		{"ABORTED", infoBoldStyle},
+		{"", infoBoldStyle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.result, func(t *testing.T) {
+			if got := resultStyle(tt.result).Render("x"); got != tt.expected.Render("x") {
+				t.Errorf("resultStyle(%q) rendered %q, want %q", tt.result, got, tt.expected.Render("x"))
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// deployTarget is a downstream deploy job used by push, defined under
+// targets.<key> in the config file
+type deployTarget struct {
+	Key string
+	Job string
+	// Parameters are NAME=TEMPLATE pairs expanded with deployValues
+	Parameters []string
+	Domain     string
+	// Protected lists subdomain globs that can only be pushed to with --yes
+	Protected     []string
+	HealthURL     string
+	HealthPattern string
+}
+
+func init() {
+	viper.SetDefault("deployment.target", "site")
+
+	// The built-in target runs build-site
+	viper.SetDefault("targets.site.job", "build-site")
+	viper.SetDefault("targets.site.parameters", []string{
+		"PROJECT_NAME={{.Pipeline}}",
+		"BUILD_NUMBER={{.BuildNumber}}",
+		"SUBDOMAIN={{.Subdomain}}",
+	})
+}
+
+// targetKeys returns the keys of all configured deploy targets, sorted
+func targetKeys() []string {
+	return configKeys("targets")
+}
+
+// getTarget reads the deploy target for key, falling back to the deployment
+// settings for the domain and health check
+func getTarget(key string) deployTarget {
+	prefix := "targets." + key + "."
+	t := deployTarget{
+		Key:           key,
+		Job:           viper.GetString(prefix + "job"),
+		Parameters:    viper.GetStringSlice(prefix + "parameters"),
+		Domain:        viper.GetString(prefix + "domain"),
+		Protected:     viper.GetStringSlice(prefix + "protected"),
+		HealthURL:     viper.GetString(prefix + "health_url"),
+		HealthPattern: viper.GetString(prefix + "health_pattern"),
+	}
+	if t.Domain == "" {
+		t.Domain = viper.GetString("deployment.domain")
+	}
+	if t.HealthURL == "" {
+		t.HealthURL = viper.GetString("deployment.health_url")
+	}
+	if t.HealthPattern == "" {
+		t.HealthPattern = viper.GetString("deployment.health_pattern")
+	}
+	return t
+}
+
+// resolveTarget looks up a deploy target by key (case insensitive)
+func resolveTarget(name string) (*deployTarget, error) {
+	name = strings.ToLower(name)
+	for _, key := range targetKeys() {
+		if key == name {
+			t := getTarget(key)
+			if t.Job == "" {
+				return nil, NewConfigError(t.field("job"), "required for deploy targets")
+			}
+			return &t, nil
+		}
+	}
+	return nil, NewValidationError("target", name, fmt.Sprintf("unknown deploy target (must be one of: %s)", strings.Join(targetKeys(), ", ")))
+}
+
+// field returns the config key of one of the target's fields
+func (t deployTarget) field(name string) string {
+	return "targets." + t.Key + "." + name
+}
+
+// IsProtected reports whether subdomain matches one of the protected globs
+func (t deployTarget) IsProtected(subdomain string) bool {
+	for _, glob := range t.Protected {
+		if ok, _ := path.Match(strings.ToLower(glob), strings.ToLower(subdomain)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// JobParams expands the target's parameter templates
+func (t deployTarget) JobParams(values deployValues) (map[string]string, error) {
+	templates, err := parseParamFlags(t.Parameters)
+	if err != nil {
+		return nil, NewConfigError(t.field("parameters"), err.Error())
+	}
+
+	params := map[string]string{}
+	for name, tmpl := range templates {
+		value, err := expandTemplate(t.field("parameters"), tmpl, values)
+		if err != nil {
+			return nil, err
+		}
+		params[name] = value
+	}
+	return params, nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestDefaultTarget(t *testing.T) {
+	target, err := resolveTarget(viper.GetString("deployment.target"))
+	if err != nil {
+		t.Fatalf("resolveTarget() failed: %v", err)
+	}
+	if target.Job != "build-site" || target.Domain != viper.GetString("deployment.domain") {
+		t.Errorf("default target = %+v", target)
+	}
+
+	params, err := target.JobParams(deployValues{Pipeline: "master", BuildNumber: "1234", Subdomain: "qa1"})
+	if err != nil {
+		t.Fatalf("JobParams() failed: %v", err)
+	}
+	expected := map[string]string{"PROJECT_NAME": "master", "BUILD_NUMBER": "1234", "SUBDOMAIN": "qa1"}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Errorf("params[%s] = %q, want %q", k, params[k], v)
+		}
+	}
+	if target.IsProtected("qa1") {
+		t.Error("the default target should not protect any subdomain")
+	}
+}
+
+func TestTargetFromConfig(t *testing.T) {
+	viper.Set("targets.agent.job", "deploy-agent")
+	viper.Set("targets.agent.parameters", []string{"ARTIFACT={{.Pipeline}}-{{.BuildNumber}}", "ENV={{.Subdomain}}", "REGION=us"})
+	viper.Set("targets.agent.domain", "agents.example.com")
+	viper.Set("targets.agent.protected", []string{"prod*", "www"})
+	viper.Set("targets.broken.parameters", []string{"X=1"})
+	defer func() {
+		for _, k := range []string{"job", "parameters", "domain", "protected"} {
+			viper.Set("targets.agent."+k, nil)
+		}
+		viper.Set("targets.broken.parameters", nil)
+	}()
+
+	target, err := resolveTarget("Agent")
+	if err != nil {
+		t.Fatalf("resolveTarget() failed: %v", err)
+	}
+	if target.HealthURL != viper.GetString("deployment.health_url") {
+		t.Errorf("HealthURL = %q, want the deployment default", target.HealthURL)
+	}
+
+	params, err := target.JobParams(deployValues{Pipeline: "agent", BuildNumber: "77", Subdomain: "staging", Domain: target.Domain})
+	if err != nil {
+		t.Fatalf("JobParams() failed: %v", err)
+	}
+	if params["ARTIFACT"] != "agent-77" || params["ENV"] != "staging" || params["REGION"] != "us" {
+		t.Errorf("params = %v", params)
+	}
+
+	for subdomain, want := range map[string]bool{"prod": true, "PROD-eu": true, "www": true, "staging": false} {
+		if got := target.IsProtected(subdomain); got != want {
+			t.Errorf("IsProtected(%s) = %v, want %v", subdomain, got, want)
+		}
+	}
+
+	var cfgErr *ConfigError
+	if _, err := resolveTarget("broken"); !errors.As(err, &cfgErr) {
+		t.Errorf("resolveTarget(broken) error = %v, want ConfigError", err)
+	}
+	var vErr *ValidationError
+	if _, err := resolveTarget("nope"); !errors.As(err, &vErr) {
+		t.Errorf("resolveTarget(nope) error = %v, want ValidationError", err)
+	}
+}
@@ -0,0 +1,348 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"jenkins/internal/formatting"
+	"jenkins/internal/jenkins"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	testsSlowest    int
+	testsHistory    int
+	testsStackLines int
+)
+
+func init() {
+	rootCmd.AddCommand(testsCmd)
+
+	testsCmd.Flags().IntVarP(&testsSlowest, "slowest", "s", 10, "Number of slowest tests to show (0 to hide)")
+	testsCmd.Flags().IntVarP(&testsHistory, "history", "H", 5, "Number of earlier builds with the same parameters to compare with (0 to skip)")
+	testsCmd.Flags().IntVar(&testsStackLines, "stack-lines", 8, "Lines of each stack trace to show (0 for all)")
+}
+
+var testsCmd = &cobra.Command{
+	Use:   "tests [build_id]",
+	Short: "Show the test results of a build",
+	Long: `Show the JUnit results Jenkins recorded for a build: failed tests with their
+error message and the start of their stack trace, test counts by suite and the
+slowest tests.
+
+The failed tests are also compared with the last --history builds that had
+the same parameters, to show which tests newly started failing and which
+have been failing for a while.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline := viper.GetString("pipeline")
+		buildID, err := resolveBuildID(args[0])
+		if err != nil {
+			return err
+		}
+
+		report, err := jenkinsClient.GetTestReport(pipeline, buildID)
+		if errors.Is(err, jenkins.ErrNotFound) {
+			return fmt.Errorf("build %s has no test results: %w", buildID, err)
+		}
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+
+		history := []testRun{{Build: buildID, Report: report}}
+		if testsHistory > 0 {
+			history = append(history, previousTestRuns(pipeline, buildID, testsHistory)...)
+		}
+		trends := testTrends(history)
+
+		printTestCounts(buildID, report)
+		printFailedTests(report.FailedCases(), trends, testsStackLines, 0)
+		printSuiteCounts(suiteCounts(report))
+		if testsSlowest > 0 {
+			printSlowestTests(slowestTests(report, testsSlowest))
+		}
+		if len(history) > 1 {
+			printTestTrends(history, trends)
+		}
+		return nil
+	},
+}
+
+// testRun is the test report of a build
+type testRun struct {
+	Build  string
+	Report *jenkins.TestReport
+}
+
+// previousTestRuns returns the test reports of up to n builds before buildID
+// with the same parameters, newest first. Builds without a report are skipped.
+func previousTestRuns(pipeline, buildID string, n int) []testRun {
+	build, err := jenkinsClient.GetBuildInfo(pipeline, buildID)
+	if err != nil {
+		verbose("Failed to read build [%s] [%v]", buildID, err)
+		return nil
+	}
+	builds, err := jenkinsClient.GetBuilds(pipeline, jenkins.DefaultQueryLimit)
+	if err != nil {
+		verbose("Failed to read build history [%v]", err)
+		return nil
+	}
+
+	number, _ := strconv.Atoi(buildID)
+	key := paramsKey(build.Parameters())
+	runs := []testRun{}
+	for _, b := range builds {
+		if len(runs) == n {
+			break
+		}
+		if id, err := strconv.Atoi(b.ID); err != nil || id >= number || b.Building || paramsKey(b.Parameters()) != key {
+			continue
+		}
+		report, err := jenkinsClient.GetTestReport(pipeline, b.ID)
+		if err != nil {
+			verbose("No test report for build [%s] [%v]", b.ID, err)
+			continue
+		}
+		runs = append(runs, testRun{Build: b.ID, Report: report})
+	}
+	return runs
+}
+
+// testTrend is a test's result across builds
+type testTrend struct {
+	Name string
+	// Results has one entry per build, newest first: FAILED, PASSED,
+	// SKIPPED, or empty if the build did not run the test
+	Results []string
+}
+
+// New reports whether the test fails in the newest build but did not fail
+// in the one before
+func (t testTrend) New() bool {
+	return t.Results[0] == jenkins.TestFailed && (len(t.Results) < 2 || t.Results[1] != jenkins.TestFailed)
+}
+
+// Streak returns for how many of the builds, newest first, the test has
+// been failing
+func (t testTrend) Streak() int {
+	n := 0
+	for _, r := range t.Results {
+		if r != jenkins.TestFailed {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// testTrends returns the trends of the tests that failed in any of the runs,
+// which are newest first, keyed by test name
+func testTrends(runs []testRun) map[string]*testTrend {
+	trends := map[string]*testTrend{}
+	for _, run := range runs {
+		for _, c := range run.Report.FailedCases() {
+			if trends[c.FullName()] == nil {
+				trends[c.FullName()] = &testTrend{Name: c.FullName(), Results: make([]string, len(runs))}
+			}
+		}
+	}
+	for i, run := range runs {
+		for _, s := range run.Report.AllSuites() {
+			for _, c := range s.Cases {
+				t, ok := trends[c.FullName()]
+				if !ok {
+					continue
+				}
+				switch {
+				case c.Failed():
+					t.Results[i] = jenkins.TestFailed
+				case c.Skipped || c.Status == jenkins.TestSkipped:
+					t.Results[i] = jenkins.TestSkipped
+				default:
+					t.Results[i] = jenkins.TestPassed
+				}
+			}
+		}
+	}
+	return trends
+}
+
+// suiteCount summarizes a test suite
+type suiteCount struct {
+	jenkins.TestSuite
+	Passed, Failed, Skipped int
+}
+
+// suiteCounts returns the suites of a report with failed suites first, then
+// by name
+func suiteCounts(report *jenkins.TestReport) []suiteCount {
+	counts := []suiteCount{}
+	for _, s := range report.AllSuites() {
+		sc := suiteCount{TestSuite: s}
+		for _, c := range s.Cases {
+			switch {
+			case c.Failed():
+				sc.Failed++
+			case c.Skipped || c.Status == jenkins.TestSkipped:
+				sc.Skipped++
+			default:
+				sc.Passed++
+			}
+		}
+		counts = append(counts, sc)
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Failed != counts[j].Failed {
+			return counts[i].Failed > counts[j].Failed
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+// slowestTests returns the n slowest test cases of a report
+func slowestTests(report *jenkins.TestReport, n int) []jenkins.TestCase {
+	cases := []jenkins.TestCase{}
+	for _, s := range report.AllSuites() {
+		cases = append(cases, s.Cases...)
+	}
+	sort.SliceStable(cases, func(i, j int) bool { return cases[i].Duration > cases[j].Duration })
+	return cases[:min(n, len(cases))]
+}
+
+// stackExcerpt returns the first n lines of a stack trace, all of them if n
+// is 0
+func stackExcerpt(trace string, n int) []string {
+	if trace == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(trace, "\n"), "\n")
+	if n > 0 && len(lines) > n {
+		return append(lines[:n], fmt.Sprintf("... (%d more lines)", len(lines)-n))
+	}
+	return lines
+}
+
+// failingFor describes how long a test has been failing, from the trend if
+// there is one and from Jenkins' own age otherwise
+func failingFor(c jenkins.TestCase, trend *testTrend) string {
+	switch {
+	case trend != nil && len(trend.Results) > 1 && trend.New():
+		return "NEW"
+	case trend != nil && len(trend.Results) > 1:
+		return fmt.Sprintf("failing in the last %d build(s)", trend.Streak())
+	case c.Age == 1 || c.Status == jenkins.TestRegression:
+		return "NEW"
+	case c.Age > 1:
+		return fmt.Sprintf("failing for %d builds, since #%d", c.Age, c.FailedSince)
+	}
+	return ""
+}
+
+func printTestCounts(buildID string, report *jenkins.TestReport) {
+	passed, failed, skipped := report.Counts()
+	total := passed + failed + skipped
+	summary := fmt.Sprintf("Build %s: %d tests, %d passed, %d failed, %d skipped in %s", buildID, total, passed, failed, skipped,
+		formatting.Duration(report.Time()))
+	if failed > 0 {
+		fmt.Println(orangeStyle.Render(summary))
+	} else {
+		fmt.Println(infoBoldStyle.Render(summary))
+	}
+	fmt.Println()
+}
+
+// printFailedTests prints the failed tests with their error and stack trace
+// excerpt. max limits the number printed, 0 prints all.
+func printFailedTests(failed []jenkins.TestCase, trends map[string]*testTrend, stackLines, max int) {
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Println(infoBoldStyle.Render("FAILED TESTS:"))
+	for i, c := range failed {
+		if max > 0 && i == max {
+			fmt.Println(grayStyle.Render(fmt.Sprintf("  ... and %d more", len(failed)-max)))
+			break
+		}
+		line := fmt.Sprintf("  %s %s (%s)", failureStyle.Render("✗"), infoBoldStyle.Render(c.FullName()), formatting.Duration(c.Time()))
+		if since := failingFor(c, trends[c.FullName()]); since != "" {
+			line += " " + orangeStyle.Render(since)
+		}
+		fmt.Println(line)
+		if c.ErrorDetails != "" {
+			msg, _, _ := strings.Cut(strings.TrimSpace(c.ErrorDetails), "\n")
+			fmt.Printf("    %s\n", msg)
+		}
+		for _, l := range stackExcerpt(c.ErrorStackTrace, stackLines) {
+			fmt.Println(grayStyle.Render("      " + l))
+		}
+	}
+	fmt.Println()
+}
+
+func printSuiteCounts(counts []suiteCount) {
+	t := reportTable("SUITE", "TESTS", "PASSED", "FAILED", "SKIPPED", "TIME")
+	for _, s := range counts {
+		t.Row(s.Name, strconv.Itoa(len(s.Cases)), strconv.Itoa(s.Passed), strconv.Itoa(s.Failed), strconv.Itoa(s.Skipped), formatting.Duration(s.Time()))
+	}
+	fmt.Println(infoBoldStyle.Render("SUITES:"))
+	fmt.Println(t)
+	fmt.Println()
+}
+
+func printSlowestTests(cases []jenkins.TestCase) {
+	t := reportTable("TEST", "STATUS", "TIME")
+	for _, c := range cases {
+		t.Row(c.FullName(), c.Status, formatting.Duration(c.Time()))
+	}
+	fmt.Println(infoBoldStyle.Render("SLOWEST TESTS:"))
+	fmt.Println(t)
+	fmt.Println()
+}
+
+func printTestTrends(runs []testRun, trends map[string]*testTrend) {
+	if len(trends) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(trends))
+	for name := range trends {
+		names = append(names, name)
+	}
+	// Newly failing tests first, then the longest failing, then the rest
+	sort.Slice(names, func(i, j int) bool {
+		a, b := trends[names[i]], trends[names[j]]
+		if a.New() != b.New() {
+			return a.New()
+		}
+		if a.Streak() != b.Streak() {
+			return a.Streak() > b.Streak()
+		}
+		return a.Name < b.Name
+	})
+
+	headers := []string{"TEST"}
+	for _, run := range runs {
+		headers = append(headers, "#"+run.Build)
+	}
+	t := reportTable(headers...)
+	marks := map[string]string{jenkins.TestFailed: "✗", jenkins.TestPassed: "✓", jenkins.TestSkipped: "skip", "": "-"}
+	for _, name := range names {
+		row := []string{name}
+		if trends[name].New() {
+			row[0] = "NEW " + name
+		}
+		for _, r := range trends[name].Results {
+			row = append(row, marks[r])
+		}
+		t.Row(row...)
+	}
+	fmt.Println(infoBoldStyle.Render("FAILURES ACROSS BUILDS:"))
+	fmt.Println(t)
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"slices"
+	"testing"
+)
+
+func testCase(class, name, status string, seconds float64) jenkins.TestCase {
+	return jenkins.TestCase{ClassName: class, Name: name, Status: status, Duration: seconds}
+}
+
+func testReportOf(cases ...jenkins.TestCase) *jenkins.TestReport {
+	return &jenkins.TestReport{Suites: []jenkins.TestSuite{{Name: "suite", Cases: cases}}}
+}
+
+func TestTestTrends(t *testing.T) {
+	runs := []testRun{
+		{Build: "12", Report: testReportOf(
+			testCase("A", "new", jenkins.TestRegression, 1),
+			testCase("A", "old", jenkins.TestFailed, 1),
+			testCase("A", "fixed", jenkins.TestFixed, 1),
+		)},
+		{Build: "11", Report: testReportOf(
+			testCase("A", "new", jenkins.TestPassed, 1),
+			testCase("A", "old", jenkins.TestFailed, 1),
+			testCase("A", "fixed", jenkins.TestFailed, 1),
+		)},
+		{Build: "10", Report: testReportOf(
+			testCase("A", "old", jenkins.TestFailed, 1),
+		)},
+	}
+
+	trends := testTrends(runs)
+	if len(trends) != 3 {
+		t.Fatalf("got %d trends, want 3", len(trends))
+	}
+
+	if n := trends["A.new"]; !n.New() || n.Streak() != 1 || !slices.Equal(n.Results, []string{jenkins.TestFailed, jenkins.TestPassed, ""}) {
+		t.Errorf("A.new = %+v", n)
+	}
+	if o := trends["A.old"]; o.New() || o.Streak() != 3 {
+		t.Errorf("A.old = %+v, want a 3 build streak", o)
+	}
+	if f := trends["A.fixed"]; f.New() || f.Streak() != 0 {
+		t.Errorf("A.fixed = %+v", f)
+	}
+
+	c := testCase("A", "old", jenkins.TestFailed, 1)
+	if got := failingFor(c, trends["A.old"]); got != "failing in the last 3 build(s)" {
+		t.Errorf("failingFor() = %q", got)
+	}
+	c.Age, c.FailedSince = 4, 8
+	if got := failingFor(c, nil); got != "failing for 4 builds, since #8" {
+		t.Errorf("failingFor() without history = %q", got)
+	}
+}
+
+func TestSuiteCountsAndSlowest(t *testing.T) {
+	report := &jenkins.TestReport{Suites: []jenkins.TestSuite{
+		{Name: "b", Cases: []jenkins.TestCase{testCase("b", "1", jenkins.TestPassed, 3), testCase("b", "2", jenkins.TestSkipped, 0)}},
+		{Name: "a", Cases: []jenkins.TestCase{testCase("a", "1", jenkins.TestFailed, 5), testCase("a", "2", jenkins.TestPassed, 1)}},
+		{Name: "c", Cases: []jenkins.TestCase{testCase("c", "1", jenkins.TestPassed, 0.5)}},
+	}}
+
+	counts := suiteCounts(report)
+	if counts[0].Name != "a" || counts[0].Failed != 1 || counts[1].Name != "b" || counts[1].Skipped != 1 || counts[1].Passed != 1 {
+		t.Errorf("suiteCounts() = %+v", counts)
+	}
+
+	slowest := slowestTests(report, 2)
+	if len(slowest) != 2 || slowest[0].FullName() != "a.1" || slowest[1].FullName() != "b.1" {
+		t.Errorf("slowestTests() = %+v", slowest)
+	}
+	if len(slowestTests(report, 10)) != 5 {
+		t.Error("slowestTests() should return every test when there are fewer than n")
+	}
+}
+
+func TestStackExcerpt(t *testing.T) {
+	trace := "Error\n\tat a\n\tat b\n\tat c\n"
+	if got := stackExcerpt(trace, 2); !slices.Equal(got, []string{"Error", "\tat a", "... (2 more lines)"}) {
+		t.Errorf("stackExcerpt(2) = %q", got)
+	}
+	if got := stackExcerpt(trace, 0); len(got) != 4 {
+		t.Errorf("stackExcerpt(0) = %q", got)
+	}
+	if got := stackExcerpt("", 2); got != nil {
+		t.Errorf("stackExcerpt(empty) = %q", got)
+	}
+}
@@ -0,0 +1,240 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"jenkins/internal/formatting"
+	"jenkins/internal/jenkins"
+	"jenkins/internal/util"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// testSlowdownMinDelta is the smallest change in average time reported as a
+// slowdown, so that millisecond tests do not show up on noise alone
+const testSlowdownMinDelta = 500 * time.Millisecond
+
+var (
+	testTimingFilter    []string
+	testTimingAnd       bool
+	testTimingLimit     int
+	testTimingMax       int
+	testTimingSuites    bool
+	testTimingRecent    int
+	testTimingThreshold float64
+)
+
+func init() {
+	rootCmd.AddCommand(testTimingCmd)
+
+	testTimingCmd.Flags().StringArrayVarP(&testTimingFilter, "filter", "f", []string{}, "Filter tests or suites by name (case insensitive)")
+	testTimingCmd.Flags().BoolVarP(&testTimingAnd, "and", "", false, "Combine filters with 'and' instead of 'or'")
+	testTimingCmd.Flags().IntVarP(&testTimingLimit, "limit", "l", 10, "Number of recent builds to read")
+	testTimingCmd.Flags().IntVarP(&testTimingMax, "max", "n", 20, "Maximum number of tests to print (0 for all)")
+	testTimingCmd.Flags().BoolVarP(&testTimingSuites, "suites", "", false, "Aggregate per suite instead of per test")
+	testTimingCmd.Flags().IntVarP(&testTimingRecent, "recent", "r", 3, "Number of newest builds compared with the older ones to find slowdowns")
+	testTimingCmd.Flags().Float64VarP(&testTimingThreshold, "threshold", "t", 25, "Report tests whose recent average is at least this many percent slower")
+}
+
+var testTimingCmd = &cobra.Command{
+	Use:   "test-timing",
+	Short: "Summarize test times across recent successful builds",
+	Long: `Read the test reports of the most recent successful builds and summarize the
+time of each test, or of each suite with --suites: average, minimum, maximum
+and standard deviation, slowest first.
+
+Tests whose average over the newest --recent builds is --threshold percent
+slower than over the older builds are listed separately.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pipeline := viper.GetString("pipeline")
+
+		var lcFilter []string
+		for _, f := range testTimingFilter {
+			verbose("Appending filter to list [%s]", strings.ToLower(f))
+			lcFilter = append(lcFilter, strings.ToLower(f))
+		}
+
+		builds, err := jenkinsClient.GetBuilds(pipeline, testTimingLimit)
+		if err != nil {
+			return err
+		}
+		successful := []jenkins.WorkflowRun{}
+		for _, b := range builds {
+			if b.Building || b.Result != "SUCCESS" {
+				verbose("Build has a status other than SUCCESS [%s][%s]", b.ID, b.Result)
+				continue
+			}
+			successful = append(successful, b)
+		}
+		if len(successful) == 0 {
+			return fmt.Errorf("no successful builds found on %s", pipeline)
+		}
+
+		fmt.Printf("Reading test reports of %d build(s) on [%s]...\n", len(successful), pipeline)
+		reports := fetchTestReports(pipeline, successful)
+
+		// GetBuilds is newest first, timings are collected oldest first
+		runs := []testRun{}
+		for i := len(successful) - 1; i >= 0; i-- {
+			if report, ok := reports[successful[i].ID]; ok {
+				runs = append(runs, testRun{Build: successful[i].ID, Report: report})
+			}
+		}
+
+		timings := collectTestTimings(runs, testTimingSuites, lcFilter, testTimingAnd)
+		verbose("Ended with [%d] tests", len(timings))
+		if len(timings) == 0 {
+			return errors.New(errStyle.Render("No matching tests found in successful builds"))
+		}
+
+		cmd.SilenceUsage = true
+
+		kind := "TEST"
+		if testTimingSuites {
+			kind = "SUITE"
+		}
+		fmt.Println()
+		printTestTimings(kind, timings, testTimingMax)
+		fmt.Println(grayStyle.Render(fmt.Sprintf("Times for %d %s(s) across %d successful build(s)", len(timings), strings.ToLower(kind), len(runs))))
+		fmt.Println()
+		printTestSlowdowns(kind, testSlowdowns(timings, testTimingRecent, testTimingThreshold), testTimingRecent)
+		return nil
+	},
+}
+
+// testTiming is the time a test or suite took in each build it ran in
+type testTiming struct {
+	Name string
+	// Seconds has one entry per build, oldest first
+	Seconds []float64
+}
+
+func (t *testTiming) Avg() time.Duration    { return seconds(util.Avg(t.Seconds)) }
+func (t *testTiming) Min() time.Duration    { return seconds(slices.Min(t.Seconds)) }
+func (t *testTiming) Max() time.Duration    { return seconds(slices.Max(t.Seconds)) }
+func (t *testTiming) StdDev() time.Duration { return seconds(util.StdDev(t.Seconds)) }
+
+// Slowdown compares the average time of the newest recent runs with that of
+// the older ones. ok is false if there are no older runs to compare with.
+func (t *testTiming) Slowdown(recent int) (before, after time.Duration, ok bool) {
+	if recent <= 0 || len(t.Seconds) <= recent {
+		return 0, 0, false
+	}
+	split := len(t.Seconds) - recent
+	return seconds(util.Avg(t.Seconds[:split])), seconds(util.Avg(t.Seconds[split:])), true
+}
+
+// seconds converts the fractional seconds of a test report to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// collectTestTimings collects the time of every test, or every suite if
+// suites is set, whose name matches the filters. Skipped tests are left
+// out. The result is sorted by average time, slowest first.
+func collectTestTimings(runs []testRun, suites bool, lcFilter []string, and bool) []*testTiming {
+	timings := map[string]*testTiming{}
+	add := func(name string, duration float64) {
+		if !matchesFilters(name, lcFilter, and) {
+			return
+		}
+		if timings[name] == nil {
+			timings[name] = &testTiming{Name: name}
+		}
+		timings[name].Seconds = append(timings[name].Seconds, duration)
+	}
+
+	for _, run := range runs {
+		for _, s := range run.Report.AllSuites() {
+			if suites {
+				add(s.Name, s.Duration)
+				continue
+			}
+			for _, c := range s.Cases {
+				if c.Skipped || c.Status == jenkins.TestSkipped {
+					continue
+				}
+				add(c.FullName(), c.Duration)
+			}
+		}
+	}
+
+	sorted := make([]*testTiming, 0, len(timings))
+	for _, t := range timings {
+		sorted = append(sorted, t)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Avg() != sorted[j].Avg() {
+			return sorted[i].Avg() > sorted[j].Avg()
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// testSlowdown is a test that got slower in the recent builds
+type testSlowdown struct {
+	*testTiming
+	Before, After time.Duration
+}
+
+// Percent returns how much slower the test got, in percent
+func (s testSlowdown) Percent() float64 {
+	if s.Before == 0 {
+		return 0
+	}
+	return float64(s.After-s.Before) * 100 / float64(s.Before)
+}
+
+// testSlowdowns returns the tests whose recent average is at least threshold
+// percent and testSlowdownMinDelta slower than before, largest change first
+func testSlowdowns(timings []*testTiming, recent int, threshold float64) []testSlowdown {
+	slower := []testSlowdown{}
+	for _, t := range timings {
+		before, after, ok := t.Slowdown(recent)
+		if !ok || after-before < testSlowdownMinDelta {
+			continue
+		}
+		s := testSlowdown{testTiming: t, Before: before, After: after}
+		if s.Percent() >= threshold {
+			slower = append(slower, s)
+		}
+	}
+	sort.SliceStable(slower, func(i, j int) bool { return slower[i].Percent() > slower[j].Percent() })
+	return slower
+}
+
+func printTestTimings(kind string, timings []*testTiming, max int) {
+	if max > 0 && len(timings) > max {
+		timings = timings[:max]
+	}
+
+	t := reportTable(kind, "RUNS", "AVG", "MIN", "MAX", "STDDEV")
+	for _, tt := range timings {
+		t.Row(tt.Name, strconv.Itoa(len(tt.Seconds)), formatting.Duration(tt.Avg()), formatting.Duration(tt.Min()),
+			formatting.Duration(tt.Max()), formatting.Duration(tt.StdDev()))
+	}
+	fmt.Println(t)
+}
+
+func printTestSlowdowns(kind string, slower []testSlowdown, recent int) {
+	if len(slower) == 0 {
+		fmt.Println(successStyle.Render(fmt.Sprintf("✓ Nothing got slower in the last %d build(s)", recent)))
+		return
+	}
+
+	t := reportTable(kind, "BEFORE", "RECENT", "CHANGE")
+	for _, s := range slower {
+		t.Row(s.Name, formatting.Duration(s.Before), formatting.Duration(s.After),
+			orangeStyle.Render(fmt.Sprintf("%s (+%.0f%%)", formatDelta(s.After-s.Before), s.Percent())))
+	}
+	fmt.Println(infoBoldStyle.Render(fmt.Sprintf("SLOWER IN THE LAST %d BUILD(S):", recent)))
+	fmt.Println(t)
+}
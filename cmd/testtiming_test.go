@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"jenkins/internal/jenkins"
+	"testing"
+	"time"
+)
+
+func TestMatchesFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []string
+		and     bool
+		want    bool
+	}{
+		{name: "Unit Tests", filters: nil, want: true},
+		{name: "Unit Tests", filters: []string{"unit"}, want: true},
+		{name: "Unit Tests", filters: []string{"deploy", "tests"}, want: true},
+		{name: "Unit Tests", filters: []string{"deploy", "tests"}, and: true, want: false},
+		{name: "Unit Tests", filters: []string{"unit", "tests"}, and: true, want: true},
+		{name: "Unit Tests", filters: []string{"deploy"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesFilters(tt.name, tt.filters, tt.and); got != tt.want {
+			t.Errorf("matchesFilters(%q, %q, %v) = %v, want %v", tt.name, tt.filters, tt.and, got, tt.want)
+		}
+	}
+}
+
+func TestCollectTestTimings(t *testing.T) {
+	run := func(build string, slow float64) testRun {
+		return testRun{Build: build, Report: &jenkins.TestReport{Suites: []jenkins.TestSuite{
+			{Name: "api", Duration: slow + 1, Cases: []jenkins.TestCase{
+				testCase("api", "slow", jenkins.TestPassed, slow),
+				testCase("api", "fast", jenkins.TestPassed, 1),
+				testCase("api", "skipped", jenkins.TestSkipped, 0),
+			}},
+			{Name: "db", Duration: 2, Cases: []jenkins.TestCase{testCase("db", "read", jenkins.TestPassed, 2)}},
+		}}}
+	}
+	runs := []testRun{run("1", 10), run("2", 10), run("3", 10), run("4", 20), run("5", 20)}
+
+	timings := collectTestTimings(runs, false, nil, false)
+	if len(timings) != 3 {
+		t.Fatalf("got %d timings, want 3 without the skipped test", len(timings))
+	}
+	slow := timings[0]
+	if slow.Name != "api.slow" || slow.Avg() != 14*time.Second || slow.Min() != 10*time.Second || slow.Max() != 20*time.Second {
+		t.Errorf("slowest = %s avg %v min %v max %v", slow.Name, slow.Avg(), slow.Min(), slow.Max())
+	}
+	if slow.StdDev() == 0 || timings[1].StdDev() != 0 {
+		t.Errorf("StdDev() = %v, %v", slow.StdDev(), timings[1].StdDev())
+	}
+
+	if filtered := collectTestTimings(runs, false, []string{"api", "fast"}, true); len(filtered) != 1 || filtered[0].Name != "api.fast" {
+		t.Errorf("filtered timings = %+v", filtered)
+	}
+	if suites := collectTestTimings(runs, true, nil, false); len(suites) != 2 || suites[0].Name != "api" {
+		t.Errorf("suite timings = %+v", suites)
+	}
+
+	slower := testSlowdowns(timings, 2, 25)
+	if len(slower) != 1 || slower[0].Name != "api.slow" || slower[0].Before != 10*time.Second || slower[0].Percent() != 100 {
+		t.Fatalf("testSlowdowns() = %+v", slower)
+	}
+	if slower := testSlowdowns(timings, 5, 25); len(slower) != 0 {
+		t.Errorf("testSlowdowns() without older builds = %+v", slower)
+	}
+}
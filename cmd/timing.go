@@ -36,7 +36,7 @@ var (
 	useAnd  bool
 	longest bool
 
-	jobRE = regexp.MustCompile(`^/job/[^/]+/(\d+)/`)
+	jobRE = regexp.MustCompile(`^(?:/job/[^/]+)+/(\d+)/`)
 )
 
 var (
@@ -74,39 +74,7 @@ var timingCmd = &cobra.Command{
 			lcFilter = append(lcFilter, strings.ToLower(f))
 		}
 
-		stageMap := map[string][]jenkins.Stage{}
-		successfulJobs := 0
-		for _, job := range jobs {
-			if job.Status != "SUCCESS" {
-				verbose("Job has a status other than SUCCESS [%s][%s]", job.ID, job.Status)
-				continue
-			}
-
-			successfulJobs++
-			for _, stage := range job.Stages {
-				if len(lcFilter) > 0 {
-					var found *bool
-					for _, f := range lcFilter {
-						if strings.Contains(strings.ToLower(stage.Name), f) {
-							vVerbose("Stage matched filter [%s][%s]", stage.Name, f)
-							if !useAnd {
-								found = util.Ptr(true)
-								break
-							} else if found == nil {
-								found = util.Ptr(true)
-							}
-						} else {
-							found = util.Ptr(false)
-						}
-					}
-					if found == nil || !*found {
-						vVerbose("Stage did not match any filter [%s][%v]", stage.Name, useAnd)
-						continue
-					}
-				}
-				stageMap[stage.Name] = append(stageMap[stage.Name], stage)
-			}
-		}
+		stageMap, _, successfulJobs := successfulStages(jobs, lcFilter, useAnd)
 
 		verbose("Ended with [%d] stages", len(stageMap))
 
@@ -125,15 +93,75 @@ var timingCmd = &cobra.Command{
 	},
 }
 
-func printStageTable(stageMap map[string][]jenkins.Stage) {
-	avgStage := []pair[stageTime]{}
+// successfulStages groups the stages of the successful jobs that match the
+// filters by name. It also returns the names in the order they first appear
+// and the number of successful jobs.
+func successfulStages(jobs []jenkins.Job, lcFilter []string, and bool) (map[string][]jenkins.Stage, []string, int) {
+	stageMap := map[string][]jenkins.Stage{}
+	order := []string{}
+	successfulJobs := 0
+	for _, job := range jobs {
+		if job.Status != "SUCCESS" {
+			verbose("Job has a status other than SUCCESS [%s][%s]", job.ID, job.Status)
+			continue
+		}
+
+		successfulJobs++
+		for _, stage := range job.Stages {
+			if !matchesFilters(stage.Name, lcFilter, and) {
+				vVerbose("Stage did not match any filter [%s][%v]", stage.Name, and)
+				continue
+			}
+			if _, ok := stageMap[stage.Name]; !ok {
+				order = append(order, stage.Name)
+			}
+			stageMap[stage.Name] = append(stageMap[stage.Name], stage)
+		}
+	}
+	return stageMap, order, successfulJobs
+}
+
+// stageTimes returns the average, minimum and maximum duration of each stage
+// in milliseconds
+func stageTimes(stageMap map[string][]jenkins.Stage) map[string]stageTime {
+	times := map[string]stageTime{}
 	for stage, stages := range stageMap {
 		durations := sliceutils.Pluck(stages, func(s jenkins.Stage) *int {
 			return &s.Duration
 		})
 		vVerbose("Stage [%s]", stage)
 		vVerbose("  %+v", durations)
-		avgStage = append(avgStage, pair[stageTime]{stage, stageTime{util.Avg(durations), slices.Min(durations), slices.Max(durations)}})
+		times[stage] = stageTime{util.Avg(durations), slices.Min(durations), slices.Max(durations)}
+	}
+	return times
+}
+
+// matchesFilters reports whether name contains any of the lower case
+// filters, or all of them if and is set. Every name matches an empty list.
+func matchesFilters(name string, lcFilter []string, and bool) bool {
+	if len(lcFilter) == 0 {
+		return true
+	}
+	lcName := strings.ToLower(name)
+	for _, f := range lcFilter {
+		matched := strings.Contains(lcName, f)
+		if matched {
+			vVerbose("Matched filter [%s][%s]", name, f)
+		}
+		if matched && !and {
+			return true
+		}
+		if !matched && and {
+			return false
+		}
+	}
+	return and
+}
+
+func printStageTable(stageMap map[string][]jenkins.Stage) {
+	avgStage := []pair[stageTime]{}
+	for stage, times := range stageTimes(stageMap) {
+		avgStage = append(avgStage, pair[stageTime]{stage, times})
 	}
 	sort.Slice(avgStage, func(i, j int) bool {
 		return avgStage[i].Value.Avg > avgStage[j].Value.Avg
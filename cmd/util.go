@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -104,3 +107,21 @@ func (p *URLPoller) Stop() {
 	default:
 	}
 }
+
+// stdinReader is shared by all prompts so buffered input isn't lost between them
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question on out and reads the answer from in.
+// Anything other than "y" or "yes" counts as no.
+func confirm(in *bufio.Reader, out io.Writer, prompt string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
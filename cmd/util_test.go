@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -61,3 +64,33 @@ func TestSpawnSuccess(t *testing.T) {
 		t.Errorf("command failed: %v", err)
 	}
 }
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+		{"y", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := confirm(bufio.NewReader(strings.NewReader(tt.input)), &out, "Continue?")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("confirm(%q) = %v, want %v", tt.input, got, tt.expected)
+			}
+			if !strings.Contains(out.String(), "Continue? [y/N]") {
+				t.Errorf("prompt not written, got %q", out.String())
+			}
+		})
+	}
+}
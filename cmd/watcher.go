@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jenkins/internal/jenkins"
+	"jenkins/internal/notify"
+	"jenkins/internal/watcher"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// WatcherStartTimeout is how long to wait for a newly spawned watcher to answer
+	WatcherStartTimeout = 5 * time.Second
+	// WatcherIdleTimeout is how long the watcher lingers with nothing to watch
+	WatcherIdleTimeout = time.Minute
+	// WatcherMaxFailures is how many checks of a build may fail in a row before
+	// the watcher gives up on it, about five minutes at MonitorPollInterval
+	WatcherMaxFailures = 60
+)
+
+func init() {
+	viper.SetDefault("watcher.dir", "")
+}
+
+// watcherPaths locates the watcher's files, in watcher.dir or ~/.jenkins-watcher
+func watcherPaths() (watcher.Paths, error) {
+	dir := viper.GetString("watcher.dir")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return watcher.Paths{}, err
+		}
+		dir = filepath.Join(home, ".jenkins-watcher")
+	}
+	return watcher.Paths{Dir: dir}, nil
+}
+
+// watcherClient returns a client for the watcher, without starting it
+func watcherClient() (*watcher.Client, error) {
+	paths, err := watcherPaths()
+	if err != nil {
+		return nil, err
+	}
+	return &watcher.Client{Socket: paths.Socket()}, nil
+}
+
+// ensureWatcher returns a client for the watcher, starting a detached watcher
+// process if none is running
+func ensureWatcher() (*watcher.Client, error) {
+	paths, err := watcherPaths()
+	if err != nil {
+		return nil, err
+	}
+	client := &watcher.Client{Socket: paths.Socket()}
+	if _, err := client.List(); err == nil {
+		return client, nil
+	}
+
+	if err := os.MkdirAll(paths.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(paths.Log(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer logFile.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	// Pass the resolved connection settings on, since the watcher can't
+	// prompt for a credentials passphrase. The API key goes over stdin so
+	// neither it nor the passphrase shows up in the watcher's environment.
+	daemon := exec.Command(exe, "monitor", "daemon")
+	daemon.Env = append(notify.Environ(),
+		fmt.Sprintf("%v=%v", childEnv, 1),
+		"JENKINS_HOST="+clientConfig.Host,
+		"JENKINS_USER="+clientConfig.User,
+	)
+	if cfg := viper.ConfigFileUsed(); cfg != "" {
+		daemon.Args = append(daemon.Args, "--config", cfg)
+	}
+	daemon.Stdout, daemon.Stderr = logFile, logFile
+	daemon.SysProcAttr = detachAttr()
+	stdin, err := daemon.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	verbose("Starting watcher [%v]", daemon.Args)
+	if err := daemon.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start watcher: %w", err)
+	}
+	_, err = io.WriteString(stdin, clientConfig.APIKey)
+	stdin.Close()
+	daemon.Process.Release()
+	if err != nil {
+		return nil, fmt.Errorf("failed to pass the API key to the watcher: %w", err)
+	}
+
+	deadline := time.Now().Add(WatcherStartTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		if _, err := client.List(); err == nil {
+			return client, nil
+		}
+	}
+	return nil, fmt.Errorf("watcher did not start; see %s", paths.Log())
+}
+
+// watchBuilds hands builds on pipeline to the watcher, along with the
+// notifiers selected with --notify
+func watchBuilds(pipeline string, buildIDs ...string) error {
+	client, err := ensureWatcher()
+	if err != nil {
+		return err
+	}
+
+	builds := make([]watcher.Build, len(buildIDs))
+	for i, id := range buildIDs {
+		builds[i] = watcher.Build{Host: clientConfig.Host, Pipeline: pipeline, ID: id, Notify: notifyNames}
+	}
+	if _, err := client.Add(builds...); err != nil {
+		return fmt.Errorf("failed to register builds with the watcher: %w", err)
+	}
+
+	fmt.Printf("Watching build(s) %s on [%s] in the background (see: jenkins monitor list)\n", strings.Join(buildIDs, ", "), pipeline)
+	return nil
+}
+
+// readWatcherKey reads the API key ensureWatcher writes to a spawned
+// watcher's stdin into the key setting
+func readWatcherKey(r io.Reader) error {
+	apiKey, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read the API key: %w", err)
+	}
+	if len(apiKey) > 0 {
+		viper.Set("key", strings.TrimSpace(string(apiKey)))
+	}
+	return nil
+}
+
+// runWatcher runs the watcher daemon in this process until it stops
+func runWatcher() error {
+	paths, err := watcherPaths()
+	if err != nil {
+		return err
+	}
+
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+	notified := map[string]bool{}
+	server := &watcher.Server{
+		Host:        clientConfig.Host,
+		Paths:       paths,
+		Interval:    MonitorPollInterval,
+		IdleTimeout: WatcherIdleTimeout,
+		Log:         logger,
+		Check: func(b *watcher.Build) bool {
+			return checkWatchedBuild(b, notified, logger)
+		},
+	}
+
+	err = server.Run()
+	if errors.Is(err, watcher.ErrAlreadyRunning) {
+		logger.Printf("Another watcher is already running")
+		return nil
+	}
+	return err
+}
+
+// checkWatchedBuild polls a watched build for the watcher, sending
+// notifications when it finishes or waits for input. notified records the
+// input steps already reported. A build that is gone, or that can't be
+// reached WatcherMaxFailures times in a row, is no longer watched.
+func checkWatchedBuild(b *watcher.Build, notified map[string]bool, logger *log.Logger) bool {
+	build, err := jenkinsClient.GetBuildInfo(b.Pipeline, b.ID)
+	if errors.Is(err, jenkins.ErrNotFound) {
+		logger.Printf("Build %s on [%s] no longer exists, not watching it anymore", b.ID, b.Pipeline)
+		return true
+	}
+	if err != nil {
+		b.Failures++
+		if b.Failures >= WatcherMaxFailures {
+			logger.Printf("Giving up on build %s on [%s] after %d failed checks: %v", b.ID, b.Pipeline, b.Failures, err)
+			return true
+		}
+		logger.Printf("Failed to check build %s on [%s]: %v", b.ID, b.Pipeline, err)
+		b.Status = "unreachable"
+		return false
+	}
+	b.Failures = 0
+
+	notifiers, err := newNotifiers(b.Notify)
+	if err != nil {
+		logger.Printf("Skipping notifications for build %s on [%s]: %v", b.ID, b.Pipeline, err)
+	}
+
+	if !build.Building {
+		logger.Printf("Build %s [%s] on [%s] finished: %s", b.ID, build.DisplayName, b.Pipeline, build.Result)
+		if err := sendNotifications(notifiers, buildEvent(notify.EventFinished, b.Pipeline, build)); err != nil {
+			logger.Printf("Notification failed: %v", err)
+		}
+		return true
+	}
+
+	b.Status = "running"
+	if job, err := jenkinsClient.GetJobDetails(b.Pipeline, b.ID); err == nil {
+		if p := computeProgress(build, job, stageHistory{}, time.Now()); len(p.Current) > 0 {
+			b.Status = fmt.Sprintf("running [%s]", strings.Join(p.Current, ", "))
+		}
+	}
+
+	inputs, err := jenkinsClient.GetPendingInputs(b.Pipeline, b.ID)
+	if err != nil {
+		return false
+	}
+	for _, input := range inputs {
+		b.Status = "waiting for input"
+		key := b.Key() + "/" + input.ID
+		if notified[key] {
+			continue
+		}
+		notified[key] = true
+		logger.Printf("Build %s [%s] on [%s] is waiting for input: %s", b.ID, build.DisplayName, b.Pipeline, input.Message)
+		event := buildEvent(notify.EventInput, b.Pipeline, build)
+		event.Message = input.Message
+		if err := sendNotifications(notifiers, event); err != nil {
+			logger.Printf("Notification failed: %v", err)
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"io"
+	"jenkins/internal/jenkins"
+	"jenkins/internal/watcher"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWatcherPaths(t *testing.T) {
+	defer viper.Set("watcher.dir", "")
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	paths, err := watcherPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths.Dir != filepath.Join(home, ".jenkins-watcher") {
+		t.Errorf("default dir = %s", paths.Dir)
+	}
+
+	viper.Set("watcher.dir", "/tmp/w")
+	paths, err = watcherPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths.Socket() != "/tmp/w/watcher.sock" || paths.Log() != "/tmp/w/watcher.log" {
+		t.Errorf("paths = %s, %s", paths.Socket(), paths.Log())
+	}
+}
+
+func TestMonitorSubcommands(t *testing.T) {
+	for _, name := range []string{"list", "stop", "daemon"} {
+		cmd, _, err := rootCmd.Find([]string{"monitor", name})
+		if err != nil || cmd.Name() != name {
+			t.Errorf("monitor %s resolved to %v (%v)", name, cmd, err)
+		}
+	}
+
+	// Build IDs still go to monitor itself
+	cmd, args, err := rootCmd.Find([]string{"monitor", "1234"})
+	if err != nil || cmd != monitorCmd || len(args) != 1 {
+		t.Errorf("monitor 1234 resolved to %v %v (%v)", cmd.Name(), args, err)
+	}
+}
+
+func TestCheckWatchedBuildDropsDeadBuilds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/master/1/api/json":
+			http.NotFound(w, r)
+		default:
+			http.Error(w, "down", http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	oldClient := jenkinsClient
+	defer func() { jenkinsClient = oldClient }()
+	jenkinsClient = jenkins.NewClient(jenkins.Config{Host: server.URL, User: "test", APIKey: "test"})
+	logger := log.New(io.Discard, "", 0)
+
+	gone := &watcher.Build{Pipeline: "master", ID: "1"}
+	if !checkWatchedBuild(gone, map[string]bool{}, logger) {
+		t.Error("a build that no longer exists should not be watched anymore")
+	}
+
+	down := &watcher.Build{Pipeline: "master", ID: "2"}
+	for i := 1; i < WatcherMaxFailures; i++ {
+		if checkWatchedBuild(down, map[string]bool{}, logger) {
+			t.Fatalf("unreachable build dropped after %d failures", i)
+		}
+	}
+	if down.Status != "unreachable" || down.Failures != WatcherMaxFailures-1 {
+		t.Errorf("unreachable build = %+v", down)
+	}
+	if !checkWatchedBuild(down, map[string]bool{}, logger) {
+		t.Errorf("unreachable build still watched after %d failures", WatcherMaxFailures)
+	}
+}
+
+func TestReadWatcherKey(t *testing.T) {
+	defer viper.Set("key", "")
+
+	if err := readWatcherKey(strings.NewReader("s3cret\n")); err != nil || viper.GetString("key") != "s3cret" {
+		t.Errorf("readWatcherKey() set key %q, %v", viper.GetString("key"), err)
+	}
+	// Nothing on stdin leaves the configured key alone
+	if err := readWatcherKey(strings.NewReader("")); err != nil || viper.GetString("key") != "s3cret" {
+		t.Errorf("readWatcherKey() with no input set key %q, %v", viper.GetString("key"), err)
+	}
+}
@@ -0,0 +1,59 @@
+package extract
+
+import "regexp"
+
+// Category is the kind of failure a stage log shows
+type Category string
+
+// Failure categories, in the order they are checked
+const (
+	CategoryAborted           Category = "aborted by user"
+	CategoryAgentDisconnected Category = "agent disconnected"
+	CategoryTimeout           Category = "timeout"
+	CategoryInfrastructure    Category = "infrastructure"
+	CategoryCompileError      Category = "compile error"
+	CategoryTestFailure       Category = "test failure"
+	CategoryUnknown           Category = "unknown"
+)
+
+// signature is a log pattern that identifies a failure category
+type signature struct {
+	category Category
+	regexp   *regexp.Regexp
+}
+
+// signatures are checked in order, so a lost agent that also fails tests is
+// reported as the agent problem
+var signatures = []signature{
+	{CategoryAborted, regexp.MustCompile(`\bAborted by \S+|\bRejected by \S+|FlowInterruptedException.*UserInterruption`)},
+	{CategoryAgentDisconnected, regexp.MustCompile(`ChannelClosedException|RequestAbortedException|Agent \S+ was deleted|Cannot contact \S+: |Remote call on \S+ failed|missing workspace|agent went offline|Connection was broken`)},
+	{CategoryTimeout, regexp.MustCompile(`Timeout has been exceeded|Cancelling nested steps due to timeout|\btimed out after\b|panic: test timed out|context deadline exceeded|TimeoutException`)},
+	{CategoryInfrastructure, regexp.MustCompile(`(?i)no space left on device|out of memory|OOMKilled|Killed process \d+|exit code 137\b|could not resolve host|connection refused|connection reset|ECONNRESET|ETIMEDOUT|50[234] (Bad Gateway|Service Unavailable|Gateway Time-?out)|docker: Error response from daemon|toomanyrequests|Read-only file system`)},
+	// Go compiler errors carry a column, unlike the file:line of t.Errorf output
+	{CategoryCompileError, regexp.MustCompile(`\S+\.go:\d+:\d+: |\S+:\d+(:\d+)?: (fatal )?error:|\berror (TS|CS)\d+:|\berror\[E\d+\]|COMPILATION ERROR|> Task \S*compile\S* FAILED|\bSyntaxError\b`)},
+	{CategoryTestFailure, regexp.MustCompile(`--- FAIL: |\bFAIL\s+\S+\s+[\d.]+s\b|\b[1-9]\d* (failing|failed)\b|\bFAILED \S+::|\bAssertionError\b|Tests run: \d+, Failures: [1-9]|There (were|was) \d+ test failures?|Tests:\s+[1-9]\d* failed`)},
+}
+
+// Classification is the category of a failure and the line that gave it away
+type Classification struct {
+	Category Category
+	// Line is the zero-based index of the matching line, or -1
+	Line int
+}
+
+// Classify returns the category of the first signature that matches any of
+// lines. Stages Jenkins reports as aborted without a recognisable reason are
+// classified as aborted.
+func Classify(status string, lines []string) Classification {
+	for _, s := range signatures {
+		for i, line := range lines {
+			if s.regexp.MatchString(line) {
+				return Classification{Category: s.category, Line: i}
+			}
+		}
+	}
+	if status == "ABORTED" {
+		return Classification{Category: CategoryAborted, Line: -1}
+	}
+	return Classification{Category: CategoryUnknown, Line: -1}
+}
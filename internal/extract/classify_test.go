@@ -0,0 +1,43 @@
+package extract
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		lines  []string
+		want   Category
+	}{
+		{"aborted", "ABORTED", []string{"Sleeping", "Aborted by alice"}, CategoryAborted},
+		{"aborted without reason", "ABORTED", []string{"Sleeping"}, CategoryAborted},
+		{"agent", "FAILED", []string{"--- FAIL: TestX", "hudson.remoting.ChannelClosedException: Channel \"hudson.remoting.Channel@1:agent-3\": Remote call failed"}, CategoryAgentDisconnected},
+		{"timeout", "FAILED", []string{"--- FAIL: TestSlow", "panic: test timed out after 10m0s"}, CategoryTimeout},
+		{"timeout only", "FAILED", []string{"Cancelling nested steps due to timeout"}, CategoryTimeout},
+		{"disk", "FAILED", []string{"cp: write error: No space left on device"}, CategoryInfrastructure},
+		{"oom", "FAILED", []string{"script returned exit code 137"}, CategoryInfrastructure},
+		{"compile", "FAILED", []string{"cmd/root.go:12:2: undefined: foo", "FAIL\tjenkins/cmd [build failed]"}, CategoryCompileError},
+		{"go test", "FAILED", []string{
+			"=== RUN   TestParse",
+			"--- FAIL: TestParse (0.00s)",
+			"    parse_test.go:12: Parse() = 1, want 2",
+			"FAIL",
+			"FAIL\tjenkins/internal/parse\t0.004s",
+		}, CategoryTestFailure},
+		{"go test compile", "FAILED", []string{"# jenkins/internal/parse [jenkins/internal/parse.test]", "internal/parse/parse_test.go:8:2: undefined: bar"}, CategoryCompileError},
+		{"maven tests", "FAILED", []string{"Tests run: 12, Failures: 2, Errors: 0"}, CategoryTestFailure},
+		{"jest", "FAILED", []string{"Tests:       3 failed, 10 passed"}, CategoryTestFailure},
+		{"unknown", "FAILED", []string{"script returned exit code 1"}, CategoryUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.status, tt.lines); got.Category != tt.want {
+				t.Errorf("Classify() = %s, want %s", got.Category, tt.want)
+			}
+		})
+	}
+
+	if c := Classify("FAILED", []string{"ok", "No space left on device"}); c.Line != 1 {
+		t.Errorf("Classify() line = %d, want 1", c.Line)
+	}
+}
@@ -0,0 +1,64 @@
+package extract
+
+import (
+	"fmt"
+	"sort"
+)
+
+// OmittedMarker formats the line that stands in for n omitted log lines
+func OmittedMarker(n int) string {
+	return fmt.Sprintf("[... %d lines omitted ...]", n)
+}
+
+// Excerpt picks at most n lines of a log, preferring the lines that matched a
+// pattern, then the rest of their snippets, then the end of the log and
+// finally its start. The lines are returned in log order, with an
+// OmittedMarker wherever lines were skipped. n <= 0 returns the whole log.
+func Excerpt(lines []string, snippets []Snippet, n int) []string {
+	if n <= 0 || len(lines) <= n {
+		return lines
+	}
+
+	keep := map[int]bool{}
+	add := func(i int) {
+		if len(keep) < n && i >= 0 && i < len(lines) {
+			keep[i] = true
+		}
+	}
+	for _, s := range snippets {
+		for _, m := range s.Matches {
+			add(m.Line)
+		}
+	}
+	for _, s := range snippets {
+		for i := s.Start; i < s.End(); i++ {
+			add(i)
+		}
+	}
+	for i := len(lines) - 1; len(keep) < n && i >= len(lines)-n; i-- {
+		add(i)
+	}
+	for i := 0; len(keep) < n; i++ {
+		add(i)
+	}
+
+	indexes := make([]int, 0, len(keep))
+	for i := range keep {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	out := []string{}
+	next := 0
+	for _, i := range indexes {
+		if i > next {
+			out = append(out, OmittedMarker(i-next))
+		}
+		out = append(out, lines[i])
+		next = i + 1
+	}
+	if next < len(lines) {
+		out = append(out, OmittedMarker(len(lines)-next))
+	}
+	return out
+}
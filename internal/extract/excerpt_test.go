@@ -0,0 +1,33 @@
+package extract
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExcerpt(t *testing.T) {
+	re, _ := Compile("boom", `BOOM`, 1, 1)
+	lines := strings.Split(logLines(20, map[int]string{5: "BOOM"}), "\n")
+	snippets := Extractor{Patterns: []Pattern{re}}.ExtractLines(lines)
+
+	got := Excerpt(lines, snippets, 5)
+	want := []string{OmittedMarker(4), "line 4", "BOOM", "line 6", OmittedMarker(11), "line 18", "line 19"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Excerpt(5) = %q, want %q", got, want)
+	}
+
+	// the matched line wins when there is only room for one
+	if got := Excerpt(lines, snippets, 1); !slices.Equal(got, []string{OmittedMarker(5), "BOOM", OmittedMarker(14)}) {
+		t.Errorf("Excerpt(1) = %q", got)
+	}
+
+	// without snippets the end of the log is kept
+	if got := Excerpt(lines, nil, 2); !slices.Equal(got, []string{OmittedMarker(18), "line 18", "line 19"}) {
+		t.Errorf("Excerpt(nil, 2) = %q", got)
+	}
+
+	if got := Excerpt(lines, snippets, 0); len(got) != 20 {
+		t.Errorf("Excerpt(0) returned %d lines, want all 20", len(got))
+	}
+}
@@ -0,0 +1,167 @@
+// Package extract finds the lines that explain a failure in a build log.
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a named regular expression for an error line, with the number of
+// lines of context to keep around each match
+type Pattern struct {
+	Name   string
+	Regexp *regexp.Regexp
+	Before int
+	After  int
+}
+
+// Compile builds a pattern from a regular expression
+func Compile(name, expr string, before, after int) (Pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Pattern{}, fmt.Errorf("pattern %s: %w", name, err)
+	}
+	return Pattern{Name: name, Regexp: re, Before: before, After: after}, nil
+}
+
+// builtins are the default patterns as name, expression, lines before and
+// lines after. Expressions are unanchored so timestamped logs still match.
+var builtins = []struct {
+	name, expr    string
+	before, after int
+}{
+	{"exit-code", `script returned exit code [1-9]\d*`, 5, 0},
+	{"oom", `(?i)\bout of memory\b|OOMKilled|java\.lang\.OutOfMemoryError|Killed process \d+|exit code 137\b`, 3, 3},
+	{"go-compile", `\S+\.go:\d+(:\d+)?: `, 1, 2},
+	{"go-test", `--- FAIL: |\bFAIL\s+\S+\s+[\d.]+s\b|\bpanic: `, 2, 8},
+	{"compiler", `\S+:\d+(:\d+)?: (fatal )?error:|\berror (TS|CS)\d+:|\berror\[E\d+\]`, 1, 3},
+	{"gradle", `\* What went wrong:|> Task \S+ FAILED|FAILURE: Build failed`, 1, 6},
+	{"maven", `\[ERROR\] |BUILD FAILURE|Tests run: \d+, Failures: [1-9]`, 0, 2},
+	{"npm", `\bnpm (ERR!|error) |ERR_PNPM_|\berror Command failed with exit code`, 0, 3},
+	{"test-failure", `\b[1-9]\d* (failing|failed)\b|\bFAILED \S+::|\bAssertionError\b|Tests:\s+[1-9]\d* failed`, 2, 6},
+	{"exception", `Traceback \(most recent call last\)|\bException in thread\b|^\s*Caused by: `, 0, 6},
+}
+
+// DefaultPatterns returns the built-in patterns
+func DefaultPatterns() []Pattern {
+	patterns := make([]Pattern, len(builtins))
+	for i, b := range builtins {
+		patterns[i] = Pattern{Name: b.name, Regexp: regexp.MustCompile(b.expr), Before: b.before, After: b.after}
+	}
+	return patterns
+}
+
+// Match is a log line that matched a pattern. Line is zero-based.
+type Match struct {
+	Line    int
+	Pattern string
+}
+
+// Snippet is a contiguous range of log lines around one or more matches.
+// Start is the zero-based index of the first line.
+type Snippet struct {
+	Start   int
+	Lines   []string
+	Matches []Match
+}
+
+// End returns the index one past the snippet's last line
+func (s Snippet) End() int {
+	return s.Start + len(s.Lines)
+}
+
+// IsMatch reports whether the line at index i matched a pattern
+func (s Snippet) IsMatch(i int) bool {
+	for _, m := range s.Matches {
+		if m.Line == i {
+			return true
+		}
+	}
+	return false
+}
+
+// Extractor finds snippets of a log that match its patterns
+type Extractor struct {
+	Patterns []Pattern
+}
+
+// Extract returns the snippets of text around matching lines, in log order.
+// Overlapping or adjacent context windows are merged into one snippet.
+func (x Extractor) Extract(text string) []Snippet {
+	return x.ExtractLines(strings.Split(text, "\n"))
+}
+
+// ExtractLines is Extract for a log already split into lines
+func (x Extractor) ExtractLines(lines []string) []Snippet {
+	snippets := []Snippet{}
+	var cur *Snippet
+	for i, line := range lines {
+		p, ok := x.match(line)
+		if !ok {
+			continue
+		}
+		start, end := max(0, i-p.Before), min(len(lines), i+p.After+1)
+		if cur != nil && start <= cur.End() {
+			if end > cur.End() {
+				cur.Lines = append(cur.Lines, lines[cur.End():end]...)
+			}
+			cur.Matches = append(cur.Matches, Match{Line: i, Pattern: p.Name})
+			continue
+		}
+		snippets = append(snippets, Snippet{
+			Start:   start,
+			Lines:   append([]string{}, lines[start:end]...),
+			Matches: []Match{{Line: i, Pattern: p.Name}},
+		})
+		cur = &snippets[len(snippets)-1]
+	}
+	return snippets
+}
+
+// match returns the first pattern that matches line
+func (x Extractor) match(line string) (Pattern, bool) {
+	for _, p := range x.Patterns {
+		if p.Regexp.MatchString(line) {
+			return p, true
+		}
+	}
+	return Pattern{}, false
+}
+
+// Limit keeps the first snippets whose lines fit in maxLines, and returns
+// them with the number of snippets dropped. A single snippet larger than
+// maxLines is cut short around its first match. maxLines <= 0 keeps all.
+func Limit(snippets []Snippet, maxLines int) ([]Snippet, int) {
+	if maxLines <= 0 {
+		return snippets, 0
+	}
+	kept := []Snippet{}
+	used := 0
+	for i, s := range snippets {
+		if used+len(s.Lines) <= maxLines {
+			kept = append(kept, s)
+			used += len(s.Lines)
+			continue
+		}
+		if len(kept) == 0 {
+			kept = append(kept, truncate(s, maxLines))
+			return kept, len(snippets) - i - 1
+		}
+		return kept, len(snippets) - i
+	}
+	return kept, 0
+}
+
+// truncate cuts s to n lines, starting just before its first match
+func truncate(s Snippet, n int) Snippet {
+	first := s.Matches[0].Line - s.Start
+	offset := max(0, min(first-2, len(s.Lines)-n))
+	out := Snippet{Start: s.Start + offset, Lines: s.Lines[offset : offset+n]}
+	for _, m := range s.Matches {
+		if m.Line >= out.Start && m.Line < out.End() {
+			out.Matches = append(out.Matches, m)
+		}
+	}
+	return out
+}
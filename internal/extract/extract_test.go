@@ -0,0 +1,112 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDefaultPatterns(t *testing.T) {
+	x := Extractor{Patterns: DefaultPatterns()}
+	tests := map[string]string{
+		"ERROR: script returned exit code 2":                   "exit-code",
+		"[2024-05-01T10:00:00Z] Killed process 1234 (java)":    "oom",
+		"java.lang.OutOfMemoryError: Java heap space":          "oom",
+		"internal/foo/bar.go:12:3: undefined: baz":             "go-compile",
+		"--- FAIL: TestThing (0.01s)":                          "go-test",
+		"FAIL\tjenkins/cmd\t0.123s":                            "go-test",
+		"src/main.c:10:5: error: expected ';'":                 "compiler",
+		"src/app.ts(3,1): error TS2304: Cannot find name 'x'.": "compiler",
+		"* What went wrong:":                                   "gradle",
+		"> Task :app:compileJava FAILED":                       "gradle",
+		"[ERROR] Failed to execute goal":                       "maven",
+		"npm ERR! code ELIFECYCLE":                             "npm",
+		"  3 failing":                                          "test-failure",
+		"FAILED tests/test_api.py::test_get - assert 1 == 2":   "test-failure",
+		"Traceback (most recent call last):":                   "exception",
+		"Caused by: java.io.IOException: Connection reset":     "exception",
+	}
+	for line, want := range tests {
+		p, ok := x.match(line)
+		if !ok || p.Name != want {
+			t.Errorf("match(%q) = %q, %v; want %q", line, p.Name, ok, want)
+		}
+	}
+
+	for _, line := range []string{"Running tests", "0 failed", "script returned exit code 0", "[INFO] BUILD SUCCESS"} {
+		if p, ok := x.match(line); ok {
+			t.Errorf("match(%q) = %q, want no match", line, p.Name)
+		}
+	}
+}
+
+func logLines(n int, errors map[int]string) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i)
+		if e, ok := errors[i]; ok {
+			lines[i] = e
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestExtract(t *testing.T) {
+	re, err := Compile("boom", `BOOM`, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := Extractor{Patterns: []Pattern{re}}
+
+	// matches at 10 and 12 overlap and merge; 30 is separate; 99 is clipped
+	text := logLines(100, map[int]string{10: "BOOM a", 12: "BOOM b", 30: "BOOM c", 99: "BOOM d"})
+	snippets := x.Extract(text)
+	if len(snippets) != 3 {
+		t.Fatalf("got %d snippets, want 3: %+v", len(snippets), snippets)
+	}
+
+	first := snippets[0]
+	if first.Start != 8 || first.End() != 14 || len(first.Matches) != 2 {
+		t.Errorf("first snippet = %d-%d with %d matches, want 8-14 with 2", first.Start, first.End(), len(first.Matches))
+	}
+	if !first.IsMatch(12) || first.IsMatch(11) {
+		t.Error("IsMatch reported the wrong lines")
+	}
+	if first.Lines[0] != "line 8" || first.Lines[len(first.Lines)-1] != "line 13" {
+		t.Errorf("first snippet lines = %q", first.Lines)
+	}
+	if last := snippets[2]; last.Start != 97 || last.End() != 100 {
+		t.Errorf("last snippet = %d-%d, want 97-100", last.Start, last.End())
+	}
+
+	if got := x.Extract("nothing to see\nhere"); len(got) != 0 {
+		t.Errorf("expected no snippets, got %+v", got)
+	}
+
+	if _, err := Compile("bad", `(`, 0, 0); err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("Compile() error = %v, want error naming the pattern", err)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	re, _ := Compile("boom", `BOOM`, 2, 2)
+	x := Extractor{Patterns: []Pattern{re}}
+	snippets := x.Extract(logLines(100, map[int]string{10: "BOOM", 30: "BOOM", 50: "BOOM"}))
+
+	kept, dropped := Limit(snippets, 12)
+	if len(kept) != 2 || dropped != 1 {
+		t.Errorf("Limit(12) kept %d dropped %d, want 2 and 1", len(kept), dropped)
+	}
+	if kept, dropped := Limit(snippets, 0); len(kept) != 3 || dropped != 0 {
+		t.Errorf("Limit(0) kept %d dropped %d, want 3 and 0", len(kept), dropped)
+	}
+
+	// a single snippet larger than the limit is cut around its first match
+	kept, dropped = Limit(snippets, 3)
+	if len(kept) != 1 || dropped != 2 {
+		t.Fatalf("Limit(3) kept %d dropped %d, want 1 and 2", len(kept), dropped)
+	}
+	if s := kept[0]; len(s.Lines) != 3 || !s.IsMatch(10) || s.Start != 8 {
+		t.Errorf("truncated snippet = %+v", s)
+	}
+}
@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KnownIssue is a recognised failure, such as a flaky test or a broken agent,
+// with where it is tracked and how to work around it
+type KnownIssue struct {
+	ID         string `yaml:"id"`
+	Title      string `yaml:"title"`
+	Pattern    string `yaml:"pattern"`
+	Stage      string `yaml:"stage"`
+	Link       string `yaml:"link"`
+	Workaround string `yaml:"workaround"`
+
+	pattern *regexp.Regexp
+	stage   *regexp.Regexp
+}
+
+// Summary returns "ID: title", or just the ID if there is no title
+func (k KnownIssue) Summary() string {
+	if k.Title == "" {
+		return k.ID
+	}
+	return fmt.Sprintf("%s: %s", k.ID, k.Title)
+}
+
+// LoadKnownIssues reads a YAML list of known issues from path. A missing file
+// is not an error and returns no issues.
+func LoadKnownIssues(path string) ([]KnownIssue, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []KnownIssue
+	if err := yaml.Unmarshal(data, &issues); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range issues {
+		if err := issues[i].compile(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return issues, nil
+}
+
+// compile checks the issue and compiles its expressions
+func (k *KnownIssue) compile() error {
+	if k.ID == "" || k.Pattern == "" {
+		return fmt.Errorf("known issue %q needs an id and a pattern", k.Summary())
+	}
+	var err error
+	if k.pattern, err = regexp.Compile(k.Pattern); err != nil {
+		return fmt.Errorf("known issue %s: %w", k.ID, err)
+	}
+	if k.Stage != "" {
+		if k.stage, err = regexp.Compile(k.Stage); err != nil {
+			return fmt.Errorf("known issue %s stage: %w", k.ID, err)
+		}
+	}
+	return nil
+}
+
+// MatchKnownIssue returns the first issue whose pattern matches one of lines
+// and whose stage expression, if any, matches stage. The index of the
+// matching line is returned with it.
+func MatchKnownIssue(issues []KnownIssue, stage string, lines []string) (*KnownIssue, int) {
+	for i := range issues {
+		k := &issues[i]
+		if k.pattern == nil || (k.stage != nil && !k.stage.MatchString(stage)) {
+			continue
+		}
+		for n, line := range lines {
+			if k.pattern.MatchString(line) {
+				return k, n
+			}
+		}
+	}
+	return nil, -1
+}
@@ -0,0 +1,60 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const knownIssuesFile = `
+- id: KNOWN-123
+  title: agent lost disk space
+  pattern: No space left on device
+  link: https://issues.example.com/KNOWN-123
+  workaround: Rebuild; the agent is cleaned nightly
+- id: KNOWN-200
+  title: flaky upload test
+  pattern: 'TestUpload.*connection reset'
+  stage: '(?i)integration'
+`
+
+func TestKnownIssues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known-issues.yaml")
+	if err := os.WriteFile(path, []byte(knownIssuesFile), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := LoadKnownIssues(path)
+	if err != nil {
+		t.Fatalf("LoadKnownIssues() failed: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want 2", len(issues))
+	}
+
+	issue, line := MatchKnownIssue(issues, "Build > Unit", []string{"ok", "write: No space left on device"})
+	if issue == nil || issue.ID != "KNOWN-123" || line != 1 {
+		t.Fatalf("MatchKnownIssue() = %v, %d", issue, line)
+	}
+	if issue.Summary() != "KNOWN-123: agent lost disk space" {
+		t.Errorf("Summary() = %q", issue.Summary())
+	}
+
+	flaky := []string{"--- FAIL: TestUpload: read: connection reset by peer"}
+	if issue, _ := MatchKnownIssue(issues, "Build > Unit", flaky); issue != nil {
+		t.Errorf("issue restricted to integration stages matched %s", issue.ID)
+	}
+	if issue, _ := MatchKnownIssue(issues, "Test > Integration", flaky); issue == nil || issue.ID != "KNOWN-200" {
+		t.Errorf("MatchKnownIssue() = %v, want KNOWN-200", issue)
+	}
+
+	if issues, err := LoadKnownIssues(filepath.Join(t.TempDir(), "missing.yaml")); err != nil || issues != nil {
+		t.Errorf("missing file = %v, %v; want no issues and no error", issues, err)
+	}
+
+	os.WriteFile(path, []byte("- id: BAD-1\n  pattern: '('\n"), 0600)
+	if _, err := LoadKnownIssues(path); err == nil || !strings.Contains(err.Error(), "BAD-1") {
+		t.Errorf("LoadKnownIssues() error = %v, want error naming the issue", err)
+	}
+}
@@ -0,0 +1,161 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrInvalidRange is returned when a download is resumed from an offset past
+// the end of the artifact
+var ErrInvalidRange = errors.New("requested range not satisfiable")
+
+// Artifact is a file archived by a build
+type Artifact struct {
+	FileName     string
+	RelativePath string
+	// Size is the file size in bytes, or -1 if unknown. Jenkins does not
+	// report it with the build, see GetArtifactSize.
+	Size int64 `json:"-"`
+	// MD5 is the hex checksum from the build's fingerprints, empty if the
+	// file was not fingerprinted
+	MD5 string `json:"-"`
+}
+
+// ArtifactDownload is the body of an artifact download
+type ArtifactDownload struct {
+	io.ReadCloser
+	// Offset is where the body starts in the file: the requested offset if
+	// Jenkins honoured the range, 0 if it sent the whole file
+	Offset int64
+	// Size is the total file size, or -1 if unknown
+	Size int64
+}
+
+// artifactPath returns the URL path of an artifact, escaping each segment of
+// its relative path
+func artifactPath(pipeline, buildID, relativePath string) string {
+	segments := strings.Split(relativePath, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return fmt.Sprintf("%s/%s/artifact/%s", JobPath(pipeline), buildID, strings.Join(segments, "/"))
+}
+
+// GetArtifacts retrieves the artifacts archived by a build, with checksums
+// from the build's fingerprints where available. Sizes are set to -1.
+func (c *Client) GetArtifacts(pipeline, buildID string) ([]Artifact, error) {
+	path := fmt.Sprintf("%s/%s/api/json", JobPath(pipeline), buildID)
+	res, err := c.Request(http.MethodGet, path, map[string]string{
+		"tree": "artifacts[fileName,relativePath],fingerprint[fileName,hash]",
+	})
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Artifacts   []Artifact
+		Fingerprint []struct {
+			FileName string
+			Hash     string
+		}
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	hashes := map[string]string{}
+	for _, f := range data.Fingerprint {
+		hashes[f.FileName] = f.Hash
+	}
+	for i := range data.Artifacts {
+		a := &data.Artifacts[i]
+		a.Size = -1
+		a.MD5 = fingerprintHash(hashes, *a)
+	}
+	return data.Artifacts, nil
+}
+
+// fingerprintHash finds the fingerprint of an artifact. Fingerprints are
+// recorded under the name the file was archived with, which is usually the
+// relative path but may be just the file name.
+func fingerprintHash(hashes map[string]string, a Artifact) string {
+	if h, ok := hashes[a.RelativePath]; ok {
+		return h
+	}
+	for name, h := range hashes {
+		if path.Base(name) == a.FileName {
+			return h
+		}
+	}
+	return ""
+}
+
+// GetArtifactSize returns the size of an artifact in bytes, or -1 if Jenkins
+// does not report it
+func (c *Client) GetArtifactSize(pipeline, buildID, relativePath string) (int64, error) {
+	path := artifactPath(pipeline, buildID, relativePath)
+	res, err := c.Request(http.MethodHead, path)
+	if err != nil {
+		c.log("Request error")
+		return -1, err
+	}
+	res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return -1, err
+	}
+	return res.ContentLength, nil
+}
+
+// DownloadArtifact starts downloading an artifact from offset, so that an
+// interrupted download can be resumed. The caller must close the download.
+// Returns an error wrapping ErrInvalidRange if offset is past the end of the
+// file.
+func (c *Client) DownloadArtifact(pipeline, buildID, relativePath string, offset int64) (*ArtifactDownload, error) {
+	path := artifactPath(pipeline, buildID, relativePath)
+	req, err := c.newRequest(http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	if res.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		res.Body.Close()
+		return nil, fmt.Errorf("%s from offset %d: %w", path, offset, ErrInvalidRange)
+	}
+	if err := checkStatus(res, path); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	download := &ArtifactDownload{ReadCloser: res.Body, Size: -1}
+	if res.StatusCode == http.StatusPartialContent {
+		download.Offset = offset
+		if res.ContentLength >= 0 {
+			download.Size = offset + res.ContentLength
+		}
+	} else if res.ContentLength >= 0 {
+		download.Size = res.ContentLength
+	}
+	return download, nil
+}
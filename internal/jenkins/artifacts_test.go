@@ -0,0 +1,89 @@
+package jenkins
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const artifactsJSON = `{
+	"artifacts": [
+		{"fileName": "app.tar.gz", "relativePath": "dist/app.tar.gz"},
+		{"fileName": "report file.html", "relativePath": "reports/report file.html"},
+		{"fileName": "notes.txt", "relativePath": "notes.txt"}
+	],
+	"fingerprint": [
+		{"fileName": "dist/app.tar.gz", "hash": "0123456789abcdef0123456789abcdef"},
+		{"fileName": "build/report file.html", "hash": "fedcba9876543210fedcba9876543210"}
+	]
+}`
+
+func TestClientGetArtifacts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/master/42/api/json" || !strings.Contains(r.URL.Query().Get("tree"), "fingerprint") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(artifactsJSON))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Host: server.URL, User: "test", APIKey: "test", Verbose: mockVerbose})
+	artifacts, err := client.GetArtifacts("master", "42")
+	if err != nil {
+		t.Fatalf("GetArtifacts failed: %v", err)
+	}
+	if len(artifacts) != 3 {
+		t.Fatalf("got %d artifacts, want 3", len(artifacts))
+	}
+
+	want := []string{"0123456789abcdef0123456789abcdef", "fedcba9876543210fedcba9876543210", ""}
+	for i, a := range artifacts {
+		if a.MD5 != want[i] {
+			t.Errorf("%s MD5 = %q, want %q", a.RelativePath, a.MD5, want[i])
+		}
+		if a.Size != -1 {
+			t.Errorf("%s Size = %d, want -1", a.RelativePath, a.Size)
+		}
+	}
+}
+
+func TestClientDownloadArtifact(t *testing.T) {
+	content := "0123456789"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/master/42/artifact/reports/report file.html" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		http.ServeContent(w, r, "report.html", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Host: server.URL, User: "test", APIKey: "test", Verbose: mockVerbose})
+
+	size, err := client.GetArtifactSize("master", "42", "reports/report file.html")
+	if err != nil || size != 10 {
+		t.Errorf("GetArtifactSize() = %d, %v", size, err)
+	}
+
+	download, err := client.DownloadArtifact("master", "42", "reports/report file.html", 4)
+	if err != nil {
+		t.Fatalf("DownloadArtifact failed: %v", err)
+	}
+	body, _ := io.ReadAll(download)
+	download.Close()
+	if string(body) != "456789" || download.Offset != 4 || download.Size != 10 {
+		t.Errorf("resumed download = %q offset %d size %d", body, download.Offset, download.Size)
+	}
+
+	if _, err := client.DownloadArtifact("master", "42", "reports/report file.html", 10); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("DownloadArtifact() past the end = %v, want ErrInvalidRange", err)
+	}
+	if _, err := client.DownloadArtifact("master", "42", "missing.txt", 0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DownloadArtifact() for a missing file = %v, want ErrNotFound", err)
+	}
+}
@@ -4,13 +4,20 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"slices"
+	"strings"
 )
 
+// ErrUnauthorized is returned when Jenkins rejects the client's credentials
+var ErrUnauthorized = errors.New("jenkins rejected the supplied credentials")
+
+// ErrNotFound is returned when the requested job, build or item doesn't exist
+var ErrNotFound = errors.New("not found")
+
 // Client handles communication with Jenkins APIs
 type Client struct {
 	host       string
@@ -39,6 +46,21 @@ func NewClient(cfg Config) *Client {
 	}
 }
 
+// JobPath converts a slash-separated job name such as "team/service/PR-123"
+// into the Jenkins URL path "job/team/job/service/job/PR-123". Segments are
+// URL-escaped as they are: Jenkins names a multibranch branch with a slash
+// "feature%2Ffoo", and that name must reach it as "feature%252Ffoo".
+func JobPath(name string) string {
+	segments := []string{}
+	for _, segment := range strings.Split(strings.Trim(name, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		segments = append(segments, "job", url.PathEscape(segment))
+	}
+	return strings.Join(segments, "/")
+}
+
 // log writes a verbose log message if verbose logging is enabled
 func (c *Client) log(format string, args ...any) {
 	if c.verbose != nil {
@@ -48,6 +70,16 @@ func (c *Client) log(format string, args ...any) {
 
 // Request makes an authenticated request to the Jenkins API
 func (c *Client) Request(method, path string, query ...map[string]string) (*http.Response, error) {
+	req, err := c.newRequest(method, path, query...)
+	if err != nil {
+		return nil, err
+	}
+	return c.httpClient.Do(req)
+}
+
+// newRequest builds an authenticated request to the Jenkins API. Query
+// values are sent as form data for methods other than GET.
+func (c *Client) newRequest(method, path string, query ...map[string]string) (*http.Request, error) {
 	c.log("Using host [%s]", c.host)
 	c.log("Using user [%s] and key [***]", c.user)
 
@@ -84,14 +116,15 @@ func (c *Client) Request(method, path string, query ...map[string]string) (*http
 	}
 	c.log("Calling jenkins API [%s][%s]", req.Method, req.URL)
 
-	return c.httpClient.Do(req)
+	return req, nil
 }
 
-// GetLatestBuild retrieves the latest build matching the product and branch filters
-func (c *Client) GetLatestBuild(pipeline, productFilter, branchFilter string) (*WorkflowRun, error) {
-	path := fmt.Sprintf("job/%s/api/json", pipeline)
+// GetLatestBuildMatching retrieves the latest build whose parameters have
+// exactly the given values. Returns nil if no recent build matches.
+func (c *Client) GetLatestBuildMatching(pipeline string, filters map[string]string) (*WorkflowRun, error) {
+	path := fmt.Sprintf("%s/api/json", JobPath(pipeline))
 	query := map[string]string{"tree": "builds[id,fullDisplayName,actions[parameters[name,value]]]"}
-	c.log("GetLatestBuild([%s], [%+v])", path, query)
+	c.log("GetLatestBuildMatching([%s], [%+v], [%+v])", path, query, filters)
 
 	res, err := c.Request(http.MethodGet, path, query)
 	if err != nil {
@@ -106,39 +139,19 @@ func (c *Client) GetLatestBuild(pipeline, productFilter, branchFilter string) (*
 		return nil, err
 	}
 
-	var latestBuild *WorkflowRun
 	for _, run := range job.Builds {
 		c.log("Build %s", run.ID)
-		for _, action := range run.Actions {
-			if len(action.Parameters) > 0 {
-				bIdx := slices.IndexFunc(action.Parameters, func(p WorkflowParameter) bool {
-					return p.Name == "TRYMAX_BRANCH"
-				})
-				pIdx := slices.IndexFunc(action.Parameters, func(p WorkflowParameter) bool {
-					return p.Name == "PRODUCT"
-				})
-				if bIdx >= 0 && pIdx >= 0 {
-					c.log("  %d = %v", bIdx, action.Parameters[bIdx].Value)
-					c.log("  %d = %v", pIdx, action.Parameters[pIdx].Value)
-					if action.Parameters[pIdx].Value == productFilter && action.Parameters[bIdx].Value == branchFilter {
-						latestBuild = &run
-						break
-					}
-				}
-			}
-		}
-
-		if latestBuild != nil {
-			break
+		if run.matchesParameters(filters) {
+			return &run, nil
 		}
 	}
 
-	return latestBuild, nil
+	return nil, nil
 }
 
 // GetBuildInfo retrieves information about a specific build
 func (c *Client) GetBuildInfo(pipeline, buildID string) (*WorkflowRun, error) {
-	path := fmt.Sprintf("job/%s/%s/api/json", pipeline, buildID)
+	path := fmt.Sprintf("%s/%s/api/json", JobPath(pipeline), buildID)
 	c.log("GetBuildInfo([%s])", path)
 
 	res, err := c.Request(http.MethodGet, path)
@@ -148,6 +161,10 @@ func (c *Client) GetBuildInfo(pipeline, buildID string) (*WorkflowRun, error) {
 	}
 	defer res.Body.Close()
 
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
 	var run WorkflowRun
 	if err := json.NewDecoder(res.Body).Decode(&run); err != nil {
 		c.log("JSON decode error for build [%s]", buildID)
@@ -159,7 +176,7 @@ func (c *Client) GetBuildInfo(pipeline, buildID string) (*WorkflowRun, error) {
 
 // GetJobs retrieves the list of recent jobs for a pipeline
 func (c *Client) GetJobs(pipeline string) ([]Job, error) {
-	path := fmt.Sprintf("job/%s/wfapi/runs", pipeline)
+	path := fmt.Sprintf("%s/wfapi/runs", JobPath(pipeline))
 	res, err := c.Request(http.MethodGet, path)
 	if err != nil {
 		c.log("Request error")
@@ -167,6 +184,10 @@ func (c *Client) GetJobs(pipeline string) ([]Job, error) {
 	}
 	defer res.Body.Close()
 
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
 	var jobs []Job
 	if err := json.NewDecoder(res.Body).Decode(&jobs); err != nil {
 		c.log("JSON decode error")
@@ -178,7 +199,7 @@ func (c *Client) GetJobs(pipeline string) ([]Job, error) {
 
 // GetJobDetails retrieves detailed information about a specific job
 func (c *Client) GetJobDetails(pipeline, jobID string) (*Job, error) {
-	path := fmt.Sprintf("job/%s/%s/wfapi/describe", pipeline, jobID)
+	path := fmt.Sprintf("%s/%s/wfapi/describe", JobPath(pipeline), jobID)
 	res, err := c.Request(http.MethodGet, path)
 	if err != nil {
 		c.log("Request error")
@@ -186,6 +207,10 @@ func (c *Client) GetJobDetails(pipeline, jobID string) (*Job, error) {
 	}
 	defer res.Body.Close()
 
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
 	var job Job
 	if err := json.NewDecoder(res.Body).Decode(&job); err != nil {
 		c.log("JSON decode error")
@@ -213,10 +238,212 @@ func (c *Client) GetStageLog(logHREF string) (*Node, error) {
 	return &node, nil
 }
 
+// GetStage retrieves the full description of a stage, including its child
+// flow nodes, from its self link
+func (c *Client) GetStage(selfHREF string) (*Stage, error) {
+	res, err := c.Request(http.MethodGet, selfHREF)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, selfHREF); err != nil {
+		return nil, err
+	}
+
+	var stage Stage
+	if err := json.NewDecoder(res.Body).Decode(&stage); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	return &stage, nil
+}
+
 // TriggerBuild triggers a parameterized build
 func (c *Client) TriggerBuild(job string, params map[string]string) (*http.Response, error) {
-	path := fmt.Sprintf("job/%s/buildWithParameters", job)
+	path := fmt.Sprintf("%s/buildWithParameters", JobPath(job))
 	c.log("TriggerBuild params [%#+v]", params)
 
 	return c.Request(http.MethodPost, path, params)
 }
+
+// WhoAmI returns the identity Jenkins associates with the client's credentials
+func (c *Client) WhoAmI() (*User, error) {
+	path := "whoAmI/api/json"
+	res, err := c.Request(http.MethodGet, path)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
+	var user User
+	if err := json.NewDecoder(res.Body).Decode(&user); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	if user.Anonymous || !user.Authenticated {
+		return nil, ErrUnauthorized
+	}
+
+	return &user, nil
+}
+
+// GetFolder lists the jobs directly inside a folder, multibranch project or
+// organization. An empty folder lists the top-level jobs on the server.
+func (c *Client) GetFolder(folder string) ([]JobSummary, error) {
+	path := "api/json"
+	if folder != "" {
+		path = fmt.Sprintf("%s/api/json", JobPath(folder))
+	}
+	query := map[string]string{"tree": "jobs[name,fullName,url,color,description,lastBuild[number,result,timestamp,building]]"}
+	c.log("GetFolder([%s], [%+v])", path, query)
+
+	res, err := c.Request(http.MethodGet, path, query)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("folder %s %w", folder, ErrNotFound)
+	}
+
+	var listing struct {
+		Jobs []JobSummary
+	}
+	if err := json.NewDecoder(res.Body).Decode(&listing); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	return listing.Jobs, nil
+}
+
+// GetJobParameters retrieves the parameter definitions declared by a job
+func (c *Client) GetJobParameters(job string) ([]ParameterDefinition, error) {
+	path := fmt.Sprintf("%s/api/json", JobPath(job))
+	query := map[string]string{"tree": "property[parameterDefinitions[name,type,description,choices,defaultParameterValue[name,value]]]"}
+	c.log("GetJobParameters([%s], [%+v])", path, query)
+
+	res, err := c.Request(http.MethodGet, path, query)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("job %s %w", job, ErrNotFound)
+	}
+
+	var props struct {
+		Property []struct {
+			ParameterDefinitions []ParameterDefinition
+		}
+	}
+	if err := json.NewDecoder(res.Body).Decode(&props); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	defs := []ParameterDefinition{}
+	for _, p := range props.Property {
+		defs = append(defs, p.ParameterDefinitions...)
+	}
+
+	return defs, nil
+}
+
+// BuildSignal is a request to interrupt a running build, in increasing order
+// of force
+type BuildSignal string
+
+const (
+	// SignalStop asks the build to abort gracefully
+	SignalStop BuildSignal = "stop"
+	// SignalTerm forcibly terminates the build's running steps
+	SignalTerm BuildSignal = "term"
+	// SignalKill hard-kills the build without cleanup
+	SignalKill BuildSignal = "kill"
+)
+
+// SignalBuild sends a stop, term or kill request to a running build
+func (c *Client) SignalBuild(pipeline, buildID string, signal BuildSignal) error {
+	path := fmt.Sprintf("%s/%s/%s", JobPath(pipeline), buildID, signal)
+	c.log("SignalBuild([%s])", path)
+
+	res, err := c.Request(http.MethodPost, path)
+	if err != nil {
+		c.log("Request error")
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkStatus(res, path)
+}
+
+// GetQueueItem retrieves a Jenkins queue item by its number
+func (c *Client) GetQueueItem(queueID string) (*QueueItem, error) {
+	path := fmt.Sprintf("queue/item/%s/api/json", queueID)
+	res, err := c.Request(http.MethodGet, path)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
+	var item QueueItem
+	if err := json.NewDecoder(res.Body).Decode(&item); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// CancelQueueItem removes an item from the build queue before it starts
+func (c *Client) CancelQueueItem(queueID string) error {
+	path := "queue/cancelItem"
+	c.log("CancelQueueItem([%s])", queueID)
+
+	res, err := c.Request(http.MethodPost, path, map[string]string{"id": queueID})
+	if err != nil {
+		c.log("Request error")
+		return err
+	}
+	defer res.Body.Close()
+
+	// Some Jenkins versions answer a successful cancellation with a 404 from
+	// the redirect target, so only treat other errors as failures
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkStatus(res, path)
+}
+
+// checkStatus returns an error for non-2xx/3xx responses, wrapping
+// ErrUnauthorized for 401 and 403 and ErrNotFound for 404
+func checkStatus(res *http.Response, path string) error {
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%w (status %d from %s)", ErrUnauthorized, res.StatusCode, path)
+	case res.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%s %w", path, ErrNotFound)
+	case res.StatusCode >= 400:
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, path)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package jenkins
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -154,66 +155,6 @@ func TestClientGetBuildInfo(t *testing.T) {
 	}
 }
 
-func TestClientGetLatestBuild(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Return raw JSON to avoid Timestamp marshaling issues in the test
-		responseJSON := `{
-			"_class": "org.jenkinsci.plugins.workflow.job.WorkflowJob",
-			"builds": [
-				{
-					"id": "1235",
-					"timestamp": 1704067200000,
-					"actions": [
-						{
-							"parameters": [
-								{"name": "PRODUCT", "value": "ingredi"},
-								{"name": "TRYMAX_BRANCH", "value": "origin/master"}
-							]
-						}
-					]
-				},
-				{
-					"id": "1234",
-					"timestamp": 1704067100000,
-					"actions": [
-						{
-							"parameters": [
-								{"name": "PRODUCT", "value": "other"},
-								{"name": "TRYMAX_BRANCH", "value": "origin/master"}
-							]
-						}
-					]
-				}
-			]
-		}`
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(responseJSON))
-	}))
-	defer server.Close()
-
-	client := NewClient(Config{
-		Host:    server.URL,
-		User:    "test",
-		APIKey:  "test",
-		Verbose: mockVerbose,
-	})
-
-	build, err := client.GetLatestBuild("master", "ingredi", "origin/master")
-	if err != nil {
-		t.Fatalf("GetLatestBuild failed: %v", err)
-	}
-
-	if build == nil {
-		t.Fatal("expected build, got nil")
-	}
-
-	if build.ID != "1235" {
-		t.Errorf("build.ID = %s, want 1235", build.ID)
-	}
-}
-
 func TestClientGetJobDetails(t *testing.T) {
 	jobJSON, err := os.ReadFile("../../testdata/job.json")
 	if err != nil {
@@ -295,3 +236,368 @@ func TestClientTriggerBuild(t *testing.T) {
 		t.Errorf("status code = %d, want %d", resp.StatusCode, http.StatusCreated)
 	}
 }
+
+func TestClientWhoAmI(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantUser     string
+		unauthorized bool
+	}{
+		{
+			name:     "authenticated",
+			status:   http.StatusOK,
+			body:     `{"_class":"hudson.security.WhoAmI","anonymous":false,"authenticated":true,"authorities":["authenticated"],"name":"alice"}`,
+			wantUser: "alice",
+		},
+		{
+			name:         "anonymous",
+			status:       http.StatusOK,
+			body:         `{"_class":"hudson.security.WhoAmI","anonymous":true,"authenticated":true,"name":"anonymous"}`,
+			unauthorized: true,
+		},
+		{
+			name:         "bad token",
+			status:       http.StatusUnauthorized,
+			body:         `<html>Unauthorized</html>`,
+			unauthorized: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/whoAmI/api/json" {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{
+				Host:    server.URL,
+				User:    "alice",
+				APIKey:  "test",
+				Verbose: mockVerbose,
+			})
+
+			user, err := client.WhoAmI()
+			if tt.unauthorized {
+				if !errors.Is(err, ErrUnauthorized) {
+					t.Errorf("WhoAmI() error = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WhoAmI failed: %v", err)
+			}
+			if user.Name != tt.wantUser {
+				t.Errorf("user.Name = %s, want %s", user.Name, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestJobPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"master", "job/master"},
+		{"team/service", "job/team/job/service"},
+		{"team/service/PR-123", "job/team/job/service/job/PR-123"},
+		{"/team/service/", "job/team/job/service"},
+		{"service/feature%2Ffoo", "job/service/job/feature%252Ffoo"},
+		{"my job", "job/my%20job"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := JobPath(tt.name); got != tt.expected {
+				t.Errorf("JobPath(%s) = %s, want %s", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClientGetBuildInfoNestedJob(t *testing.T) {
+	workflowJSON, err := os.ReadFile("../../testdata/workflow_run.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/job/team/job/service/job/feature%252Ffoo/1234/api/json" {
+			t.Errorf("unexpected path: %s", r.URL.EscapedPath())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(workflowJSON)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	if _, err := client.GetBuildInfo("team/service/feature%2Ffoo", "1234"); err != nil {
+		t.Fatalf("GetBuildInfo failed: %v", err)
+	}
+}
+
+func TestClientGetBuildInfoNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	if _, err := client.GetBuildInfo("master", "99999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetBuildInfo error = %v, want ErrNotFound", err)
+	}
+	if _, err := client.GetJobDetails("master", "99999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetJobDetails error = %v, want ErrNotFound", err)
+	}
+	if _, err := client.GetStage("/job/master/99999/execution/node/6/wfapi/describe"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetStage error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClientGetStage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Links are absolute paths, which end up after the host's slash
+		if r.URL.Path != "//job/master/1234/execution/node/6/wfapi/describe" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "6", "name": "Build", "status": "FAILED", "stageFlowNodes": [{"id": "7", "name": "make", "status": "FAILED"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	stage, err := client.GetStage("/job/master/1234/execution/node/6/wfapi/describe")
+	if err != nil {
+		t.Fatalf("GetStage failed: %v", err)
+	}
+	if stage.ID != "6" || len(stage.StageFlowNodes) != 1 || stage.StageFlowNodes[0].ID != "7" {
+		t.Errorf("GetStage() = %+v", stage)
+	}
+}
+
+func TestClientGetFolder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/team/api/json" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("tree") == "" {
+			t.Error("expected tree query parameter")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"jobs": [
+				{"_class": "org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject", "name": "service"},
+				{"_class": "org.jenkinsci.plugins.workflow.job.WorkflowJob", "name": "nightly",
+				 "lastBuild": {"number": 42, "result": "SUCCESS", "timestamp": 1704067200000}}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	jobs, err := client.GetFolder("team")
+	if err != nil {
+		t.Fatalf("GetFolder failed: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(jobs))
+	}
+	if !jobs[0].IsFolder() {
+		t.Error("multibranch project should be a folder")
+	}
+	if jobs[1].LastBuild == nil || jobs[1].LastBuild.Number != 42 {
+		t.Errorf("jobs[1].LastBuild = %+v, want number 42", jobs[1].LastBuild)
+	}
+}
+
+func TestClientGetJobParameters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/job/master/api/json" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"property": [
+				{},
+				{"parameterDefinitions": [
+					{"name": "PRODUCT", "type": "ChoiceParameterDefinition", "choices": ["ingredi", "bpam"],
+					 "defaultParameterValue": {"name": "PRODUCT", "value": "ingredi"}},
+					{"name": "CLEAN", "type": "BooleanParameterDefinition",
+					 "defaultParameterValue": {"name": "CLEAN", "value": false}},
+					{"name": "TRYMAX_BRANCH", "type": "StringParameterDefinition"}
+				]}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	defs, err := client.GetJobParameters("master")
+	if err != nil {
+		t.Fatalf("GetJobParameters failed: %v", err)
+	}
+	if len(defs) != 3 {
+		t.Fatalf("expected 3 definitions, got %d", len(defs))
+	}
+	if len(defs[0].Choices) != 2 || defs[0].Default() != "ingredi" {
+		t.Errorf("defs[0] = %+v, want 2 choices and default ingredi", defs[0])
+	}
+	if defs[1].Default() != "false" {
+		t.Errorf("defs[1].Default() = %q, want false", defs[1].Default())
+	}
+	if defs[2].Default() != "" {
+		t.Errorf("defs[2].Default() = %q, want empty", defs[2].Default())
+	}
+}
+
+func TestClientGetLatestBuildMatching(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"builds": [
+				{"id": "12", "actions": [{"parameters": [{"name": "COMPONENT", "value": "agent"}, {"name": "GIT_REF", "value": "feature"}]}]},
+				{"id": "11", "actions": [{"parameters": [{"name": "COMPONENT", "value": "agent"}, {"name": "GIT_REF", "value": "main"}]}]}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	build, err := client.GetLatestBuildMatching("master", map[string]string{"COMPONENT": "agent", "GIT_REF": "main"})
+	if err != nil {
+		t.Fatalf("GetLatestBuildMatching failed: %v", err)
+	}
+	if build == nil || build.ID != "11" {
+		t.Fatalf("build = %+v, want 11", build)
+	}
+
+	build, err = client.GetLatestBuildMatching("master", map[string]string{"COMPONENT": "other"})
+	if err != nil {
+		t.Fatalf("GetLatestBuildMatching failed: %v", err)
+	}
+	if build != nil {
+		t.Errorf("build = %+v, want nil", build)
+	}
+}
+
+func TestClientSignalBuild(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		gotPath = r.URL.Path
+		if r.URL.Path == "/job/master/99/term" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	if err := client.SignalBuild("master", "1234", SignalStop); err != nil {
+		t.Fatalf("SignalBuild failed: %v", err)
+	}
+	if gotPath != "/job/master/1234/stop" {
+		t.Errorf("path = %s, want /job/master/1234/stop", gotPath)
+	}
+
+	if err := client.SignalBuild("master", "99", SignalTerm); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("SignalBuild error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestClientQueueItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/queue/item/55/api/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": 55, "cancelled": false, "why": "Waiting for next available executor", "executable": null}`))
+		case "/queue/cancelItem":
+			if r.Method != http.MethodPost {
+				t.Errorf("method = %s, want POST", r.Method)
+			}
+			r.ParseForm()
+			if r.Form.Get("id") != "55" {
+				t.Errorf("id = %s, want 55", r.Form.Get("id"))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	item, err := client.GetQueueItem("55")
+	if err != nil {
+		t.Fatalf("GetQueueItem failed: %v", err)
+	}
+	if item.ID != 55 || item.Executable.Number != 0 || item.Why == "" {
+		t.Errorf("item = %+v", item)
+	}
+
+	if err := client.CancelQueueItem("55"); err != nil {
+		t.Fatalf("CancelQueueItem failed: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Computer is a Jenkins node, either the built-in node or an agent
+type Computer struct {
+	DisplayName        string
+	Offline            bool
+	TemporarilyOffline bool
+	OfflineCauseReason string
+	Idle               bool
+	NumExecutors       int
+	AssignedLabels     []Label
+	Executors          []Executor
+	MonitorData        ComputerMonitors
+}
+
+// Label is a label assigned to a node
+type Label struct {
+	Name string
+}
+
+// Executor is a slot on a node that runs one build step at a time
+type Executor struct {
+	Idle bool
+	// CurrentExecutable is what the executor is running, nil when idle
+	CurrentExecutable *struct {
+		FullDisplayName string
+		URL             string
+	}
+}
+
+// ComputerMonitors holds the node monitor readings Jenkins keeps per node.
+// Readings are nil when the node is offline or the monitor is disabled.
+type ComputerMonitors struct {
+	DiskSpace    *SpaceMonitor `json:"hudson.node_monitors.DiskSpaceMonitor"`
+	TempSpace    *SpaceMonitor `json:"hudson.node_monitors.TemporarySpaceMonitor"`
+	Swap         *SwapMonitor  `json:"hudson.node_monitors.SwapSpaceMonitor"`
+	Architecture string        `json:"hudson.node_monitors.ArchitectureMonitor"`
+}
+
+// SpaceMonitor is the free space of a node's directory, in bytes
+type SpaceMonitor struct {
+	Path string
+	Size int64
+}
+
+// SwapMonitor is a node's memory and swap, in bytes
+type SwapMonitor struct {
+	AvailablePhysicalMemory int64
+	AvailableSwapSpace      int64
+	TotalPhysicalMemory     int64
+	TotalSwapSpace          int64
+}
+
+// Labels returns the node's label names, without the implicit label every
+// node gets for its own name
+func (c Computer) Labels() []string {
+	labels := []string{}
+	for _, l := range c.AssignedLabels {
+		if l.Name != c.DisplayName {
+			labels = append(labels, l.Name)
+		}
+	}
+	return labels
+}
+
+// BusyExecutors returns the number of executors running something
+func (c Computer) BusyExecutors() int {
+	busy := 0
+	for _, e := range c.Executors {
+		if !e.Idle {
+			busy++
+		}
+	}
+	return busy
+}
+
+// Status returns "online", "offline" or "temporarily offline"
+func (c Computer) Status() string {
+	switch {
+	case c.TemporarilyOffline:
+		return "temporarily offline"
+	case c.Offline:
+		return "offline"
+	}
+	return "online"
+}
+
+// GetComputers retrieves the nodes of the Jenkins instance with their
+// executors and monitor readings
+func (c *Client) GetComputers() ([]Computer, error) {
+	path := "computer/api/json"
+	res, err := c.Request(http.MethodGet, path, map[string]string{"depth": "1"})
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
+	var data struct {
+		Computer []Computer
+	}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+	return data.Computer, nil
+}
@@ -0,0 +1,64 @@
+package jenkins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+const computersJSON = `{
+	"busyExecutors": 1, "totalExecutors": 3,
+	"computer": [{
+		"_class": "hudson.model.Hudson$MasterComputer", "displayName": "Built-In Node",
+		"offline": false, "temporarilyOffline": false, "idle": false, "numExecutors": 2,
+		"assignedLabels": [{"name": "built-in"}],
+		"executors": [{"idle": false, "currentExecutable": {"fullDisplayName": "master #12", "url": "https://jenkins/job/master/12/"}}, {"idle": true}],
+		"monitorData": {
+			"hudson.node_monitors.DiskSpaceMonitor": {"path": "/var/jenkins", "size": 53687091200},
+			"hudson.node_monitors.SwapSpaceMonitor": {"availablePhysicalMemory": 1024, "availableSwapSpace": 0, "totalPhysicalMemory": 4096, "totalSwapSpace": 0},
+			"hudson.node_monitors.ArchitectureMonitor": "Linux (amd64)"
+		}
+	}, {
+		"_class": "hudson.slaves.SlaveComputer", "displayName": "linux-1",
+		"offline": true, "temporarilyOffline": true, "offlineCauseReason": "disk full", "idle": true, "numExecutors": 1,
+		"assignedLabels": [{"name": "docker"}, {"name": "linux"}, {"name": "linux-1"}],
+		"executors": [{"idle": true}],
+		"monitorData": {"hudson.node_monitors.DiskSpaceMonitor": null, "hudson.node_monitors.ArchitectureMonitor": null}
+	}]
+}`
+
+func TestClientGetComputers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/computer/api/json" || r.URL.Query().Get("depth") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(computersJSON))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Host: server.URL, User: "test", APIKey: "test", Verbose: mockVerbose})
+	computers, err := client.GetComputers()
+	if err != nil {
+		t.Fatalf("GetComputers failed: %v", err)
+	}
+	if len(computers) != 2 {
+		t.Fatalf("got %d computers, want 2", len(computers))
+	}
+
+	builtIn, agent := computers[0], computers[1]
+	if builtIn.Status() != "online" || builtIn.BusyExecutors() != 1 || builtIn.Executors[0].CurrentExecutable.FullDisplayName != "master #12" {
+		t.Errorf("built-in node = %+v", builtIn)
+	}
+	if builtIn.MonitorData.DiskSpace.Size != 50<<30 || builtIn.MonitorData.Swap.TotalPhysicalMemory != 4096 || builtIn.MonitorData.Architecture != "Linux (amd64)" {
+		t.Errorf("built-in monitors = %+v", builtIn.MonitorData)
+	}
+
+	if agent.Status() != "temporarily offline" || agent.OfflineCauseReason != "disk full" || agent.MonitorData.DiskSpace != nil {
+		t.Errorf("agent = %+v", agent)
+	}
+	if labels := agent.Labels(); !slices.Equal(labels, []string{"docker", "linux"}) {
+		t.Errorf("Labels() = %q, want the labels without the node name", labels)
+	}
+}
@@ -0,0 +1,119 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// PendingInput is an input step a running build is paused on
+type PendingInput struct {
+	ID          string
+	ProceedText string
+	Message     string
+	Inputs      []InputParameter
+	ProceedURL  string `json:"proceedUrl"`
+	AbortURL    string `json:"abortUrl"`
+}
+
+// InputParameter is a parameter requested by an input step
+type InputParameter struct {
+	Type        string
+	Name        string
+	Description string
+	Definition  ParameterDefinition
+}
+
+// Parameters returns the input's parameters as definitions, so they can be
+// validated and prompted for like job parameters
+func (p PendingInput) Parameters() []ParameterDefinition {
+	defs := make([]ParameterDefinition, 0, len(p.Inputs))
+	for _, in := range p.Inputs {
+		def := in.Definition
+		def.Name = in.Name
+		if def.Type == "" {
+			def.Type = in.Type
+		}
+		if def.Description == "" {
+			def.Description = in.Description
+		}
+		defs = append(defs, def)
+	}
+	return defs
+}
+
+// GetPendingInputs retrieves the input steps a build is waiting on
+func (c *Client) GetPendingInputs(pipeline, buildID string) ([]PendingInput, error) {
+	path := fmt.Sprintf("%s/%s/wfapi/pendingInputActions", JobPath(pipeline), buildID)
+	res, err := c.Request(http.MethodGet, path)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
+	var inputs []PendingInput
+	if err := json.NewDecoder(res.Body).Decode(&inputs); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	return inputs, nil
+}
+
+// SubmitInput proceeds past a pending input step with the given parameter
+// values. Boolean parameters are sent as JSON booleans.
+func (c *Client) SubmitInput(pipeline, buildID string, input PendingInput, params map[string]string) error {
+	path := fmt.Sprintf("%s/%s/wfapi/inputSubmit?inputId=%s", JobPath(pipeline), buildID, url.QueryEscape(input.ID))
+	c.log("SubmitInput([%s])", path)
+
+	values := []map[string]any{}
+	for _, def := range input.Parameters() {
+		value, ok := params[def.Name]
+		if !ok {
+			continue
+		}
+		var v any = value
+		if def.Type == "BooleanParameterDefinition" {
+			if b, err := strconv.ParseBool(value); err == nil {
+				v = b
+			}
+		}
+		values = append(values, map[string]any{"name": def.Name, "value": v})
+	}
+
+	form, err := json.Marshal(map[string]any{"parameter": values})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.Request(http.MethodPost, path, map[string]string{"json": string(form)})
+	if err != nil {
+		c.log("Request error")
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkStatus(res, path)
+}
+
+// AbortInput rejects a pending input step, aborting the build
+func (c *Client) AbortInput(pipeline, buildID string, input PendingInput) error {
+	path := fmt.Sprintf("%s/%s/input/%s/abort", JobPath(pipeline), buildID, url.PathEscape(input.ID))
+	c.log("AbortInput([%s])", path)
+
+	res, err := c.Request(http.MethodPost, path, map[string]string{})
+	if err != nil {
+		c.log("Request error")
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkStatus(res, path)
+}
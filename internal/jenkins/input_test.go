@@ -0,0 +1,98 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const pendingInputs = `[{
+	"id": "Deploy",
+	"proceedText": "Ship it",
+	"message": "Deploy to production?",
+	"inputs": [
+		{"type": "StringParameterDefinition", "name": "VERSION", "description": "Version to deploy",
+		 "definition": {"defaultParameterValue": {"name": "VERSION", "value": "1.0"}}},
+		{"type": "BooleanParameterDefinition", "name": "NOTIFY", "definition": {}}
+	],
+	"proceedUrl": "/job/master/1234/wfapi/inputSubmit?inputId=Deploy",
+	"abortUrl": "/job/master/1234/input/Deploy/abort"
+}]`
+
+func TestPendingInputParameters(t *testing.T) {
+	var inputs []PendingInput
+	if err := json.Unmarshal([]byte(pendingInputs), &inputs); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	defs := inputs[0].Parameters()
+	if len(defs) != 2 {
+		t.Fatalf("len(Parameters()) = %d, want 2", len(defs))
+	}
+	if defs[0].Name != "VERSION" || defs[0].Type != "StringParameterDefinition" || defs[0].Default() != "1.0" {
+		t.Errorf("Parameters()[0] = %+v", defs[0])
+	}
+	if defs[0].Description != "Version to deploy" {
+		t.Errorf("Description = %q", defs[0].Description)
+	}
+	if defs[1].Name != "NOTIFY" || defs[1].Type != "BooleanParameterDefinition" {
+		t.Errorf("Parameters()[1] = %+v", defs[1])
+	}
+}
+
+func TestClientInput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/master/1234/wfapi/pendingInputActions":
+			w.Write([]byte(pendingInputs))
+		case "/job/master/1234/wfapi/inputSubmit":
+			if r.URL.Query().Get("inputId") != "Deploy" {
+				t.Errorf("inputId = %q", r.URL.Query().Get("inputId"))
+			}
+			r.ParseForm()
+			var form struct {
+				Parameter []struct {
+					Name  string
+					Value any
+				}
+			}
+			if err := json.Unmarshal([]byte(r.Form.Get("json")), &form); err != nil {
+				t.Fatalf("json = %q: %v", r.Form.Get("json"), err)
+			}
+			if len(form.Parameter) != 2 || form.Parameter[0].Value != "2.0" || form.Parameter[1].Value != true {
+				t.Errorf("parameters = %+v", form.Parameter)
+			}
+		case "/job/master/1234/input/Deploy/abort":
+			if r.Method != http.MethodPost {
+				t.Errorf("method = %s, want POST", r.Method)
+			}
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	inputs, err := client.GetPendingInputs("master", "1234")
+	if err != nil {
+		t.Fatalf("GetPendingInputs failed: %v", err)
+	}
+	if len(inputs) != 1 || inputs[0].Message != "Deploy to production?" {
+		t.Fatalf("GetPendingInputs() = %+v", inputs)
+	}
+
+	if err := client.SubmitInput("master", "1234", inputs[0], map[string]string{"VERSION": "2.0", "NOTIFY": "true"}); err != nil {
+		t.Fatalf("SubmitInput failed: %v", err)
+	}
+
+	if err := client.AbortInput("master", "1234", inputs[0]); err != nil {
+		t.Fatalf("AbortInput failed: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MatchMode selects how a ParameterMatcher compares parameter values
+type MatchMode int
+
+const (
+	// MatchExact requires the value to equal the pattern
+	MatchExact MatchMode = iota
+	// MatchGlob matches the value against a shell glob (path.Match syntax)
+	MatchGlob
+	// MatchRegex matches the value against a regular expression
+	MatchRegex
+)
+
+// DefaultQueryLimit is the number of builds scanned when a query has no limit
+const DefaultQueryLimit = 100
+
+// ParameterMatcher matches a single build parameter
+type ParameterMatcher struct {
+	Name    string
+	Mode    MatchMode
+	Pattern string
+	re      *regexp.Regexp
+}
+
+// NewParameterMatcher creates a matcher, validating glob and regex patterns
+func NewParameterMatcher(name string, mode MatchMode, pattern string) (ParameterMatcher, error) {
+	m := ParameterMatcher{Name: name, Mode: mode, Pattern: pattern}
+	switch mode {
+	case MatchGlob:
+		if _, err := path.Match(pattern, ""); err != nil {
+			return m, fmt.Errorf("invalid glob %q for %s: %w", pattern, name, err)
+		}
+	case MatchRegex:
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return m, fmt.Errorf("invalid regex %q for %s: %w", pattern, name, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// Matches reports whether value satisfies the matcher
+func (m ParameterMatcher) Matches(value string) bool {
+	switch m.Mode {
+	case MatchGlob:
+		ok, _ := path.Match(m.Pattern, value)
+		return ok
+	case MatchRegex:
+		if m.re == nil {
+			m.re = regexp.MustCompile(m.Pattern)
+		}
+		return m.re.MatchString(value)
+	default:
+		return value == m.Pattern
+	}
+}
+
+// BuildQuery describes a search over a job's recent builds. Zero-valued
+// fields do not filter.
+type BuildQuery struct {
+	Parameters []ParameterMatcher
+	// Results are accepted build results (e.g. SUCCESS, FAILURE); "BUILDING"
+	// matches builds that are still running
+	Results []string
+	// User matches the user ID or name of the user who triggered the build
+	User        string
+	Since       time.Time
+	Until       time.Time
+	MinDuration time.Duration
+	MaxDuration time.Duration
+	// Limit is the number of most recent builds to scan
+	Limit int
+}
+
+// Matches reports whether run satisfies every filter in the query
+func (q BuildQuery) Matches(run WorkflowRun) bool {
+	if len(q.Results) > 0 {
+		result := run.Result
+		if run.Building {
+			result = "BUILDING"
+		}
+		if !slices.ContainsFunc(q.Results, func(r string) bool { return strings.EqualFold(r, result) }) {
+			return false
+		}
+	}
+
+	if !q.Since.IsZero() && run.Timestamp.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && run.Timestamp.Time.After(q.Until) {
+		return false
+	}
+
+	d := time.Duration(run.Duration) * time.Millisecond
+	if q.MinDuration > 0 && d < q.MinDuration {
+		return false
+	}
+	if q.MaxDuration > 0 && d > q.MaxDuration {
+		return false
+	}
+
+	if q.User != "" {
+		if !slices.ContainsFunc(run.Causes(), func(c Cause) bool {
+			return strings.EqualFold(c.UserID, q.User) || strings.EqualFold(c.UserName, q.User)
+		}) {
+			return false
+		}
+	}
+
+	if len(q.Parameters) > 0 {
+		params := run.Parameters()
+		for _, m := range q.Parameters {
+			value, ok := params[m.Name]
+			if !ok || !m.Matches(value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// SearchBuilds scans the most recent builds of pipeline and returns those
+// matching the query, newest first
+func (c *Client) SearchBuilds(pipeline string, q BuildQuery) ([]WorkflowRun, error) {
+	runs, err := c.GetBuilds(pipeline, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []WorkflowRun{}
+	for _, run := range runs {
+		if q.Matches(run) {
+			matched = append(matched, run)
+		}
+	}
+	c.log("SearchBuilds matched [%d] of [%d] builds", len(matched), len(runs))
+
+	return matched, nil
+}
+
+// GetBuilds retrieves metadata, parameters and causes for the most recent
+// limit builds of pipeline, newest first
+func (c *Client) GetBuilds(pipeline string, limit int) ([]WorkflowRun, error) {
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+
+	path := fmt.Sprintf("%s/api/json", JobPath(pipeline))
+	query := map[string]string{"tree": fmt.Sprintf(
+		"allBuilds[id,fullDisplayName,displayName,result,building,timestamp,duration,estimatedDuration,url,description,"+
+			"actions[parameters[name,value],causes[userId,userName,shortDescription]]]{0,%d}", limit)}
+	c.log("GetBuilds([%s], [%+v])", path, query)
+
+	res, err := c.Request(http.MethodGet, path, query)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var job struct {
+		AllBuilds []WorkflowRun
+	}
+	if err := json.NewDecoder(res.Body).Decode(&job); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	return job.AllBuilds, nil
+}
@@ -0,0 +1,128 @@
+package jenkins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParameterMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    MatchMode
+		pattern string
+		value   string
+		matches bool
+	}{
+		{"exact match", MatchExact, "origin/master", "origin/master", true},
+		{"exact mismatch", MatchExact, "origin/master", "origin/main", false},
+		{"glob match", MatchGlob, "origin/release/*", "origin/release/1.0", true},
+		{"glob does not cross slashes", MatchGlob, "origin/*", "origin/release/1.0", false},
+		{"regex match", MatchRegex, `^origin/release/\d+\.\d+$`, "origin/release/24.1", true},
+		{"regex mismatch", MatchRegex, `^origin/release/\d+$`, "origin/release/x", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewParameterMatcher("BRANCH", tt.mode, tt.pattern)
+			if err != nil {
+				t.Fatalf("NewParameterMatcher failed: %v", err)
+			}
+			if m.Matches(tt.value) != tt.matches {
+				t.Errorf("Matches(%q) = %v, want %v", tt.value, !tt.matches, tt.matches)
+			}
+		})
+	}
+
+	if _, err := NewParameterMatcher("X", MatchRegex, "("); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	if _, err := NewParameterMatcher("X", MatchGlob, "["); err == nil {
+		t.Error("expected error for invalid glob")
+	}
+}
+
+func TestBuildQueryMatches(t *testing.T) {
+	start := time.Unix(1704067200, 0)
+	run := WorkflowRun{
+		ID:        "10",
+		Result:    "SUCCESS",
+		Duration:  int((90 * time.Minute).Milliseconds()),
+		Timestamp: Timestamp{start},
+		Actions: []WorkflowAction{
+			{Causes: []Cause{{UserID: "alice", UserName: "Alice Smith"}}},
+			{Parameters: []WorkflowParameter{{Name: "PRODUCT", Value: "bpam"}, {Name: "TRYMAX_BRANCH", Value: "origin/release/1.0"}}},
+		},
+	}
+	glob, _ := NewParameterMatcher("TRYMAX_BRANCH", MatchGlob, "origin/release/*")
+	exact, _ := NewParameterMatcher("PRODUCT", MatchExact, "ingredi")
+
+	tests := []struct {
+		name    string
+		query   BuildQuery
+		matches bool
+	}{
+		{"empty query", BuildQuery{}, true},
+		{"result", BuildQuery{Results: []string{"success"}}, true},
+		{"other result", BuildQuery{Results: []string{"FAILURE"}}, false},
+		{"user id", BuildQuery{User: "ALICE"}, true},
+		{"user name", BuildQuery{User: "Alice Smith"}, true},
+		{"other user", BuildQuery{User: "bob"}, false},
+		{"since before", BuildQuery{Since: start.Add(-time.Hour)}, true},
+		{"since after", BuildQuery{Since: start.Add(time.Hour)}, false},
+		{"until before", BuildQuery{Until: start.Add(-time.Hour)}, false},
+		{"min duration", BuildQuery{MinDuration: time.Hour}, true},
+		{"max duration", BuildQuery{MaxDuration: time.Hour}, false},
+		{"glob parameter", BuildQuery{Parameters: []ParameterMatcher{glob}}, true},
+		{"all parameters", BuildQuery{Parameters: []ParameterMatcher{glob, exact}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.query.Matches(run) != tt.matches {
+				t.Errorf("Matches() = %v, want %v", !tt.matches, tt.matches)
+			}
+		})
+	}
+
+	building := WorkflowRun{Building: true}
+	if !(BuildQuery{Results: []string{"BUILDING"}}).Matches(building) {
+		t.Error("BUILDING should match running builds")
+	}
+}
+
+func TestClientSearchBuilds(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tree := r.URL.Query().Get("tree")
+		if !strings.HasPrefix(tree, "allBuilds[") || !strings.HasSuffix(tree, "{0,25}") {
+			t.Errorf("unexpected tree: %s", tree)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{
+			"allBuilds": [
+				{"id": "3", "result": "FAILURE", "timestamp": 1704067200000, "actions": []},
+				{"id": "2", "result": "SUCCESS", "timestamp": 1704067100000, "actions": []},
+				{"id": "1", "result": "SUCCESS", "timestamp": 1704067000000, "actions": []}
+			]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	builds, err := client.SearchBuilds("master", BuildQuery{Results: []string{"SUCCESS"}, Limit: 25})
+	if err != nil {
+		t.Fatalf("SearchBuilds failed: %v", err)
+	}
+	if len(builds) != 2 || builds[0].ID != "2" || builds[1].ID != "1" {
+		t.Errorf("SearchBuilds() = %+v, want builds 2 and 1", builds)
+	}
+}
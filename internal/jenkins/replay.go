@@ -0,0 +1,111 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ReplayCauseClass is the cause class recorded on builds started by Replay
+const ReplayCauseClass = "org.jenkinsci.plugins.workflow.cps.replay.ReplayCause"
+
+// GetNextBuildNumber returns the number the job will assign to its next build
+func (c *Client) GetNextBuildNumber(pipeline string) (int, error) {
+	path := fmt.Sprintf("%s/api/json", JobPath(pipeline))
+	res, err := c.Request(http.MethodGet, path, map[string]string{"tree": "nextBuildNumber"})
+	if err != nil {
+		c.log("Request error")
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return 0, err
+	}
+
+	var job struct {
+		NextBuildNumber int
+	}
+	if err := json.NewDecoder(res.Body).Decode(&job); err != nil {
+		c.log("JSON decode error")
+		return 0, err
+	}
+
+	return job.NextBuildNumber, nil
+}
+
+// GetReplayScript retrieves the main pipeline script a build ran with, as
+// shown on its Replay page
+func (c *Client) GetReplayScript(pipeline, buildID string) (string, error) {
+	path := fmt.Sprintf("%s/%s/replay/", JobPath(pipeline), buildID)
+	res, err := c.Request(http.MethodGet, path)
+	if err != nil {
+		c.log("Request error")
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("build %s cannot be replayed (is it a pipeline build?)", buildID)
+	}
+	if err := checkStatus(res, path); err != nil {
+		return "", err
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return extractTextarea(string(body), "_.mainScript")
+}
+
+// ReplayBuild starts a new run of a build using mainScript in place of the
+// original Jenkinsfile
+func (c *Client) ReplayBuild(pipeline, buildID, mainScript string) error {
+	path := fmt.Sprintf("%s/%s/replay/run", JobPath(pipeline), buildID)
+	c.log("ReplayBuild([%s])", path)
+
+	form, err := json.Marshal(map[string]string{"mainScript": mainScript})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.Request(http.MethodPost, path, map[string]string{
+		"mainScript": mainScript,
+		"json":       string(form),
+	})
+	if err != nil {
+		c.log("Request error")
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkStatus(res, path)
+}
+
+// extractTextarea returns the unescaped contents of the textarea with the given name
+func extractTextarea(page, name string) (string, error) {
+	nameIdx := strings.Index(page, fmt.Sprintf(`name="%s"`, name))
+	if nameIdx == -1 {
+		return "", fmt.Errorf("failed to find %s in replay page", name)
+	}
+
+	openEnd := strings.Index(page[nameIdx:], ">")
+	if openEnd == -1 {
+		return "", fmt.Errorf("failed to locate end of textarea tag")
+	}
+	contentStart := nameIdx + openEnd + 1
+
+	closeIdx := strings.Index(page[contentStart:], "</textarea>")
+	if closeIdx == -1 {
+		return "", fmt.Errorf("failed to locate closing </textarea> tag")
+	}
+
+	// Browsers drop a single newline directly after the opening tag
+	content := strings.TrimPrefix(page[contentStart:contentStart+closeIdx], "\n")
+	return html.UnescapeString(content), nil
+}
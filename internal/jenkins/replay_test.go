@@ -0,0 +1,80 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const replayPage = `<html><body><form action="run" method="post">
+<textarea name="_.mainScript" class="jenkins-input">
+pipeline {
+  stages { stage('Build') { steps { sh 'make &amp;&amp; echo &quot;done&quot;' } } }
+}</textarea>
+</form></body></html>`
+
+func TestExtractTextarea(t *testing.T) {
+	script, err := extractTextarea(replayPage, "_.mainScript")
+	if err != nil {
+		t.Fatalf("extractTextarea failed: %v", err)
+	}
+	expected := "pipeline {\n  stages { stage('Build') { steps { sh 'make && echo \"done\"' } } }\n}"
+	if script != expected {
+		t.Errorf("script = %q, want %q", script, expected)
+	}
+
+	if _, err := extractTextarea("<html></html>", "_.mainScript"); err == nil {
+		t.Error("expected error when the textarea is missing")
+	}
+}
+
+func TestClientReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/master/api/json":
+			w.Write([]byte(`{"nextBuildNumber": 1240}`))
+		case "/job/master/1234/replay/":
+			w.Write([]byte(replayPage))
+		case "/job/master/1234/replay/run":
+			if r.Method != http.MethodPost {
+				t.Errorf("method = %s, want POST", r.Method)
+			}
+			r.ParseForm()
+			if r.Form.Get("mainScript") != "echo 'hi'" {
+				t.Errorf("mainScript = %q", r.Form.Get("mainScript"))
+			}
+			var form map[string]string
+			if err := json.Unmarshal([]byte(r.Form.Get("json")), &form); err != nil || form["mainScript"] != "echo 'hi'" {
+				t.Errorf("json = %q", r.Form.Get("json"))
+			}
+			w.WriteHeader(http.StatusFound)
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	next, err := client.GetNextBuildNumber("master")
+	if err != nil {
+		t.Fatalf("GetNextBuildNumber failed: %v", err)
+	}
+	if next != 1240 {
+		t.Errorf("next = %d, want 1240", next)
+	}
+
+	if _, err := client.GetReplayScript("master", "1234"); err != nil {
+		t.Fatalf("GetReplayScript failed: %v", err)
+	}
+
+	if err := client.ReplayBuild("master", "1234", "echo 'hi'"); err != nil {
+		t.Fatalf("ReplayBuild failed: %v", err)
+	}
+}
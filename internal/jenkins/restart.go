@@ -0,0 +1,78 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RestartCauseClass is the cause class recorded on builds restarted from a stage
+const RestartCauseClass = "org.jenkinsci.plugins.pipeline.modeldefinition.causes.RestartDeclarativePipelineCause"
+
+// RestartFromStage restarts a completed declarative pipeline build from the
+// given top-level stage
+func (c *Client) RestartFromStage(pipeline, buildID, stage string) error {
+	path := fmt.Sprintf("%s/%s/restart/restart", JobPath(pipeline), buildID)
+	c.log("RestartFromStage([%s], [%s])", path, stage)
+
+	form, err := json.Marshal(map[string]string{"stageName": stage})
+	if err != nil {
+		return err
+	}
+
+	res, err := c.Request(http.MethodPost, path, map[string]string{
+		"stageName": stage,
+		"json":      string(form),
+	})
+	if err != nil {
+		c.log("Request error")
+		return err
+	}
+	defer res.Body.Close()
+
+	return checkStatus(res, path)
+}
+
+// GetQueue retrieves every item currently in the build queue
+func (c *Client) GetQueue() ([]QueueItem, error) {
+	path := "queue/api/json"
+	query := map[string]string{"tree": "items[id,why,cancelled,task[name,url],actions[causes[shortDescription,userId,userName]]]"}
+	res, err := c.Request(http.MethodGet, path, query)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
+	var queue struct {
+		Items []QueueItem
+	}
+	if err := json.NewDecoder(res.Body).Decode(&queue); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	return queue.Items, nil
+}
+
+// FindQueuedBuild returns the queued item for pipeline that has a cause of the
+// given class, or nil if there is none
+func (c *Client) FindQueuedBuild(pipeline, causeClass string) (*QueueItem, error) {
+	items, err := c.GetQueue()
+	if err != nil {
+		return nil, err
+	}
+
+	jobPath := "/" + JobPath(pipeline) + "/"
+	for _, item := range items {
+		if strings.HasSuffix(item.Task.URL, jobPath) && item.HasCause(causeClass) {
+			return &item, nil
+		}
+	}
+	return nil, nil
+}
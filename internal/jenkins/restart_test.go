@@ -0,0 +1,85 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWorkflowRunRestartableStages(t *testing.T) {
+	var run WorkflowRun
+	data := `{"actions": [
+		{"_class": "hudson.model.CauseAction", "causes": [{"shortDescription": "Started by user"}]},
+		{"_class": "org.jenkinsci.plugins.pipeline.modeldefinition.actions.RestartDeclarativePipelineAction",
+		 "restartEnabled": true, "restartableStages": ["Build", "Test", "Deploy"]}
+	]}`
+	if err := json.Unmarshal([]byte(data), &run); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	stages := run.RestartableStages()
+	if len(stages) != 3 || stages[1] != "Test" {
+		t.Errorf("RestartableStages() = %v", stages)
+	}
+
+	if stages := (WorkflowRun{}).RestartableStages(); stages != nil {
+		t.Errorf("RestartableStages() on a run without the action = %v, want nil", stages)
+	}
+}
+
+func TestClientRestart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/team/job/app/1234/restart/restart":
+			if r.Method != http.MethodPost {
+				t.Errorf("method = %s, want POST", r.Method)
+			}
+			r.ParseForm()
+			if r.Form.Get("stageName") != "Test" {
+				t.Errorf("stageName = %q", r.Form.Get("stageName"))
+			}
+			var form map[string]string
+			if err := json.Unmarshal([]byte(r.Form.Get("json")), &form); err != nil || form["stageName"] != "Test" {
+				t.Errorf("json = %q", r.Form.Get("json"))
+			}
+			w.WriteHeader(http.StatusFound)
+		case "/queue/api/json":
+			w.Write([]byte(`{"items": [
+				{"id": 10, "task": {"url": "http://jenkins/job/team/job/app/"},
+				 "actions": [{"causes": [{"_class": "hudson.model.Cause$UserIdCause"}]}]},
+				{"id": 11, "task": {"url": "http://jenkins/job/other/"},
+				 "actions": [{"causes": [{"_class": "` + RestartCauseClass + `"}]}]},
+				{"id": 12, "task": {"url": "http://jenkins/job/team/job/app/"},
+				 "actions": [{"causes": [{"_class": "` + RestartCauseClass + `"}]}]}
+			]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		Host:    server.URL,
+		User:    "test",
+		APIKey:  "test",
+		Verbose: mockVerbose,
+	})
+
+	if err := client.RestartFromStage("team/app", "1234", "Test"); err != nil {
+		t.Fatalf("RestartFromStage failed: %v", err)
+	}
+
+	item, err := client.FindQueuedBuild("team/app", RestartCauseClass)
+	if err != nil {
+		t.Fatalf("FindQueuedBuild failed: %v", err)
+	}
+	if item == nil || item.ID != 12 {
+		t.Errorf("FindQueuedBuild() = %+v, want item 12", item)
+	}
+
+	item, err = client.FindQueuedBuild("team/app", ReplayCauseClass)
+	if err != nil || item != nil {
+		t.Errorf("FindQueuedBuild() = %+v, %v, want nil", item, err)
+	}
+}
@@ -0,0 +1,146 @@
+package jenkins
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Test case statuses reported by the JUnit plugin
+const (
+	TestPassed     = "PASSED"
+	TestFixed      = "FIXED"
+	TestSkipped    = "SKIPPED"
+	TestFailed     = "FAILED"
+	TestRegression = "REGRESSION"
+)
+
+// TestReport is the JUnit test result Jenkins parsed for a build
+type TestReport struct {
+	Duration  float64
+	FailCount int
+	PassCount int
+	SkipCount int
+	// TotalCount is only set on aggregated reports, which have no PassCount
+	TotalCount int
+	Suites     []TestSuite
+	// ChildReports holds the results of aggregated reports, whose counts
+	// already include them
+	ChildReports []struct {
+		Result TestReport
+	}
+}
+
+// TestSuite is a suite of test cases in a report
+type TestSuite struct {
+	Name     string
+	Duration float64
+	Cases    []TestCase
+}
+
+// TestCase is the result of a single test
+type TestCase struct {
+	ClassName       string
+	Name            string
+	Duration        float64
+	Status          string
+	ErrorDetails    string
+	ErrorStackTrace string
+	// Age is the number of builds the test has been failing for
+	Age int
+	// FailedSince is the number of the build the test started failing in
+	FailedSince int
+	Skipped     bool
+}
+
+// FullName returns the test's class and name
+func (c TestCase) FullName() string {
+	if c.ClassName == "" {
+		return c.Name
+	}
+	return c.ClassName + "." + c.Name
+}
+
+// Failed reports whether the test failed
+func (c TestCase) Failed() bool {
+	return c.Status == TestFailed || c.Status == TestRegression
+}
+
+// Time returns how long the test took
+func (c TestCase) Time() time.Duration {
+	return time.Duration(c.Duration * float64(time.Second))
+}
+
+// Time returns how long the tests took
+func (r TestReport) Time() time.Duration {
+	return time.Duration(r.Duration * float64(time.Second))
+}
+
+// Time returns how long the suite took
+func (s TestSuite) Time() time.Duration {
+	return time.Duration(s.Duration * float64(time.Second))
+}
+
+// AllSuites returns the report's suites, including those of child reports
+func (r TestReport) AllSuites() []TestSuite {
+	suites := append([]TestSuite{}, r.Suites...)
+	for _, child := range r.ChildReports {
+		suites = append(suites, child.Result.AllSuites()...)
+	}
+	return suites
+}
+
+// Counts returns the numbers of passed, failed and skipped tests, including
+// those of child reports
+func (r TestReport) Counts() (passed, failed, skipped int) {
+	if len(r.ChildReports) == 0 {
+		return r.PassCount, r.FailCount, r.SkipCount
+	}
+	// An aggregated report's totals already sum its children
+	if r.TotalCount > 0 {
+		return r.TotalCount - r.FailCount - r.SkipCount, r.FailCount, r.SkipCount
+	}
+	for _, child := range r.ChildReports {
+		p, f, s := child.Result.Counts()
+		passed, failed, skipped = passed+p, failed+f, skipped+s
+	}
+	return passed, failed, skipped
+}
+
+// FailedCases returns every failed test case in suite order
+func (r TestReport) FailedCases() []TestCase {
+	failed := []TestCase{}
+	for _, s := range r.AllSuites() {
+		for _, c := range s.Cases {
+			if c.Failed() {
+				failed = append(failed, c)
+			}
+		}
+	}
+	return failed
+}
+
+// GetTestReport retrieves the test results of a build. Returns an error
+// wrapping ErrNotFound if the build has no test report.
+func (c *Client) GetTestReport(pipeline, buildID string) (*TestReport, error) {
+	path := fmt.Sprintf("%s/%s/testReport/api/json", JobPath(pipeline), buildID)
+	res, err := c.Request(http.MethodGet, path)
+	if err != nil {
+		c.log("Request error")
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := checkStatus(res, path); err != nil {
+		return nil, err
+	}
+
+	var report TestReport
+	if err := json.NewDecoder(res.Body).Decode(&report); err != nil {
+		c.log("JSON decode error")
+		return nil, err
+	}
+
+	return &report, nil
+}
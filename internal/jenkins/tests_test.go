@@ -0,0 +1,83 @@
+package jenkins
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testReport = `{
+	"_class": "hudson.tasks.junit.TestResult",
+	"duration": 12.5, "failCount": 2, "passCount": 3, "skipCount": 1,
+	"suites": [{
+		"name": "com.example.ApiTest", "duration": 10.25,
+		"cases": [
+			{"className": "com.example.ApiTest", "name": "testGet", "duration": 0.5, "status": "PASSED"},
+			{"className": "com.example.ApiTest", "name": "testPost", "duration": 9.75, "status": "REGRESSION",
+			 "errorDetails": "expected 200 but was 500", "errorStackTrace": "java.lang.AssertionError\n\tat ApiTest.testPost", "age": 1, "failedSince": 1234},
+			{"className": "com.example.ApiTest", "name": "testPut", "duration": 0, "status": "SKIPPED", "skipped": true}
+		]
+	}, {
+		"name": "com.example.DbTest", "duration": 2.25,
+		"cases": [
+			{"className": "com.example.DbTest", "name": "testRead", "duration": 1, "status": "FIXED"},
+			{"className": "com.example.DbTest", "name": "testWrite", "duration": 1.25, "status": "FAILED", "errorDetails": "timeout", "age": 3, "failedSince": 1232},
+			{"className": "com.example.DbTest", "name": "testDelete", "duration": 0, "status": "PASSED"}
+		]
+	}]
+}`
+
+func TestClientGetTestReport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job/master/1234/testReport/api/json":
+			w.Write([]byte(testReport))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{Host: server.URL, User: "test", APIKey: "test", Verbose: mockVerbose})
+
+	report, err := client.GetTestReport("master", "1234")
+	if err != nil {
+		t.Fatalf("GetTestReport failed: %v", err)
+	}
+	if passed, failed, skipped := report.Counts(); passed != 3 || failed != 2 || skipped != 1 {
+		t.Errorf("Counts() = %d, %d, %d", passed, failed, skipped)
+	}
+
+	failed := report.FailedCases()
+	if len(failed) != 2 || failed[0].FullName() != "com.example.ApiTest.testPost" || failed[1].Age != 3 {
+		t.Fatalf("FailedCases() = %+v", failed)
+	}
+	if failed[0].Time() != 9750*time.Millisecond || failed[0].FailedSince != 1234 {
+		t.Errorf("testPost = %+v", failed[0])
+	}
+
+	if _, err := client.GetTestReport("master", "1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetTestReport() for a build without tests = %v, want ErrNotFound", err)
+	}
+}
+
+func TestTestReportChildReports(t *testing.T) {
+	child := TestReport{PassCount: 2, FailCount: 1, SkipCount: 1, Suites: []TestSuite{{Name: "child", Cases: []TestCase{{Name: "t", Status: TestFailed}}}}}
+	// Aggregated reports carry totals that include their children
+	report := TestReport{TotalCount: 4, FailCount: 1, SkipCount: 1}
+	report.ChildReports = append(report.ChildReports, struct{ Result TestReport }{child})
+
+	if passed, failed, skipped := report.Counts(); passed != 2 || failed != 1 || skipped != 1 {
+		t.Errorf("Counts() = %d, %d, %d", passed, failed, skipped)
+	}
+	// Without totals, the children are summed
+	report.TotalCount, report.FailCount, report.SkipCount = 0, 0, 0
+	if passed, failed, skipped := report.Counts(); passed != 2 || failed != 1 || skipped != 1 {
+		t.Errorf("Counts() without totals = %d, %d, %d", passed, failed, skipped)
+	}
+	if len(report.AllSuites()) != 1 || len(report.FailedCases()) != 1 {
+		t.Errorf("child suites were not included")
+	}
+}
@@ -4,6 +4,7 @@ package jenkins
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -87,12 +88,163 @@ type WorkflowRun struct {
 	URL               string
 	Description       string
 	Building          bool
+	ChangeSets        []ChangeSet
+	// Culprits are the authors of the changes since the last successful
+	// build, as far as Jenkins can tell
+	Culprits []ChangeSetAuthor
+}
+
+// Parameters returns the run's build parameters as strings keyed by name
+func (r WorkflowRun) Parameters() map[string]string {
+	params := map[string]string{}
+	for _, action := range r.Actions {
+		for _, p := range action.Parameters {
+			if p.Value == nil {
+				params[p.Name] = ""
+				continue
+			}
+			params[p.Name] = fmt.Sprint(p.Value)
+		}
+	}
+	return params
+}
+
+// Causes returns every cause recorded for the run
+func (r WorkflowRun) Causes() []Cause {
+	causes := []Cause{}
+	for _, action := range r.Actions {
+		causes = append(causes, action.Causes...)
+	}
+	return causes
+}
+
+// Commits returns the commits of every change set recorded for the run
+func (r WorkflowRun) Commits() []ChangeSetItem {
+	commits := []ChangeSetItem{}
+	for _, cs := range r.ChangeSets {
+		commits = append(commits, cs.Items...)
+	}
+	return commits
+}
+
+// CulpritNames returns the full names of the run's culprits
+func (r WorkflowRun) CulpritNames() []string {
+	names := make([]string, 0, len(r.Culprits))
+	for _, c := range r.Culprits {
+		names = append(names, c.FullName)
+	}
+	return names
+}
+
+// RestartableStages returns the top-level stages the run can be restarted
+// from, or nil if restarting is not available
+func (r WorkflowRun) RestartableStages() []string {
+	for _, action := range r.Actions {
+		if action.RestartEnabled {
+			return action.RestartableStages
+		}
+	}
+	return nil
+}
+
+// TriggeredBy returns the name of the user who started the run, or the first
+// cause's description when it was not started by a user
+func (r WorkflowRun) TriggeredBy() string {
+	causes := r.Causes()
+	for _, c := range causes {
+		if c.UserName != "" {
+			return c.UserName
+		}
+		if c.UserID != "" {
+			return c.UserID
+		}
+	}
+	if len(causes) > 0 {
+		return causes[0].ShortDescription
+	}
+	return ""
+}
+
+// matchesParameters reports whether every filter has exactly that value in
+// the run's parameters
+func (r WorkflowRun) matchesParameters(filters map[string]string) bool {
+	params := r.Parameters()
+	for name, want := range filters {
+		if got, ok := params[name]; !ok || got != want {
+			return false
+		}
+	}
+	return true
 }
 
 // WorkflowAction represents an action taken during a workflow run
 type WorkflowAction struct {
 	Class      string `json:"_class"`
 	Parameters []WorkflowParameter
+	Causes     []Cause
+
+	// Set on the declarative pipeline restart action
+	RestartEnabled    bool
+	RestartableStages []string
+}
+
+// ChangeSet lists the SCM commits a run picked up since the previous run
+type ChangeSet struct {
+	Class string `json:"_class"`
+	Kind  string
+	Items []ChangeSetItem
+}
+
+// ChangeSetItem is a single commit in a change set
+type ChangeSetItem struct {
+	CommitID      string `json:"commitId"`
+	Msg           string
+	Comment       string
+	Author        ChangeSetAuthor
+	AuthorEmail   string
+	Timestamp     Timestamp
+	AffectedPaths []string
+	// Paths lists the affected paths with how they were changed
+	Paths []ChangeSetPath
+}
+
+// ChangeSetPath is a file changed by a commit
+type ChangeSetPath struct {
+	// EditType is add, edit or delete
+	EditType string
+	File     string
+}
+
+// Subject returns the first line of the commit message
+func (i ChangeSetItem) Subject() string {
+	msg := strings.TrimSpace(i.Msg)
+	if msg == "" {
+		msg = strings.TrimSpace(i.Comment)
+	}
+	subject, _, _ := strings.Cut(msg, "\n")
+	return subject
+}
+
+// ShortID returns the first 8 characters of the commit ID
+func (i ChangeSetItem) ShortID() string {
+	if len(i.CommitID) > 8 {
+		return i.CommitID[:8]
+	}
+	return i.CommitID
+}
+
+// ChangeSetAuthor is the Jenkins user an SCM author maps to
+type ChangeSetAuthor struct {
+	FullName    string
+	AbsoluteURL string `json:"absoluteUrl"`
+}
+
+// Cause describes why a build was started
+type Cause struct {
+	Class            string `json:"_class"`
+	ShortDescription string
+	UserID           string `json:"userId"`
+	UserName         string
 }
 
 // WorkflowParameter represents a parameter passed to a workflow
@@ -102,6 +254,25 @@ type WorkflowParameter struct {
 	Value any
 }
 
+// ParameterDefinition describes a build parameter declared by a job
+type ParameterDefinition struct {
+	Class                 string `json:"_class"`
+	Name                  string
+	Type                  string
+	Description           string
+	Choices               []string
+	DefaultParameterValue *WorkflowParameter
+}
+
+// Default returns the definition's default value as a string, or an empty
+// string if it has none
+func (d ParameterDefinition) Default() string {
+	if d.DefaultParameterValue == nil || d.DefaultParameterValue.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(d.DefaultParameterValue.Value)
+}
+
 // WorkflowJob represents a Jenkins workflow job with multiple builds
 type WorkflowJob struct {
 	Class  string `json:"_class"`
@@ -129,4 +300,79 @@ type ExecutableItem struct {
 type QueueItem struct {
 	ID         int
 	Executable ExecutableItem
+	Cancelled  bool
+	Why        string
+	Task       QueueTask
+	Actions    []WorkflowAction
+}
+
+// QueueTask identifies the job a queue item will run
+type QueueTask struct {
+	Class string `json:"_class"`
+	Name  string
+	URL   string
+}
+
+// HasCause reports whether any of the item's causes has the given class
+func (q QueueItem) HasCause(class string) bool {
+	for _, action := range q.Actions {
+		for _, c := range action.Causes {
+			if c.Class == class {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// User describes the identity returned by the whoAmI endpoint
+type User struct {
+	Class         string `json:"_class"`
+	Name          string
+	Anonymous     bool
+	Authenticated bool
+	Authorities   []string
+}
+
+// BuildSummary is the abbreviated build reference embedded in job listings
+type BuildSummary struct {
+	Number    int
+	Result    string
+	Timestamp Timestamp
+	Building  bool
+}
+
+// JobSummary is an entry in a folder's job listing
+type JobSummary struct {
+	Class       string `json:"_class"`
+	Name        string
+	FullName    string
+	URL         string
+	Color       string
+	Description string
+	LastBuild   *BuildSummary
+}
+
+// IsFolder reports whether the job contains other jobs (folders, multibranch
+// projects and organization folders)
+func (j JobSummary) IsFolder() bool {
+	return strings.HasSuffix(j.Class, ".Folder") ||
+		strings.HasSuffix(j.Class, "MultiBranchProject") ||
+		strings.HasSuffix(j.Class, "OrganizationFolder")
+}
+
+// Kind returns a short, human-readable description of the job type
+func (j JobSummary) Kind() string {
+	switch {
+	case strings.HasSuffix(j.Class, "OrganizationFolder"):
+		return "organization"
+	case strings.HasSuffix(j.Class, "MultiBranchProject"):
+		return "multibranch"
+	case strings.HasSuffix(j.Class, ".Folder"):
+		return "folder"
+	case strings.HasSuffix(j.Class, "WorkflowJob"):
+		return "pipeline"
+	default:
+		return "job"
+	}
 }
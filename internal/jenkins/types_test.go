@@ -199,3 +199,89 @@ func TestStageLinks(t *testing.T) {
 		t.Errorf("stage.Links.Log.HREF = %s, want /job/test/1/node/5/wfapi/log", stage.Links.Log.HREF)
 	}
 }
+
+func TestJobSummaryKind(t *testing.T) {
+	tests := []struct {
+		class    string
+		kind     string
+		isFolder bool
+	}{
+		{"com.cloudbees.hudson.plugins.folder.Folder", "folder", true},
+		{"org.jenkinsci.plugins.workflow.multibranch.WorkflowMultiBranchProject", "multibranch", true},
+		{"jenkins.branch.OrganizationFolder", "organization", true},
+		{"org.jenkinsci.plugins.workflow.job.WorkflowJob", "pipeline", false},
+		{"hudson.model.FreeStyleProject", "job", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.class, func(t *testing.T) {
+			j := JobSummary{Class: tt.class}
+			if j.Kind() != tt.kind {
+				t.Errorf("Kind() = %s, want %s", j.Kind(), tt.kind)
+			}
+			if j.IsFolder() != tt.isFolder {
+				t.Errorf("IsFolder() = %v, want %v", j.IsFolder(), tt.isFolder)
+			}
+		})
+	}
+}
+
+func TestWorkflowRunParameters(t *testing.T) {
+	run := WorkflowRun{
+		Actions: []WorkflowAction{
+			{Class: "hudson.model.CauseAction"},
+			{Parameters: []WorkflowParameter{
+				{Name: "PRODUCT", Value: "ingredi"},
+				{Name: "CLEAN", Value: true},
+				{Name: "EMPTY", Value: nil},
+			}},
+		},
+	}
+
+	params := run.Parameters()
+	expected := map[string]string{"PRODUCT": "ingredi", "CLEAN": "true", "EMPTY": ""}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Errorf("params[%s] = %q, want %q", k, params[k], v)
+		}
+	}
+
+	if !run.matchesParameters(map[string]string{"PRODUCT": "ingredi", "CLEAN": "true"}) {
+		t.Error("run should match its own parameters")
+	}
+	if run.matchesParameters(map[string]string{"PRODUCT": "bpam"}) {
+		t.Error("run should not match a different value")
+	}
+	if run.matchesParameters(map[string]string{"MISSING": ""}) {
+		t.Error("run should not match a parameter it does not have")
+	}
+}
+
+func TestWorkflowRunCommits(t *testing.T) {
+	data := `{"id":"12","changeSets":[{"_class":"hudson.plugins.git.GitChangeSetList","kind":"git","items":[
+		{"commitId":"0123456789abcdef","msg":"Fix the build","author":{"fullName":"Alice"},"timestamp":1700000000000,"affectedPaths":["main.go"],
+		 "paths":[{"editType":"edit","file":"main.go"}]},
+		{"commitId":"abc","msg":"","comment":"Short\n\nLonger description","author":{"fullName":"Bob"}}]}],
+		"culprits":[{"absoluteUrl":"https://jenkins.example.com/user/alice","fullName":"Alice"},{"fullName":"Bob"}]}`
+
+	var run WorkflowRun
+	if err := json.Unmarshal([]byte(data), &run); err != nil {
+		t.Fatal(err)
+	}
+	commits := run.Commits()
+	if len(commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(commits))
+	}
+	if c := commits[0]; c.ShortID() != "01234567" || c.Author.FullName != "Alice" || c.Timestamp.Unix() != 1700000000 || c.AffectedPaths[0] != "main.go" {
+		t.Errorf("first commit = %+v", c)
+	}
+	if commits[1].ShortID() != "abc" {
+		t.Errorf("ShortID() = %q, want abc", commits[1].ShortID())
+	}
+	if commits[0].Paths[0].EditType != "edit" || commits[0].Subject() != "Fix the build" || commits[1].Subject() != "Short" {
+		t.Errorf("paths and subjects = %+v, %q, %q", commits[0].Paths, commits[0].Subject(), commits[1].Subject())
+	}
+	if names := run.CulpritNames(); len(names) != 2 || names[0] != "Alice" || run.Culprits[0].AbsoluteURL == "" {
+		t.Errorf("culprits = %+v", run.Culprits)
+	}
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// secretEnv lists the variables holding credentials, which commands don't get
+var secretEnv = []string{"JENKINS_KEY", "JENKINS_PASSPHRASE"}
+
+// CommandNotifier runs a command through the system shell with the event in
+// JENKINS_* environment variables
+type CommandNotifier struct {
+	Command string
+}
+
+// Notify runs the command and waits for it to finish
+func (n *CommandNotifier) Notify(e Event) error {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	cmd := exec.Command(shell, flag, n.Command)
+	cmd.Env = append(Environ(), Env(e)...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("notify command [%s] failed: %w: %s", n.Command, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Environ returns the process environment without the variables holding
+// credentials, for processes that must not see them
+func Environ() []string {
+	return slices.DeleteFunc(os.Environ(), func(kv string) bool {
+		name, _, _ := strings.Cut(kv, "=")
+		return slices.Contains(secretEnv, name)
+	})
+}
+
+// Env returns the event as KEY=VALUE environment variables
+func Env(e Event) []string {
+	return []string{
+		"JENKINS_EVENT=" + e.Kind,
+		"JENKINS_PIPELINE=" + e.Pipeline,
+		"JENKINS_BUILD_ID=" + e.BuildID,
+		"JENKINS_BUILD_NAME=" + e.DisplayName,
+		"JENKINS_RESULT=" + e.Result,
+		"JENKINS_BUILD_URL=" + e.URL,
+		"JENKINS_DURATION=" + strconv.Itoa(int(e.Duration.Seconds())),
+		"JENKINS_MESSAGE=" + e.Summary(),
+	}
+}
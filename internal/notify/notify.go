@@ -0,0 +1,43 @@
+// Package notify delivers build notifications to configurable sinks.
+package notify
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event kinds
+const (
+	// EventFinished is sent when a build completes
+	EventFinished = "finished"
+	// EventInput is sent when a build starts waiting for input
+	EventInput = "input"
+)
+
+// Event describes something that happened to a monitored build
+type Event struct {
+	Kind        string        `json:"event"`
+	Pipeline    string        `json:"pipeline"`
+	BuildID     string        `json:"build_id"`
+	DisplayName string        `json:"display_name"`
+	Result      string        `json:"result,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	Duration    time.Duration `json:"-"`
+	// Message carries extra detail, such as the input step's message
+	Message string `json:"message,omitempty"`
+}
+
+// Summary returns a one-line human readable description of the event
+func (e Event) Summary() string {
+	switch e.Kind {
+	case EventInput:
+		return fmt.Sprintf("Build %s [%s] on %s is waiting for input: %s", e.BuildID, e.DisplayName, e.Pipeline, e.Message)
+	default:
+		return fmt.Sprintf("Build %s [%s] on %s finished: %s", e.BuildID, e.DisplayName, e.Pipeline, e.Result)
+	}
+}
+
+// Notifier delivers events to a single sink
+type Notifier interface {
+	Notify(e Event) error
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEvent = Event{
+	Kind:        EventFinished,
+	Pipeline:    "master",
+	BuildID:     "42",
+	DisplayName: "#42",
+	Result:      "FAILURE",
+	URL:         "https://jenkins/job/master/42/",
+	Duration:    90 * time.Second,
+}
+
+func TestEventSummary(t *testing.T) {
+	if got := testEvent.Summary(); got != "Build 42 [#42] on master finished: FAILURE" {
+		t.Errorf("Summary() = %q", got)
+	}
+
+	input := testEvent
+	input.Kind, input.Message = EventInput, "Deploy?"
+	if got := input.Summary(); !strings.HasSuffix(got, "is waiting for input: Deploy?") {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestCommandNotifier(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	t.Setenv("JENKINS_KEY", "secret")
+	t.Setenv("JENKINS_PASSPHRASE", "secret")
+
+	out := filepath.Join(t.TempDir(), "out")
+	n := &CommandNotifier{Command: `echo "$JENKINS_BUILD_ID $JENKINS_RESULT $JENKINS_DURATION$JENKINS_KEY$JENKINS_PASSPHRASE" > ` + out}
+	if err := n.Notify(testEvent); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(got)) != "42 FAILURE 90" {
+		t.Errorf("command saw %q", got)
+	}
+
+	if err := (&CommandNotifier{Command: "exit 3"}).Notify(testEvent); err == nil {
+		t.Error("expected error from failing command")
+	}
+}
+
+func TestWebhookNotifiers(t *testing.T) {
+	var body map[string]any
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(data, &body)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	webhook := &WebhookNotifier{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer x"}}
+	if err := webhook.Notify(testEvent); err != nil {
+		t.Fatalf("webhook Notify failed: %v", err)
+	}
+	if body["build_id"] != "42" || body["result"] != "FAILURE" || body["duration_seconds"] != float64(90) || body["event"] != "finished" {
+		t.Errorf("webhook body = %v", body)
+	}
+	if auth != "Bearer x" {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	chat := &ChatNotifier{URL: server.URL}
+	if err := chat.Notify(testEvent); err != nil {
+		t.Fatalf("chat Notify failed: %v", err)
+	}
+	if text, _ := body["text"].(string); !strings.Contains(text, "finished: FAILURE") || !strings.Contains(text, testEvent.URL) {
+		t.Errorf("chat body = %v", body)
+	}
+
+	if err := (&ChatNotifier{URL: server.URL + "/fail"}).Notify(testEvent); err == nil {
+		t.Error("expected error for a failing webhook")
+	}
+}
+
+func TestPipeNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	n := &PipeNotifier{Path: path}
+	for range 2 {
+		if err := n.Notify(testEvent); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	var e Event
+	if err := json.Unmarshal([]byte(lines[0]), &e); err != nil || e.BuildID != "42" {
+		t.Errorf("line = %q (%v)", lines[0], err)
+	}
+
+	if err := (&PipeNotifier{Path: filepath.Join(t.TempDir(), "missing")}).Notify(testEvent); err == nil {
+		t.Error("expected error for a missing pipe")
+	}
+}
+
+func TestBellNotifier(t *testing.T) {
+	var out bytes.Buffer
+	if err := (&BellNotifier{Out: &out}).Notify(testEvent); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "\a" {
+		t.Errorf("bell wrote %q", out.String())
+	}
+}
@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+)
+
+// PipeNotifier writes each event as a line of JSON to a named pipe or file.
+// Writing to a pipe nobody is reading fails instead of blocking.
+type PipeNotifier struct {
+	Path string
+}
+
+// Notify appends the event to the pipe
+func (n *PipeNotifier) Notify(e Event) error {
+	f, err := os.OpenFile(n.Path, os.O_WRONLY|os.O_APPEND|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", n.Path, err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// BellNotifier rings the terminal bell
+type BellNotifier struct {
+	Out io.Writer
+}
+
+// Notify writes the BEL character
+func (n *BellNotifier) Notify(Event) error {
+	_, err := fmt.Fprint(n.Out, "\a")
+	return err
+}
@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WebhookTimeout bounds how long a webhook request may take
+const WebhookTimeout = 10 * time.Second
+
+// WebhookNotifier posts the event as JSON to a URL
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+}
+
+// Notify posts the event with a duration in seconds and a summary
+func (n *WebhookNotifier) Notify(e Event) error {
+	payload := struct {
+		Event
+		Duration int    `json:"duration_seconds"`
+		Summary  string `json:"summary"`
+	}{e, int(e.Duration.Seconds()), e.Summary()}
+	return postJSON(n.URL, n.Headers, payload)
+}
+
+// ChatNotifier posts the event summary to a Slack or Microsoft Teams
+// compatible incoming webhook
+type ChatNotifier struct {
+	URL string
+}
+
+// Notify posts the summary, linking to the build when its URL is known
+func (n *ChatNotifier) Notify(e Event) error {
+	text := e.Summary()
+	if e.URL != "" {
+		text = fmt.Sprintf("%s\n%s", text, e.URL)
+	}
+	return postJSON(n.URL, nil, map[string]string{"text": text})
+}
+
+func postJSON(url string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	client := &http.Client{Timeout: WebhookTimeout}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned %s", res.Status)
+	}
+	return nil
+}
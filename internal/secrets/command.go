@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// AccountEnv is the environment variable holding the account name for key commands
+const AccountEnv = "JENKINS_ACCOUNT"
+
+// CommandBackend delegates secret storage to external commands, typically a
+// password manager CLI. Each command is run through the system shell with the
+// account name in JENKINS_ACCOUNT.
+type CommandBackend struct {
+	// GetCommand prints the secret on stdout
+	GetCommand string
+	// StoreCommand reads the secret from stdin
+	StoreCommand string
+	// EraseCommand removes the secret
+	EraseCommand string
+}
+
+// Get runs GetCommand and returns its trimmed output. A command that fails
+// or prints nothing means no secret is stored.
+func (b *CommandBackend) Get(account string) (string, error) {
+	if b.GetCommand == "" {
+		return "", errors.New("no get command configured")
+	}
+
+	out, err := b.run(b.GetCommand, account, "")
+	if err != nil {
+		return "", notFound(err)
+	}
+
+	secret := strings.TrimSpace(out)
+	if secret == "" {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set runs StoreCommand with the secret on stdin
+func (b *CommandBackend) Set(account, secret string) error {
+	if b.StoreCommand == "" {
+		return errors.New("no store command configured")
+	}
+	_, err := b.run(b.StoreCommand, account, secret+"\n")
+	return err
+}
+
+// Delete runs EraseCommand. A command that fails means no secret was stored.
+func (b *CommandBackend) Delete(account string) error {
+	if b.EraseCommand == "" {
+		return errors.New("no erase command configured")
+	}
+	_, err := b.run(b.EraseCommand, account, "")
+	return notFound(err)
+}
+
+// notFound turns a key command that exited non-zero into ErrNotFound, which
+// is how password managers report a missing entry. Commands that could not
+// be run at all keep their error.
+func notFound(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("%w (%v)", ErrNotFound, err)
+	}
+	return err
+}
+
+func (b *CommandBackend) run(command, account, stdin string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	cmd := exec.Command(shell, flag, command)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", AccountEnv, account))
+	cmd.Stdin = strings.NewReader(stdin)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("key command [%s] failed: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCommandBackend(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("key command tests use a POSIX shell")
+	}
+
+	store := filepath.Join(t.TempDir(), "store")
+	b := &CommandBackend{
+		GetCommand:   fmt.Sprintf(`cat "%s.$JENKINS_ACCOUNT"`, store),
+		StoreCommand: fmt.Sprintf(`cat > "%s.$JENKINS_ACCOUNT"`, store),
+		EraseCommand: fmt.Sprintf(`rm "%s.$JENKINS_ACCOUNT"`, store),
+	}
+
+	if _, err := b.Get("alice"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() before Set() error = %v, want ErrNotFound", err)
+	}
+
+	if err := b.Set("alice", "s3cret"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if _, err := os.Stat(store + ".alice"); err != nil {
+		t.Fatalf("store command did not receive account: %v", err)
+	}
+
+	secret, err := b.Get("alice")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if secret != "s3cret" {
+		t.Errorf("Get() = %q, want s3cret", secret)
+	}
+
+	if err := b.Delete("alice"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if err := b.Delete("alice"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() of a missing secret error = %v, want ErrNotFound", err)
+	}
+
+	// A command that prints nothing has nothing stored either
+	empty := &CommandBackend{GetCommand: "true"}
+	if _, err := empty.Get("alice"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() with empty output error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCommandBackendMissingCommands(t *testing.T) {
+	b := &CommandBackend{}
+
+	if _, err := b.Get("alice"); err == nil {
+		t.Error("Get() without a get command should fail")
+	}
+	if err := b.Set("alice", "s3cret"); err == nil {
+		t.Error("Set() without a store command should fail")
+	}
+	if err := b.Delete("alice"); err == nil {
+		t.Error("Delete() without an erase command should fail")
+	}
+}
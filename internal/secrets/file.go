@@ -0,0 +1,171 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// kdfIterations is the PBKDF2 iteration count used to derive the file key
+	kdfIterations = 100_000
+	saltSize      = 16
+	keySize       = 32
+)
+
+// ErrBadPassphrase is returned when the credentials file cannot be decrypted
+var ErrBadPassphrase = errors.New("incorrect passphrase or corrupt credentials file")
+
+// FileBackend stores secrets in a single AES-GCM encrypted file. The
+// encryption key is derived from a passphrase that is only requested when
+// the file actually needs to be read or written.
+type FileBackend struct {
+	Path       string
+	Passphrase func() (string, error)
+}
+
+// encryptedFile is the on-disk format of the credentials file
+type encryptedFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// Get decrypts the file and returns the secret for account
+func (b *FileBackend) Get(account string) (string, error) {
+	secrets, _, err := b.load()
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := secrets[account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set stores the secret for account, creating the file if needed
+func (b *FileBackend) Set(account, secret string) error {
+	secrets, passphrase, err := b.load()
+	if errors.Is(err, ErrNotFound) {
+		secrets = map[string]string{}
+	} else if err != nil {
+		return err
+	}
+
+	if passphrase == "" {
+		if passphrase, err = b.Passphrase(); err != nil {
+			return err
+		}
+	}
+
+	secrets[account] = secret
+	return b.save(secrets, passphrase)
+}
+
+// Delete removes the secret for account, removing the file when it is empty
+func (b *FileBackend) Delete(account string) error {
+	secrets, passphrase, err := b.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := secrets[account]; !ok {
+		return ErrNotFound
+	}
+	delete(secrets, account)
+
+	if len(secrets) == 0 {
+		return os.Remove(b.Path)
+	}
+	return b.save(secrets, passphrase)
+}
+
+// load reads and decrypts the file, returning the passphrase that opened it
+func (b *FileBackend) load() (map[string]string, string, error) {
+	raw, err := os.ReadFile(b.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", ErrNotFound
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, "", fmt.Errorf("failed to parse credentials file %s: %w", b.Path, err)
+	}
+
+	passphrase, err := b.Passphrase()
+	if err != nil {
+		return nil, "", err
+	}
+
+	gcm, err := newGCM(passphrase, file.Salt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, "", ErrBadPassphrase
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, "", fmt.Errorf("failed to parse decrypted credentials: %w", err)
+	}
+	return secrets, passphrase, nil
+}
+
+func (b *FileBackend) save(secrets map[string]string, passphrase string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(encryptedFile{
+		Salt:  salt,
+		Nonce: nonce,
+		Data:  gcm.Seal(nil, nonce, plain, nil),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(b.Path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(b.Path, raw, 0o600)
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, kdfIterations, keySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func staticPassphrase(p string) func() (string, error) {
+	return func() (string, error) { return p, nil }
+}
+
+func TestFileBackendRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	b := &FileBackend{Path: path, Passphrase: staticPassphrase("hunter2")}
+
+	if _, err := b.Get("alice@jenkins"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() on missing file error = %v, want ErrNotFound", err)
+	}
+
+	if err := b.Set("alice@jenkins", "key-a"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+	if err := b.Set("bob@jenkins", "key-b"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("credentials file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "key-a") {
+		t.Error("credentials file should not contain the plaintext secret")
+	}
+
+	secret, err := b.Get("alice@jenkins")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if secret != "key-a" {
+		t.Errorf("Get() = %s, want key-a", secret)
+	}
+
+	if err := b.Delete("alice@jenkins"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := b.Get("alice@jenkins"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+	}
+
+	if err := b.Delete("bob@jenkins"); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Error("credentials file should be removed once empty")
+	}
+}
+
+func TestFileBackendWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	b := &FileBackend{Path: path, Passphrase: staticPassphrase("right")}
+	if err := b.Set("alice@jenkins", "key-a"); err != nil {
+		t.Fatalf("Set() failed: %v", err)
+	}
+
+	wrong := &FileBackend{Path: path, Passphrase: staticPassphrase("wrong")}
+	if _, err := wrong.Get("alice@jenkins"); !errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("Get() error = %v, want ErrBadPassphrase", err)
+	}
+}
+
+func TestFileBackendPassphraseOnlyWhenNeeded(t *testing.T) {
+	b := &FileBackend{
+		Path: filepath.Join(t.TempDir(), "missing"),
+		Passphrase: func() (string, error) {
+			t.Error("passphrase should not be requested for a missing file")
+			return "", nil
+		},
+	}
+
+	if _, err := b.Get("alice@jenkins"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,17 @@
+// Package secrets provides pluggable storage backends for Jenkins API keys.
+package secrets
+
+import "errors"
+
+// ErrNotFound is returned when a backend has no secret stored for an account
+var ErrNotFound = errors.New("no stored secret for account")
+
+// Backend stores and retrieves secrets keyed by account name
+type Backend interface {
+	// Get returns the secret stored for account, or ErrNotFound
+	Get(account string) (string, error)
+	// Set stores secret for account, replacing any existing value
+	Set(account, secret string) error
+	// Delete removes the secret stored for account, or returns ErrNotFound
+	Delete(account string) error
+}
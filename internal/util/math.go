@@ -1,7 +1,11 @@
 // Package util provides generic utility functions.
 package util
 
-import "golang.org/x/exp/constraints"
+import (
+	"math"
+
+	"golang.org/x/exp/constraints"
+)
 
 // Number is a constraint for numeric types
 type Number interface {
@@ -25,3 +29,18 @@ func Avg[T Number](data []T) float64 {
 func Ptr[Value any](v Value) *Value {
 	return &v
 }
+
+// StdDev calculates the population standard deviation of a slice of numbers.
+// Returns 0 if the slice is empty.
+func StdDev[T Number](data []T) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	avg := Avg(data)
+	var sum float64
+	for _, v := range data {
+		d := float64(v) - avg
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(data)))
+}
@@ -87,6 +87,28 @@ func TestAvgFloat(t *testing.T) {
 	}
 }
 
+func TestStdDev(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []float64
+		expected float64
+	}{
+		{name: "empty slice", data: []float64{}, expected: 0},
+		{name: "single element", data: []float64{5}, expected: 0},
+		{name: "constant values", data: []float64{3, 3, 3}, expected: 0},
+		{name: "spread values", data: []float64{2, 4, 4, 4, 5, 5, 7, 9}, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := StdDev(tt.data)
+			if diff := result - tt.expected; diff > 0.0001 || diff < -0.0001 {
+				t.Errorf("StdDev(%v) = %f, want %f", tt.data, result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestPtr(t *testing.T) {
 	t.Run("int pointer", func(t *testing.T) {
 		value := 42
@@ -0,0 +1,253 @@
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrAlreadyRunning is returned by Run when another daemon owns the socket
+var ErrAlreadyRunning = errors.New("watcher is already running")
+
+// Server is the watcher daemon. It polls each watched build with Check until
+// Check reports it done, and exits once nothing has been watched for
+// IdleTimeout.
+type Server struct {
+	// Host is the Jenkins host Check polls. Builds on any other host are
+	// refused, or dropped when restored from the state file.
+	Host        string
+	Paths       Paths
+	Interval    time.Duration
+	IdleTimeout time.Duration
+	// Check polls a build, updating its Status, and reports whether it is
+	// done and should no longer be watched
+	Check func(b *Build) bool
+	Log   *log.Logger
+
+	mu     sync.Mutex
+	builds []Build
+	stop   chan struct{}
+	forget bool
+}
+
+// Run starts the daemon and blocks until it is stopped, signalled or idle
+func (s *Server) Run() error {
+	if err := os.MkdirAll(s.Paths.Dir, 0o700); err != nil {
+		return err
+	}
+
+	client := &Client{Socket: s.Paths.Socket()}
+	if _, err := client.List(); err == nil {
+		return ErrAlreadyRunning
+	}
+	// Nothing answered, so any socket left behind is stale
+	os.Remove(s.Paths.Socket())
+
+	listener, err := net.Listen("unix", s.Paths.Socket())
+	if err != nil {
+		return err
+	}
+	os.Chmod(s.Paths.Socket(), 0o600)
+
+	if err := os.WriteFile(s.Paths.PID(), []byte(strconv.Itoa(os.Getpid())), 0o600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.stop = make(chan struct{})
+	if err := s.load(); err != nil {
+		s.Log.Printf("Ignoring unreadable state file: %v", err)
+	}
+	s.Log.Printf("Watcher started (pid %d) with %d build(s)", os.Getpid(), len(s.builds))
+
+	go s.accept(listener)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	idleSince := time.Now()
+
+loop:
+	for {
+		if s.poll() > 0 {
+			idleSince = time.Now()
+		} else if time.Since(idleSince) >= s.IdleTimeout {
+			s.Log.Printf("Nothing left to watch, exiting")
+			break
+		}
+
+		select {
+		case <-ticker.C:
+		case sig := <-signals:
+			s.Log.Printf("Received %s, exiting", sig)
+			break loop
+		case <-s.stop:
+			s.Log.Printf("Stop requested, exiting")
+			break loop
+		}
+	}
+
+	listener.Close()
+	os.Remove(s.Paths.Socket())
+	os.Remove(s.Paths.PID())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.forget {
+		s.builds = nil
+	}
+	return s.save()
+}
+
+// poll checks every watched build once and returns how many are left
+func (s *Server) poll() int {
+	s.mu.Lock()
+	builds := slices.Clone(s.builds)
+	s.mu.Unlock()
+
+	for _, b := range builds {
+		done := s.Check(&b)
+
+		s.mu.Lock()
+		idx := slices.IndexFunc(s.builds, func(w Build) bool { return w.Key() == b.Key() })
+		if idx >= 0 {
+			if done {
+				s.builds = slices.Delete(s.builds, idx, idx+1)
+			} else {
+				s.builds[idx].Status = b.Status
+				s.builds[idx].Failures = b.Failures
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.save(); err != nil {
+		s.Log.Printf("Failed to save state: %v", err)
+	}
+	return len(s.builds)
+}
+
+func (s *Server) accept(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			// The listener is closed on shutdown
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(DialTimeout))
+
+	var req Request
+	res := Response{PID: os.Getpid()}
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		res.Error = fmt.Sprintf("invalid request: %v", err)
+		json.NewEncoder(conn).Encode(res)
+		return
+	}
+
+	s.mu.Lock()
+	switch req.Op {
+	case OpAdd:
+		for _, b := range req.Builds {
+			if b.Host != s.Host {
+				res.Error = fmt.Sprintf("watcher polls %s, not %s; stop it to watch builds on another host", s.Host, b.Host)
+				continue
+			}
+			if slices.ContainsFunc(s.builds, func(w Build) bool { return w.Key() == b.Key() }) {
+				continue
+			}
+			if b.Added.IsZero() {
+				b.Added = time.Now()
+			}
+			s.builds = append(s.builds, b)
+			s.Log.Printf("Watching build %s on [%s]", b.ID, b.Pipeline)
+		}
+	case OpRemove:
+		for _, b := range req.Builds {
+			s.builds = slices.DeleteFunc(s.builds, func(w Build) bool { return w.Key() == b.Key() })
+			s.Log.Printf("Stopped watching build %s on [%s]", b.ID, b.Pipeline)
+		}
+	case OpList:
+	case OpStop:
+		if !s.forget {
+			s.forget = true
+			close(s.stop)
+		}
+	default:
+		res.Error = fmt.Sprintf("unknown operation '%s'", req.Op)
+	}
+	if req.Op == OpAdd || req.Op == OpRemove {
+		if err := s.save(); err != nil {
+			s.Log.Printf("Failed to save state: %v", err)
+		}
+	}
+	res.Builds = slices.Clone(s.builds)
+	s.mu.Unlock()
+
+	json.NewEncoder(conn).Encode(res)
+}
+
+// load restores builds saved by a previous daemon. Callers hold no lock
+// since it runs before the socket is served.
+func (s *Server) load() error {
+	data, err := os.ReadFile(s.Paths.State())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var builds []Build
+	if err := json.Unmarshal(data, &builds); err != nil {
+		return err
+	}
+	for _, b := range builds {
+		// Builds saved before hosts were recorded belong to this host
+		if b.Host == "" {
+			b.Host = s.Host
+		}
+		if b.Host != s.Host {
+			s.Log.Printf("Dropping build %s on [%s]: it runs on %s, not %s", b.ID, b.Pipeline, b.Host, s.Host)
+			continue
+		}
+		s.builds = append(s.builds, b)
+	}
+	return nil
+}
+
+// save writes the watched builds to the state file, removing it when there
+// are none. Callers must hold s.mu.
+func (s *Server) save() error {
+	if len(s.builds) == 0 {
+		err := os.Remove(s.Paths.State())
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	data, err := json.MarshalIndent(s.builds, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.Paths.State(), data, 0o600)
+}
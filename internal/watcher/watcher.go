@@ -0,0 +1,123 @@
+// Package watcher implements a background daemon that watches Jenkins builds
+// and the client used to control it over a unix socket.
+package watcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"time"
+)
+
+// Operations understood by the daemon
+const (
+	OpAdd    = "add"
+	OpRemove = "remove"
+	OpList   = "list"
+	OpStop   = "stop"
+)
+
+// DialTimeout bounds how long the client waits to reach the daemon
+const DialTimeout = 2 * time.Second
+
+// ErrNotRunning is returned by the client when no daemon is listening
+var ErrNotRunning = errors.New("watcher is not running")
+
+// Build is a build being watched by the daemon
+type Build struct {
+	// Host is the Jenkins host the build runs on
+	Host     string    `json:"host,omitempty"`
+	Pipeline string    `json:"pipeline"`
+	ID       string    `json:"id"`
+	Notify   []string  `json:"notify,omitempty"`
+	Added    time.Time `json:"added"`
+	// Status is the daemon's last view of the build, for display
+	Status string `json:"status,omitempty"`
+	// Failures counts the checks in a row that could not reach the build
+	Failures int `json:"failures,omitempty"`
+}
+
+// Key identifies the build across hosts and pipelines
+func (b Build) Key() string {
+	return b.Host + "/" + b.Pipeline + "#" + b.ID
+}
+
+// Request is sent by the client to the daemon, one per connection
+type Request struct {
+	Op     string  `json:"op"`
+	Builds []Build `json:"builds,omitempty"`
+}
+
+// Response is the daemon's reply to a Request
+type Response struct {
+	Error  string  `json:"error,omitempty"`
+	PID    int     `json:"pid"`
+	Builds []Build `json:"builds"`
+}
+
+// Paths locates the daemon's files inside its state directory
+type Paths struct {
+	Dir string
+}
+
+// Socket is the unix socket the daemon listens on
+func (p Paths) Socket() string { return filepath.Join(p.Dir, "watcher.sock") }
+
+// PID holds the daemon's process ID while it runs
+func (p Paths) PID() string { return filepath.Join(p.Dir, "watcher.pid") }
+
+// Log receives the daemon's output
+func (p Paths) Log() string { return filepath.Join(p.Dir, "watcher.log") }
+
+// State persists the watched builds so a restarted daemon picks them up again
+func (p Paths) State() string { return filepath.Join(p.Dir, "state.json") }
+
+// Client talks to a running daemon
+type Client struct {
+	Socket string
+}
+
+// Do sends req and returns the daemon's response. Returns ErrNotRunning if
+// the socket can't be reached.
+func (c *Client) Do(req Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.Socket, DialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	var res Response
+	if err := json.NewDecoder(conn).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to read watcher response: %w", err)
+	}
+	if res.Error != "" {
+		return &res, errors.New(res.Error)
+	}
+	return &res, nil
+}
+
+// Add starts watching builds
+func (c *Client) Add(builds ...Build) (*Response, error) {
+	return c.Do(Request{Op: OpAdd, Builds: builds})
+}
+
+// Remove stops watching builds
+func (c *Client) Remove(builds ...Build) (*Response, error) {
+	return c.Do(Request{Op: OpRemove, Builds: builds})
+}
+
+// List returns the watched builds
+func (c *Client) List() (*Response, error) {
+	return c.Do(Request{Op: OpList})
+}
+
+// Stop shuts the daemon down and forgets its builds
+func (c *Client) Stop() (*Response, error) {
+	return c.Do(Request{Op: OpStop})
+}
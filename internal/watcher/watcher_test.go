@@ -0,0 +1,195 @@
+package watcher
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startServer runs a server in the background and waits for it to answer
+func startServer(t *testing.T, s *Server) (*Client, chan error) {
+	t.Helper()
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run() }()
+
+	client := &Client{Socket: s.Paths.Socket()}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := client.List(); err == nil {
+			return client, done
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer(t *testing.T) {
+	// Keep the socket path short enough for unix socket limits
+	dir, err := os.MkdirTemp("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	finished := map[string]bool{}
+	s := &Server{
+		Paths:       Paths{Dir: dir},
+		Interval:    10 * time.Millisecond,
+		IdleTimeout: time.Minute,
+		Log:         log.New(io.Discard, "", 0),
+		Check: func(b *Build) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			b.Status = "running"
+			return finished[b.ID]
+		},
+	}
+
+	client, done := startServer(t, s)
+
+	if _, err := client.Add(Build{Pipeline: "master", ID: "1"}, Build{Pipeline: "master", ID: "2"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	// Adding twice doesn't duplicate
+	res, err := client.Add(Build{Pipeline: "master", ID: "1"})
+	if err != nil || len(res.Builds) != 2 {
+		t.Fatalf("Add = %+v, %v", res, err)
+	}
+	if res.PID != os.Getpid() {
+		t.Errorf("PID = %d, want %d", res.PID, os.Getpid())
+	}
+	if _, err := os.Stat(s.Paths.PID()); err != nil {
+		t.Errorf("pid file missing: %v", err)
+	}
+
+	// A second daemon refuses to start
+	if err := (&Server{Paths: s.Paths, Log: s.Log}).Run(); !errors.Is(err, ErrAlreadyRunning) {
+		t.Errorf("second Run() = %v, want ErrAlreadyRunning", err)
+	}
+
+	mu.Lock()
+	finished["1"] = true
+	mu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		res, err = client.List()
+		if err != nil {
+			t.Fatalf("List failed: %v", err)
+		}
+		if len(res.Builds) == 1 && res.Builds[0].Status == "running" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("finished build still watched: %+v", res.Builds)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if res.Builds[0].ID != "2" || res.Builds[0].Added.IsZero() {
+		t.Errorf("remaining build = %+v", res.Builds[0])
+	}
+
+	if _, err := os.Stat(s.Paths.State()); err != nil {
+		t.Errorf("state file missing: %v", err)
+	}
+
+	if res, err = client.Remove(Build{Pipeline: "master", ID: "2"}); err != nil || len(res.Builds) != 0 {
+		t.Errorf("Remove = %+v, %v", res, err)
+	}
+
+	if _, err := client.Do(Request{Op: "bogus"}); err == nil {
+		t.Error("expected error for unknown operation")
+	}
+
+	if _, err := client.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Run returned %v", err)
+	}
+
+	if _, err := client.List(); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("List after stop = %v, want ErrNotRunning", err)
+	}
+	for _, path := range []string{s.Paths.Socket(), s.Paths.PID(), s.Paths.State()} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s left behind after stop", path)
+		}
+	}
+}
+
+func TestServerResumesState(t *testing.T) {
+	dir, err := os.MkdirTemp("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	paths := Paths{Dir: dir}
+	state := `[{"pipeline": "master", "id": "7"}, {"host": "https://other", "pipeline": "master", "id": "8"}]`
+	if err := os.WriteFile(paths.State(), []byte(state), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		Host:        "https://jenkins",
+		Paths:       paths,
+		Interval:    time.Hour,
+		IdleTimeout: time.Hour,
+		Log:         log.New(io.Discard, "", 0),
+		Check:       func(b *Build) bool { return false },
+	}
+	client, done := startServer(t, s)
+
+	res, err := client.List()
+	// Builds saved without a host are kept, builds on another host dropped
+	if err != nil || len(res.Builds) != 1 || res.Builds[0].ID != "7" || res.Builds[0].Host != s.Host {
+		t.Errorf("List = %+v, %v", res, err)
+	}
+
+	if _, err := client.Add(Build{Host: "https://other", Pipeline: "master", ID: "9"}); err == nil {
+		t.Error("expected error adding a build on another host")
+	}
+	if res, err := client.Add(Build{Host: s.Host, Pipeline: "master", ID: "9"}); err != nil || len(res.Builds) != 2 {
+		t.Errorf("Add = %+v, %v", res, err)
+	}
+
+	client.Stop()
+	<-done
+}
+
+func TestServerExitsWhenIdle(t *testing.T) {
+	dir, err := os.MkdirTemp("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &Server{
+		Paths:       Paths{Dir: dir},
+		Interval:    10 * time.Millisecond,
+		IdleTimeout: 50 * time.Millisecond,
+		Log:         log.New(io.Discard, "", 0),
+		Check:       func(b *Build) bool { return true },
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("idle server did not exit")
+	}
+}